@@ -1,16 +1,21 @@
 package api
 
 import (
+	"context"
+	"net/http"
 	"net/url"
 	"qbit-cli/pkg/utils"
 )
 
-func QbitAppBuildInfo() (*QbitServerInfo, error) {
-	resp, err := GetQbitClient().Get("/api/v2/app/buildInfo", url.Values{})
+func QbitAppBuildInfo(ctx context.Context) (*QbitServerInfo, error) {
+	resp, err := GetQbitClient().Get(ctx, "/api/v2/app/buildInfo", url.Values{})
 	if err != nil {
 		return nil, err
 	}
 	defer utils.SafeClose(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, newQbitClientError("QbitAppBuildInfo", resp, "")
+	}
 	var info QbitServerInfo
 	err = ParseJSON(resp, &info)
 	if err != nil {
@@ -19,12 +24,15 @@ func QbitAppBuildInfo() (*QbitServerInfo, error) {
 	return &info, nil
 }
 
-func QbitApiVersion() (string, error) {
-	resp, err := GetQbitClient().Get("/api/v2/app/webapiVersion", url.Values{})
+func QbitApiVersion(ctx context.Context) (string, error) {
+	resp, err := GetQbitClient().Get(ctx, "/api/v2/app/webapiVersion", url.Values{})
 	if err != nil {
 		return "", err
 	}
 	defer utils.SafeClose(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return "", newQbitClientError("QbitApiVersion", resp, "")
+	}
 	v, err := ParseString(resp)
 	if err != nil {
 		return "", err
@@ -32,12 +40,15 @@ func QbitApiVersion() (string, error) {
 	return v, nil
 }
 
-func QbitAppVersion() (string, error) {
-	resp, err := GetQbitClient().Get("/api/v2/app/version", url.Values{})
+func QbitAppVersion(ctx context.Context) (string, error) {
+	resp, err := GetQbitClient().Get(ctx, "/api/v2/app/version", url.Values{})
 	if err != nil {
 		return "", err
 	}
 	defer utils.SafeClose(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return "", newQbitClientError("QbitAppVersion", resp, "")
+	}
 	v, err := ParseString(resp)
 	if err != nil {
 		return "", err
@@ -45,12 +56,15 @@ func QbitAppVersion() (string, error) {
 	return v, nil
 }
 
-func QbitAppPreference() (string, error) {
-	resp, err := GetQbitClient().Get("/api/v2/app/preferences", url.Values{})
+func QbitAppPreference(ctx context.Context) (string, error) {
+	resp, err := GetQbitClient().Get(ctx, "/api/v2/app/preferences", url.Values{})
 	if err != nil {
 		return "", err
 	}
 	defer utils.SafeClose(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return "", newQbitClientError("QbitAppPreference", resp, "")
+	}
 	json, err := ParseRawJSON(resp)
 	if err != nil {
 		return "", err
@@ -58,13 +72,29 @@ func QbitAppPreference() (string, error) {
 	return json, nil
 }
 
-func QbitSetAppPreference(json string) error {
+func QbitSetAppPreference(ctx context.Context, json string) error {
 	params := url.Values{}
 	params.Set("json", json)
-	resp, err := GetQbitClient().Post("/api/v2/app/setPreferences", params)
+	resp, err := GetQbitClient().Post(ctx, "/api/v2/app/setPreferences", params)
 	if err != nil {
 		return err
 	}
 	defer utils.SafeClose(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return newQbitClientError("QbitSetAppPreference", resp, "")
+	}
 	return nil
 }
+
+// DefaultSavePath returns the default save path of torrents.
+func DefaultSavePath(ctx context.Context) (string, error) {
+	resp, err := GetQbitClient().Get(ctx, "/api/v2/app/defaultSavePath", url.Values{})
+	if err != nil {
+		return "", err
+	}
+	defer utils.SafeClose(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return "", newQbitClientError("DefaultSavePath", resp, "")
+	}
+	return ParseString(resp)
+}
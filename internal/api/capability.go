@@ -0,0 +1,222 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// https://github.com/qbittorrent/qBittorrent/wiki/WebUI-API-(qBittorrent-5.0)
+// WebUI api version is bumped with qBittorrent, e.g. 4.6 ships 2.9.x and 5.0 ships 2.11.x
+
+// ApiVersion is WebUI api version, e.g. 2.11.2
+type ApiVersion struct {
+	Major, Minor, Patch int
+}
+
+func ParseApiVersion(v string) (ApiVersion, error) {
+	var version ApiVersion
+	parts := strings.Split(strings.TrimPrefix(strings.TrimSpace(v), "v"), ".")
+	if len(parts) < 2 || len(parts) > 3 {
+		return version, fmt.Errorf("invalid api version %q", v)
+	}
+	nums := []*int{&version.Major, &version.Minor, &version.Patch}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return version, fmt.Errorf("invalid api version %q", v)
+		}
+		*nums[i] = n
+	}
+	return version, nil
+}
+
+func mustParseApiVersion(v string) ApiVersion {
+	version, err := ParseApiVersion(v)
+	if err != nil {
+		panic(err)
+	}
+	return version
+}
+
+func (v ApiVersion) Less(o ApiVersion) bool {
+	if v.Major != o.Major {
+		return v.Major < o.Major
+	}
+	if v.Minor != o.Minor {
+		return v.Minor < o.Minor
+	}
+	return v.Patch < o.Patch
+}
+
+func (v ApiVersion) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// Feature is an api behavior which is not available on all servers
+type Feature string
+
+const (
+	// FeatureStopStart torrents/stop and torrents/start, older servers use torrents/pause and torrents/resume
+	FeatureStopStart Feature = "torrents/stop"
+	// FeatureRenameFolder torrents/renameFolder, renameFile with oldPath and newPath
+	FeatureRenameFolder Feature = "torrents/renameFolder"
+	// FeatureFileIndex index field of torrents/files which is used by torrents/filePrio
+	FeatureFileIndex Feature = "torrents/files index"
+	// FeatureExport torrents/export which returns the .torrent file
+	FeatureExport Feature = "torrents/export"
+	// FeatureInactiveSeedingTime inactiveSeedingTimeLimit of torrents/setShareLimits
+	FeatureInactiveSeedingTime Feature = "torrents/setShareLimits inactiveSeedingTimeLimit"
+	// FeatureShareLimitAction shareLimitAction of torrents/setShareLimits
+	FeatureShareLimitAction Feature = "torrents/setShareLimits shareLimitAction"
+)
+
+// capabilities is the min api version of features
+var capabilities = map[Feature]ApiVersion{
+	FeatureStopStart:    mustParseApiVersion("2.11.0"),
+	FeatureRenameFolder: mustParseApiVersion("2.8.0"),
+	FeatureFileIndex:    mustParseApiVersion("2.8.2"),
+	FeatureExport:       mustParseApiVersion("2.8.14"),
+
+	FeatureInactiveSeedingTime: mustParseApiVersion("2.9.2"),
+	FeatureShareLimitAction:    mustParseApiVersion("2.11.4"),
+}
+
+// legacyEndpoints maps endpoint to its name on servers without the feature
+var legacyEndpoints = map[string]struct {
+	feature Feature
+	name    string
+}{
+	"stop":  {FeatureStopStart, "pause"},
+	"start": {FeatureStopStart, "resume"},
+}
+
+// legacyStateFilters maps /torrents/info filter to its name on servers without the feature
+var legacyStateFilters = map[string]struct {
+	feature Feature
+	name    string
+}{
+	"stopped": {FeatureStopStart, "paused"},
+	"running": {FeatureStopStart, "resumed"},
+}
+
+// legacyAddParams maps /torrents/add param to its name on servers without the feature
+var legacyAddParams = map[string]struct {
+	feature Feature
+	name    string
+}{
+	"stopped": {FeatureStopStart, "paused"},
+}
+
+// ErrUnsupported is the kind of UnsupportedError
+var ErrUnsupported = errors.New("unsupported by server")
+
+// UnsupportedError is returned when server api version is lower than the feature requires.
+type UnsupportedError struct {
+	Feature  Feature
+	Required ApiVersion
+	Server   ApiVersion
+}
+
+func (e *UnsupportedError) Error() string {
+	return fmt.Sprintf("%s requires WebUI API %s, server is %s", e.Feature, e.Required, e.Server)
+}
+
+func (e *UnsupportedError) Is(target error) bool {
+	return target == ErrUnsupported
+}
+
+// ServerApiVersion returns WebUI api version of server, it is fetched once per client.
+func (c *QbitClient) ServerApiVersion(ctx context.Context) (ApiVersion, error) {
+	if c.apiVersion != nil {
+		return *c.apiVersion, nil
+	}
+	v, err := QbitApiVersion(ctx)
+	if err != nil {
+		return ApiVersion{}, err
+	}
+	version, err := ParseApiVersion(v)
+	if err != nil {
+		return ApiVersion{}, err
+	}
+	c.apiVersion = &version
+	return version, nil
+}
+
+// Supports reports whether server supports the feature.
+func Supports(ctx context.Context, feature Feature) (bool, error) {
+	err := RequireFeature(ctx, feature)
+	if errors.Is(err, ErrUnsupported) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// RequireFeature returns UnsupportedError if server doesn't support the feature.
+func RequireFeature(ctx context.Context, feature Feature) error {
+	required, ok := capabilities[feature]
+	if !ok {
+		return nil
+	}
+	version, err := GetQbitClient().ServerApiVersion(ctx)
+	if err != nil {
+		return err
+	}
+	if version.Less(required) {
+		return &UnsupportedError{Feature: feature, Required: required, Server: version}
+	}
+	return nil
+}
+
+// torrentEndpoint returns /torrents/* endpoint name for the server, e.g. stop is pause before 5.0
+func torrentEndpoint(ctx context.Context, operation string) (string, error) {
+	legacy, ok := legacyEndpoints[operation]
+	if !ok {
+		return operation, nil
+	}
+	supported, err := Supports(ctx, legacy.feature)
+	if err != nil {
+		return "", err
+	}
+	if supported {
+		return operation, nil
+	}
+	return legacy.name, nil
+}
+
+// stateFilter returns /torrents/info filter for the server, e.g. stopped is paused before 5.0
+func stateFilter(ctx context.Context, filter string) (string, error) {
+	legacy, ok := legacyStateFilters[filter]
+	if !ok {
+		return filter, nil
+	}
+	supported, err := Supports(ctx, legacy.feature)
+	if err != nil {
+		return "", err
+	}
+	if supported {
+		return filter, nil
+	}
+	return legacy.name, nil
+}
+
+// renameAddParams renames /torrents/add params for the server, e.g. stopped is paused before 5.0
+func renameAddParams(ctx context.Context, params url.Values) error {
+	for param, legacy := range legacyAddParams {
+		if !params.Has(param) {
+			continue
+		}
+		supported, err := Supports(ctx, legacy.feature)
+		if err != nil {
+			return err
+		}
+		if !supported {
+			params[legacy.name] = params[param]
+			params.Del(param)
+		}
+	}
+	return nil
+}
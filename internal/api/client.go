@@ -2,6 +2,7 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -14,76 +15,99 @@ import (
 	"qbit-cli/internal/config"
 	"qbit-cli/pkg/utils"
 	"strings"
-	"time"
 )
 
 type QbitClient struct {
+	// err is the config error, it is returned by every request
+	err      error
 	needAuth bool
-	Config   *config.Config
-	Client   *http.Client
-	Headers  map[string]string
+	// apiVersion is cached by ServerApiVersion
+	apiVersion *ApiVersion
+	Profile    *config.Profile
+	Client     *http.Client
+	Headers    map[string]string
 }
 
 var serverInfo *QbitServerInfo
 
-func GetQbitServerInfo() *QbitServerInfo {
+func GetQbitServerInfo(ctx context.Context) (*QbitServerInfo, error) {
 	if serverInfo != nil {
-		return serverInfo
+		return serverInfo, nil
 	}
-	info, err := QbitAppBuildInfo()
+	info, err := QbitAppBuildInfo(ctx)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
-	appVersion, e := QbitAppVersion()
-	if e != nil {
-		panic(e)
+	appVersion, err := QbitAppVersion(ctx)
+	if err != nil {
+		return nil, err
 	}
-	apiVersion, er := QbitApiVersion()
-	if er != nil {
-		panic(er)
+	apiVersion, err := QbitApiVersion(ctx)
+	if err != nil {
+		return nil, err
 	}
 	info.AppVersion = appVersion
 	info.WebApiVersion = apiVersion
 	serverInfo = info
-	return serverInfo
+	return serverInfo, nil
 }
 
 var client *QbitClient
 
+// GetQbitClient returns the shared qBittorrent client,
+// login is deferred to the first request so that it shares the request context.
 func GetQbitClient() *QbitClient {
 	if client != nil {
-		client.login()
 		return client
 	}
 
-	cfg := config.GetConfig()
+	profile, err := config.GetProfile()
+	if err != nil {
+		client = &QbitClient{err: err, Profile: &config.Profile{}, Headers: make(map[string]string), Client: NewHttpClient(RequestTimeout())}
+		return client
+	}
+	httpClient, err := NewServiceHttpClient(RequestTimeout(), "server", profile.Server.TransportConfig)
+	if err != nil {
+		httpClient = NewHttpClient(RequestTimeout())
+	} else if profile.Server.Host == "" {
+		err = config.NewConfigError("server.host is required")
+	}
 
 	client = &QbitClient{
-		needAuth: cfg.Server.Username != "" && cfg.Server.Password != "",
-		Config:   cfg,
+		err:      err,
+		needAuth: profile.Server.Username != "" && profile.Server.Password != "",
+		Profile:  profile,
 		Headers:  make(map[string]string),
-		Client:   &http.Client{Timeout: time.Second * 10},
+		Client:   httpClient,
 	}
-	client.login()
 	return client
 }
 
+// ResetClients drops the shared clients, they are created again from the current config. It is used by tests.
+func ResetClients() {
+	client = nil
+	jackettClient = nil
+	embyClient = nil
+	serverInfo = nil
+}
+
 func (c *QbitClient) host() string {
-	return c.Config.Server.Host
+	return c.Profile.Server.Host
 }
 func (c *QbitClient) user() string {
-	return c.Config.Server.Username
+	return c.Profile.Server.Username
 }
 func (c *QbitClient) pwd() string {
-	return c.Config.Server.Password
+	return c.Profile.Server.Password
 }
 func (c *QbitClient) token() string {
-	return c.Config.Server.Token
+	return c.Profile.Server.Token
 }
 
 type JackettClient struct {
-	Config *config.Config
-	Client *http.Client
+	err     error
+	Profile *config.Profile
+	Client  *http.Client
 }
 
 var jackettClient *JackettClient
@@ -92,9 +116,24 @@ func GetJackettClient() *JackettClient {
 	if jackettClient != nil {
 		return jackettClient
 	}
+	profile, err := config.GetProfile()
+	if err != nil {
+		profile = &config.Profile{}
+	} else if profile.Jackett.Host == "" {
+		err = config.NewConfigError("jackett.host is required")
+	}
+	// searching all indexers may take minutes, it's only limited by --timeout
+	httpClient, e := NewServiceHttpClient(0, "jackett", profile.Jackett.TransportConfig)
+	if e != nil {
+		httpClient = NewHttpClient(0)
+		if err == nil {
+			err = e
+		}
+	}
 	jackettClient = &JackettClient{
-		Config: config.GetConfig(),
-		Client: &http.Client{Timeout: time.Second * 10},
+		err:     err,
+		Profile: profile,
+		Client:  httpClient,
 	}
 	return jackettClient
 }
@@ -103,22 +142,24 @@ var jackettAuthEndpoint = []string{
 	"/api/v2.0/indexers",
 }
 
-func (c *JackettClient) Get(endpoint string, params url.Values) (*http.Response, error) {
+func (c *JackettClient) Get(ctx context.Context, endpoint string, params url.Values) (*http.Response, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
 	if params == nil {
 		params = url.Values{}
 	}
-	params.Set("apikey", c.Config.Jackett.ApiKey)
-	fullUrl := c.Config.Jackett.Host + endpoint
+	params.Set("apikey", c.Profile.Jackett.ApiKey)
+	fullUrl := c.Profile.Jackett.Host + endpoint
 	fullUrl += "?" + params.Encode()
-	log.Println(fullUrl)
 
-	req, err := http.NewRequest(http.MethodGet, fullUrl, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fullUrl, nil)
 	if err != nil {
-		return nil, &HTTPClientError{"Get", fullUrl, err}
+		return nil, &HTTPClientError{message: "Get", url: fullUrl, err: err}
 	}
 	for _, e := range jackettAuthEndpoint {
 		if e == endpoint {
-			req.Header.Set("Cookie", c.Config.Jackett.Cookie)
+			req.Header.Set("Cookie", c.Profile.Jackett.Cookie)
 			break
 		}
 	}
@@ -130,8 +171,9 @@ func (c *JackettClient) Get(endpoint string, params url.Values) (*http.Response,
 }
 
 type EmbyClient struct {
-	Config *config.Config
-	Client *http.Client
+	err     error
+	Profile *config.Profile
+	Client  *http.Client
 }
 
 var embyClient *EmbyClient
@@ -140,24 +182,38 @@ func GetEmbyClient() *EmbyClient {
 	if embyClient != nil {
 		return embyClient
 	}
+	profile, err := config.GetProfile()
+	if err != nil {
+		profile = &config.Profile{}
+	} else if profile.Emby.Host == "" {
+		err = config.NewConfigError("emby.host is required")
+	}
+	httpClient, e := NewServiceHttpClient(RequestTimeout(), "emby", profile.Emby.TransportConfig)
+	if e != nil {
+		httpClient = NewHttpClient(RequestTimeout())
+		if err == nil {
+			err = e
+		}
+	}
 	embyClient = &EmbyClient{
-		Config: config.GetConfig(),
-		Client: &http.Client{Timeout: time.Second * 10},
+		err:     err,
+		Profile: profile,
+		Client:  httpClient,
 	}
 
 	return embyClient
 }
 
 func (c *EmbyClient) embyHost() string {
-	return c.Config.Emby.Host
+	return c.Profile.Emby.Host
 }
 
 func (c *EmbyClient) embyApiKey() string {
-	return c.Config.Emby.ApiKey
+	return c.Profile.Emby.ApiKey
 }
 
 func (c *EmbyClient) EmbyUser() string {
-	return c.Config.Emby.User
+	return c.Profile.Emby.User
 }
 
 func (c *EmbyClient) EmbyUserEndpoint(endpoint ...string) string {
@@ -166,7 +222,10 @@ func (c *EmbyClient) EmbyUserEndpoint(endpoint ...string) string {
 	return strings.Join(str, "/")
 }
 
-func (c *EmbyClient) Get(endpoint string, params url.Values) (*http.Response, error) {
+func (c *EmbyClient) Get(ctx context.Context, endpoint string, params url.Values) (*http.Response, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
 	fullUrl := c.embyHost() + endpoint
 	if params == nil {
 		params = url.Values{}
@@ -174,19 +233,10 @@ func (c *EmbyClient) Get(endpoint string, params url.Values) (*http.Response, er
 	if len(params) > 0 {
 		fullUrl += "?" + params.Encode()
 	}
-	if config.Debug {
-		token := ""
-		if len(params) > 0 {
-			token = "&X-Emby-Token=" + c.embyApiKey()
-		} else {
-			token = "?X-Emby-Token=" + c.embyApiKey()
-		}
-		log.Println(fullUrl + token)
-	}
 
-	req, err := http.NewRequest(http.MethodGet, fullUrl, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fullUrl, nil)
 	if err != nil {
-		return nil, &HTTPClientError{"Get", fullUrl, err}
+		return nil, &HTTPClientError{message: "Get", url: fullUrl, err: err}
 	}
 	c.embyAuth(req)
 	resp, err := c.Client.Do(req)
@@ -202,9 +252,12 @@ func (c *EmbyClient) embyAuth(req *http.Request) {
 	req.Header.Set("X-Emby-Device-Name", "qbit-cli")
 }
 
-func (c *EmbyClient) Post(endpoint string, params url.Values) (*http.Response, error) {
+func (c *EmbyClient) Post(ctx context.Context, endpoint string, params url.Values) (*http.Response, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
 	fullUrl := c.embyHost() + endpoint
-	req, err := http.NewRequest(http.MethodPost, fullUrl, strings.NewReader(params.Encode()))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fullUrl, strings.NewReader(params.Encode()))
 	if err != nil {
 		return nil, err
 	}
@@ -213,7 +266,7 @@ func (c *EmbyClient) Post(endpoint string, params url.Values) (*http.Response, e
 	c.embyAuth(req)
 	resp, err := c.Client.Do(req)
 	if err != nil {
-		return nil, &HTTPClientError{"Post", fullUrl, err}
+		return nil, &HTTPClientError{message: "Post", url: fullUrl, err: err}
 	}
 
 	return resp, nil
@@ -244,23 +297,22 @@ func ParseRawJSON(resp *http.Response) (string, error) {
 	return string(raw), nil
 }
 
-func (c *QbitClient) Get(endpoint string, params url.Values) (*http.Response, error) {
+func (c *QbitClient) Get(ctx context.Context, endpoint string, params url.Values) (*http.Response, error) {
+	if err := c.login(ctx); err != nil {
+		return nil, err
+	}
+
 	fullUrl := c.host() + endpoint
 	if len(params) > 0 {
 		fullUrl += "?" + params.Encode()
 	}
-	log.Println(fullUrl)
 
-	req, err := http.NewRequest(http.MethodGet, fullUrl, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fullUrl, nil)
 	if err != nil {
-		return nil, &HTTPClientError{"Get", fullUrl, err}
-	}
-
-	for k, v := range c.Headers {
-		req.Header.Set(k, v)
+		return nil, &HTTPClientError{message: "Get", url: fullUrl, err: err}
 	}
 
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(ctx, req)
 	if err != nil {
 		return nil, err
 	}
@@ -268,26 +320,31 @@ func (c *QbitClient) Get(endpoint string, params url.Values) (*http.Response, er
 	return resp, nil
 }
 
-func (c *QbitClient) Post(endpoint string, params url.Values) (*http.Response, error) {
+func (c *QbitClient) Post(ctx context.Context, endpoint string, params url.Values) (*http.Response, error) {
+	if err := c.login(ctx); err != nil {
+		return nil, err
+	}
+
 	fullUrl := c.host() + endpoint
-	req, err := http.NewRequest(http.MethodPost, fullUrl, strings.NewReader(params.Encode()))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fullUrl, strings.NewReader(params.Encode()))
 	if err != nil {
 		return nil, err
 	}
 
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	for k, v := range c.Headers {
-		req.Header.Set(k, v)
-	}
-	resp, err := c.Client.Do(req)
+	resp, err := c.do(ctx, req)
 	if err != nil {
-		return nil, &HTTPClientError{"Post", fullUrl, err}
+		return nil, &HTTPClientError{message: "Post", url: fullUrl, err: err}
 	}
 
 	return resp, nil
 }
 
-func (c *QbitClient) PostForm(endpoint string, params url.Values, fields string, files []*os.File) (*http.Response, error) {
+func (c *QbitClient) PostForm(ctx context.Context, endpoint string, params url.Values, fields string, files []*os.File) (*http.Response, error) {
+	if err := c.login(ctx); err != nil {
+		return nil, err
+	}
+
 	body := &bytes.Buffer{}
 	writer := multipart.NewWriter(body)
 	for key, values := range params {
@@ -311,80 +368,205 @@ func (c *QbitClient) PostForm(endpoint string, params url.Values, fields string,
 		return nil, err
 	}
 
-	req, err := http.NewRequest(http.MethodPost, c.host()+endpoint, body)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.host()+endpoint, body)
 	if err != nil {
 		return nil, err
 	}
 	req.Header.Set("Content-Type", writer.FormDataContentType())
+	resp, err := c.do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// do sends the request with auth headers.
+// An expired SID is answered with 403, in that case login again and retry the request once.
+func (c *QbitClient) do(ctx context.Context, req *http.Request) (*http.Response, error) {
 	for k, v := range c.Headers {
 		req.Header.Set(k, v)
 	}
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := c.Client.Do(req)
 	if err != nil {
 		return nil, err
 	}
-	return resp, nil
+	if resp.StatusCode != http.StatusForbidden || c.token() != "" || !c.needAuth {
+		return resp, nil
+	}
+	utils.SafeClose(resp.Body)
+
+	log.Printf("%s returns 403, session may be expired, login again\n", req.URL.Path)
+	if err := c.Login(ctx); err != nil {
+		return nil, err
+	}
+
+	retry := req.Clone(ctx)
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		retry.Body = body
+	}
+	for k, v := range c.Headers {
+		retry.Header.Set(k, v)
+	}
+	return c.Client.Do(retry)
 }
 
-func (c *QbitClient) login() {
+// login set auth headers, session is loaded from cache file if exists to avoid logging in on every invocation.
+func (c *QbitClient) login(ctx context.Context) error {
+	if c.err != nil {
+		return c.err
+	}
 
 	// compatible latest version(>=5.20)
 	if c.token() != "" {
 		c.Headers["Authorization"] = fmt.Sprintf("Bearer %s", c.token())
-		return
+		return nil
+	}
+
+	if c.Headers["Cookie"] != "" || !c.needAuth {
+		return nil
+	}
+
+	if cookie := loadSession(c.host(), c.user()); cookie != "" {
+		c.Headers["Cookie"] = cookie
+		return nil
 	}
 
-	if c.Headers["Cookie"] != "" {
-		return
+	return c.Login(ctx)
+}
+
+// Login always post /api/v2/auth/login and persist the new session.
+func (c *QbitClient) Login(ctx context.Context) error {
+	if c.err != nil {
+		return c.err
 	}
+	delete(c.Headers, "Cookie")
 
 	body := url.Values{
 		"username": {c.user()},
 		"password": {c.pwd()},
 	}
 
-	resp, err := c.Client.PostForm(c.host()+"/api/v2/auth/login", body)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.host()+"/api/v2/auth/login", strings.NewReader(body.Encode()))
 	if err != nil {
-		panic(err)
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return err
 	}
 	defer utils.SafeClose(resp.Body)
 
+	if resp.StatusCode == http.StatusForbidden {
+		e := newQbitClientError("Login", resp, "IP is banned for too many failed login attempts")
+		e.kind = ErrAuth
+		return e
+	}
 	if resp.StatusCode != http.StatusOK {
-		panic("login failed")
+		e := newQbitClientError("Login", resp, "")
+		e.kind = ErrAuth
+		return e
 	}
 
 	cookie, _, _ := strings.Cut(resp.Header.Get("Set-Cookie"), ";")
 	if cookie == "" {
-		panic("login success, but cookie is empty")
+		e := newQbitClientError("Login", resp, "login failed, check your username and password")
+		e.kind = ErrAuth
+		return e
 	}
 
 	c.Headers["Cookie"] = cookie
+	if err := saveSession(c.host(), c.user(), cookie); err != nil {
+		log.Printf("save session failed: %v\n", err)
+	}
+	return nil
 }
 
-type HTTPClientError struct {
-	message string
-	url     string
-	err     error
+// Logout post /api/v2/auth/logout and remove the cached session.
+func (c *QbitClient) Logout(ctx context.Context) error {
+	if c.err != nil {
+		return c.err
+	}
+	defer removeSession(c.host(), c.user())
+
+	cookie := c.Headers["Cookie"]
+	if cookie == "" {
+		cookie = loadSession(c.host(), c.user())
+	}
+	if c.token() != "" || cookie == "" {
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.host()+"/api/v2/auth/logout", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Cookie", cookie)
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer utils.SafeClose(resp.Body)
+	delete(c.Headers, "Cookie")
+	if resp.StatusCode != http.StatusOK {
+		return newQbitClientError("Logout", resp, "")
+	}
+	return nil
 }
 
-type QbitClientError struct {
-	message string
-	method  string
-	err     error
+// AuthMode returns how the client authenticates: token, cookie or none.
+func (c *QbitClient) AuthMode() string {
+	if c.token() != "" {
+		return "token"
+	}
+	if c.needAuth {
+		return "cookie"
+	}
+	return "none"
 }
 
-func (c *QbitClientError) Error() string {
-	errStr := ""
+// CachedSession returns the persisted session cookie, empty if not exists.
+func (c *QbitClient) CachedSession() string {
+	return loadSession(c.host(), c.user())
+}
+
+// SessionValid checks current auth without logging in again, so that an expired session is reported as it is.
+func (c *QbitClient) SessionValid(ctx context.Context) (bool, error) {
 	if c.err != nil {
-		errStr = c.err.Error()
+		return false, c.err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.host()+"/api/v2/app/version", nil)
+	if err != nil {
+		return false, err
+	}
+	switch c.AuthMode() {
+	case "token":
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.token()))
+	case "cookie":
+		req.Header.Set("Cookie", loadSession(c.host(), c.user()))
+	}
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return false, err
 	}
-	return fmt.Sprintf("%s qbit client error: %s %s", c.method, c.message, errStr)
+	defer utils.SafeClose(resp.Body)
+	if resp.StatusCode == http.StatusForbidden {
+		return false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return false, newQbitClientError("SessionValid", resp, "")
+	}
+	return true, nil
 }
 
-func (e *HTTPClientError) Error() string {
-	errStr := ""
-	if e.err != nil {
-		errStr = e.err.Error()
-	}
-	return fmt.Sprintf("http client error: %s %s %s", e.url, e.message, errStr)
+func (c *QbitClient) Host() string {
+	return c.host()
+}
+
+func (c *QbitClient) User() string {
+	return c.user()
 }
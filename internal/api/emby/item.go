@@ -1,15 +1,15 @@
 package emby
 
 import (
-	"errors"
+	"context"
 	"net/http"
 	"net/url"
 	"qbit-cli/internal/api"
 )
 
-func Items(params url.Values) (*api.EmbyItems, error) {
+func Items(ctx context.Context, params url.Values) (*api.EmbyItems, error) {
 	embyClient := api.GetEmbyClient()
-	resp, err := embyClient.Get(embyClient.EmbyUserEndpoint("Items"), params)
+	resp, err := embyClient.Get(ctx, embyClient.EmbyUserEndpoint("Items"), params)
 	if err != nil {
 		return nil, err
 	}
@@ -17,7 +17,7 @@ func Items(params url.Values) (*api.EmbyItems, error) {
 		if resp.StatusCode == http.StatusNotFound {
 			return &api.EmbyItems{}, nil
 		} else {
-			return nil, errors.New(resp.Status)
+			return nil, api.NewHTTPClientError(resp)
 		}
 	}
 	var result *api.EmbyItems
@@ -27,14 +27,14 @@ func Items(params url.Values) (*api.EmbyItems, error) {
 	return result, nil
 }
 
-func Item(item string) (*api.EmbyItem, error) {
+func Item(ctx context.Context, item string) (*api.EmbyItem, error) {
 	embyClient := api.GetEmbyClient()
-	resp, err := embyClient.Get(embyClient.EmbyUserEndpoint("Items", item), url.Values{})
+	resp, err := embyClient.Get(ctx, embyClient.EmbyUserEndpoint("Items", item), url.Values{})
 	if err != nil {
 		return nil, err
 	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, errors.New(resp.Status)
+		return nil, api.NewHTTPClientError(resp)
 	}
 	var result api.EmbyItem
 	if err := api.ParseJSON(resp, &result); err != nil {
@@ -43,22 +43,22 @@ func Item(item string) (*api.EmbyItem, error) {
 	return &result, nil
 }
 
-func RefreshItem(item string, params url.Values) error {
+func RefreshItem(ctx context.Context, item string, params url.Values) error {
 	if params == nil {
 		params = url.Values{}
 	}
-	resp, err := api.GetEmbyClient().Post("/emby/Items/"+item+"/Refresh", params)
+	resp, err := api.GetEmbyClient().Post(ctx, "/emby/Items/"+item+"/Refresh", params)
 	if err != nil {
 		return err
 	}
 	if resp.StatusCode == http.StatusNoContent || resp.StatusCode == http.StatusOK {
 		return nil
 	} else {
-		return errors.New(resp.Status)
+		return api.NewHTTPClientError(resp)
 	}
 }
 
-func RefreshItemByItemId(itemID string) error {
+func RefreshItemByItemId(ctx context.Context, itemID string) error {
 	params := url.Values{
 		"Recursive":           {"true"},
 		"MetadataRefreshMode": {"FullRefresh"},
@@ -66,20 +66,20 @@ func RefreshItemByItemId(itemID string) error {
 		"ReplaceAllMetadata":  {"true"},
 		"ReplaceAllImages":    {"true"},
 	}
-	return RefreshItem(itemID, params)
+	return RefreshItem(ctx, itemID, params)
 }
 
-func ResetItemMetadata(item string) error {
+func ResetItemMetadata(ctx context.Context, item string) error {
 	params := url.Values{
 		"ItemIds": []string{item},
 	}
-	resp, err := api.GetEmbyClient().Post("/emby/items/metadata/reset", params)
+	resp, err := api.GetEmbyClient().Post(ctx, "/emby/items/metadata/reset", params)
 	if err != nil {
 		return err
 	}
 	if resp.StatusCode == http.StatusNoContent || resp.StatusCode == http.StatusOK {
 		return nil
 	} else {
-		return errors.New(resp.Status)
+		return api.NewHTTPClientError(resp)
 	}
 }
@@ -0,0 +1,153 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Kinds of errors, use errors.Is to check them:
+//
+//	errors.Is(err, api.ErrNotFound)
+var (
+	ErrAuth     = errors.New("authentication failed")
+	ErrNotFound = errors.New("not found")
+	ErrConflict = errors.New("conflict")
+)
+
+// statusKind maps http status to error kind
+func statusKind(statusCode int) error {
+	switch statusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ErrAuth
+	case http.StatusNotFound:
+		return ErrNotFound
+	case http.StatusConflict:
+		return ErrConflict
+	}
+	return nil
+}
+
+// QbitClientError is returned when qBittorrent answers with an unexpected status.
+type QbitClientError struct {
+	message string
+	err     error
+	// kind overrides the kind derived from StatusCode
+	kind error
+	// Operation is the api function name, e.g. TorrentAdd
+	Operation  string
+	Endpoint   string
+	StatusCode int
+}
+
+// newQbitClientError builds error from response, message defaults to response status.
+func newQbitClientError(operation string, resp *http.Response, message string) *QbitClientError {
+	if message == "" {
+		message = resp.Status
+	}
+	e := &QbitClientError{message: message, Operation: operation, StatusCode: resp.StatusCode}
+	if resp.Request != nil && resp.Request.URL != nil {
+		e.Endpoint = resp.Request.URL.Path
+	}
+	return e
+}
+
+func (c *QbitClientError) Error() string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("%s qbit client error: %s", c.Operation, c.message))
+	if c.Endpoint != "" {
+		b.WriteString(fmt.Sprintf(" [%d %s]", c.StatusCode, c.Endpoint))
+	}
+	if c.err != nil {
+		b.WriteString(" " + c.err.Error())
+	}
+	return b.String()
+}
+
+func (c *QbitClientError) Unwrap() error {
+	return c.err
+}
+
+func (c *QbitClientError) Is(target error) bool {
+	if c.kind != nil {
+		return c.kind == target
+	}
+	return statusKind(c.StatusCode) == target
+}
+
+// HTTPClientError is returned when request fails or a non-qBittorrent service answers with an unexpected status.
+type HTTPClientError struct {
+	message    string
+	url        string
+	err        error
+	StatusCode int
+}
+
+func newHTTPClientError(resp *http.Response) *HTTPClientError {
+	e := &HTTPClientError{message: resp.Status, StatusCode: resp.StatusCode}
+	if resp.Request != nil && resp.Request.URL != nil {
+		e.url = resp.Request.URL.Path
+	}
+	return e
+}
+
+// NewHTTPClientError builds error from response of Jackett, Emby and so on.
+func NewHTTPClientError(resp *http.Response) error {
+	return newHTTPClientError(resp)
+}
+
+func (e *HTTPClientError) Error() string {
+	errStr := ""
+	if e.err != nil {
+		errStr = e.err.Error()
+	}
+	return fmt.Sprintf("http client error: %s %s %s", e.url, e.message, errStr)
+}
+
+func (e *HTTPClientError) Unwrap() error {
+	return e.err
+}
+
+func (e *HTTPClientError) Is(target error) bool {
+	return statusKind(e.StatusCode) == target
+}
+
+// PartialError is returned by bulk operations when some items failed.
+type PartialError struct {
+	Total int
+	Errs  []error
+}
+
+// Add records a failed item, nil error is ignored.
+func (e *PartialError) Add(item string, err error) {
+	if err != nil {
+		e.Errs = append(e.Errs, fmt.Errorf("%s: %w", item, err))
+	}
+}
+
+// Err returns nil if nothing failed.
+func (e *PartialError) Err() error {
+	if len(e.Errs) == 0 {
+		return nil
+	}
+	return e
+}
+
+// Partial reports whether some items succeeded.
+func (e *PartialError) Partial() bool {
+	return len(e.Errs) < e.Total
+}
+
+func (e *PartialError) Error() string {
+	lines := make([]string, 0, len(e.Errs)+1)
+	lines = append(lines, fmt.Sprintf("%d of %d failed:", len(e.Errs), e.Total))
+	for _, err := range e.Errs {
+		lines = append(lines, "  "+err.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (e *PartialError) Unwrap() []error {
+	return e.Errs
+}
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"qbit-cli/pkg/utils"
+)
+
+// LowSpaceAction is what TorrentAddChecked does with a torrent which would leave less free space than the reserve.
+type LowSpaceAction string
+
+const (
+	LowSpaceRefuse LowSpaceAction = "refuse"
+	LowSpaceWarn   LowSpaceAction = "warn"
+	// LowSpacePause adds the torrent stopped, so that it can be started after making room
+	LowSpacePause LowSpaceAction = "pause"
+	// LowSpaceIgnore disables the check
+	LowSpaceIgnore LowSpaceAction = "ignore"
+)
+
+var LowSpaceActions = []string{string(LowSpaceRefuse), string(LowSpaceWarn), string(LowSpacePause), string(LowSpaceIgnore)}
+
+// ErrNoSpace is the kind of errors of torrents refused by SpaceGuard.
+var ErrNoSpace = errors.New("insufficient free space")
+
+// SpaceGuard compares the free space of the server with the size of torrents before adding them.
+type SpaceGuard struct {
+	// Reserve is the free space in bytes which must be left after adding a torrent
+	Reserve int64
+	Action  LowSpaceAction
+}
+
+func (g *SpaceGuard) enabled() bool {
+	return g != nil && g.Action != LowSpaceIgnore
+}
+
+// check returns the reason if adding size bytes leaves less than the reserve of free bytes.
+func (g *SpaceGuard) check(free, size int64) string {
+	if free-size >= g.Reserve {
+		return ""
+	}
+	return fmt.Sprintf("needs %s, %s free on disk with %s reserve", utils.FormatFileSizeAuto(uint64(size), 1),
+		utils.FormatFileSizeAuto(uint64(max(free, 0)), 1), utils.FormatFileSizeAuto(uint64(g.Reserve), 1))
+}
+
+// FreeSpace returns free_space_on_disk of the default save path from /sync/maindata server state,
+// ok is false if the server doesn't report it.
+func FreeSpace(ctx context.Context) (free int64, ok bool, err error) {
+	data, err := SyncMainData(ctx, 0)
+	if err != nil {
+		return 0, false, err
+	}
+	var state struct {
+		FreeSpaceOnDisk *int64 `json:"free_space_on_disk"`
+	}
+	if len(data.ServerState) > 0 {
+		if err := json.Unmarshal(data.ServerState, &state); err != nil {
+			return 0, false, err
+		}
+	}
+	if state.FreeSpaceOnDisk == nil {
+		return 0, false, nil
+	}
+	return *state.FreeSpaceOnDisk, true, nil
+}
@@ -0,0 +1,118 @@
+package api
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"qbit-cli/internal/config"
+	"qbit-cli/pkg/utils"
+	"time"
+)
+
+const (
+	defaultMaxRetry       = 3
+	defaultRequestTimeout = 10 * time.Second
+)
+
+// RequestTimeout returns the timeout of a request to qBittorrent and Emby configured by http block of config file.
+// Jackett and jobs are not limited by it, because searching indexers and downloading may take minutes.
+func RequestTimeout() time.Duration {
+	timeout := config.GetConfig().Http.Timeout
+	if timeout == 0 {
+		return defaultRequestTimeout
+	} else if timeout < 0 {
+		return 0
+	}
+	return timeout
+}
+
+// NewHttpClient returns a client with retry, backoff and rate limit configured by http block of config file.
+// It should be called after flags are parsed, because config file is loaded here.
+func NewHttpClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: newTransport(http.DefaultTransport),
+	}
+}
+
+// NewServiceHttpClient is NewHttpClient with TLS and proxy settings of a service.
+func NewServiceHttpClient(timeout time.Duration, service string, cfg config.TransportConfig) (*http.Client, error) {
+	base, err := newBaseTransport(cfg)
+	if err != nil {
+		return nil, config.NewConfigError(fmt.Sprintf("%s: %v", service, err))
+	}
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: newTransport(base),
+	}, nil
+}
+
+func newTransport(base http.RoundTripper) http.RoundTripper {
+	cfg := config.GetConfig().Http
+	maxRetry := cfg.MaxRetry
+	if maxRetry == 0 {
+		maxRetry = defaultMaxRetry
+	} else if maxRetry < 0 {
+		maxRetry = 0
+	}
+	return utils.NewTransport(base, utils.TransportOptions{
+		MaxRetry:     maxRetry,
+		RetryWait:    cfg.RetryWait,
+		MaxRetryWait: cfg.MaxRetryWait,
+		RateLimit:    cfg.RateLimit,
+		RecordDir:    config.RecordDir,
+		ReplayDir:    config.ReplayDir,
+	})
+}
+
+// newBaseTransport clones http.DefaultTransport with CA bundle, client certificate and proxy.
+func newBaseTransport(cfg config.TransportConfig) (http.RoundTripper, error) {
+	if cfg == (config.TransportConfig{}) {
+		return http.DefaultTransport, nil
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: cfg.InsecureSkipVerify}
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("ca-file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("ca-file: no certificate found in %s", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if cfg.ClientCert != "" || cfg.ClientKey != "" {
+		if cfg.ClientCert == "" || cfg.ClientKey == "" {
+			return nil, fmt.Errorf("client-cert and client-key must be set together")
+		}
+		cert, err := tls.LoadX509KeyPair(cfg.ClientCert, cfg.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("client-cert: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	transport.TLSClientConfig = tlsConfig
+
+	if cfg.Proxy != "" {
+		proxy, err := url.Parse(cfg.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("proxy: %w", err)
+		}
+		switch proxy.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			return nil, fmt.Errorf("proxy: unsupported scheme %q, use http, https or socks5", proxy.Scheme)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+	return transport, nil
+}
@@ -1,23 +1,28 @@
 package api
 
 import (
+	"context"
+	"net/http"
 	"net/url"
 	"qbit-cli/pkg/utils"
 	"strings"
 )
 
-func JackettSearch(indexer string, category []string, query string) (*JackettResults, error) {
+func JackettSearch(ctx context.Context, indexer string, category []string, query string) (*JackettResults, error) {
 	params := url.Values{
 		"Query":    []string{query},
 		"Category": category,
 	}
 	endpoint := "/api/v2.0/indexers/_/results"
 	endpoint = strings.Replace(endpoint, "_", indexer, 1)
-	resp, err := GetJackettClient().Get(endpoint, params)
+	resp, err := GetJackettClient().Get(ctx, endpoint, params)
 	if err != nil {
 		return nil, err
 	}
 	defer utils.SafeClose(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, newHTTPClientError(resp)
+	}
 	var result JackettResults
 	err = ParseJSON(resp, &result)
 	if err != nil {
@@ -26,12 +31,15 @@ func JackettSearch(indexer string, category []string, query string) (*JackettRes
 	return &result, nil
 }
 
-func JackettIndexers(enabled bool) (*[]JackettIndexer, error) {
-	resp, err := GetJackettClient().Get("/api/v2.0/indexers", url.Values{})
+func JackettIndexers(ctx context.Context, enabled bool) (*[]JackettIndexer, error) {
+	resp, err := GetJackettClient().Get(ctx, "/api/v2.0/indexers", url.Values{})
 	if err != nil {
 		return nil, err
 	}
 	defer utils.SafeClose(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, newHTTPClientError(resp)
+	}
 	var result []JackettIndexer
 	err = ParseJSON(resp, &result)
 	if err != nil {
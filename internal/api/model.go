@@ -1,6 +1,7 @@
 package api
 
 import (
+	"encoding/json"
 	"fmt"
 )
 
@@ -25,6 +26,7 @@ type SearchResult struct {
 	ID uint32 `json:"id"`
 }
 
+// Torrent is an item of /torrents/info, times are unix seconds and durations are seconds.
 type Torrent struct {
 	Hash     string  `json:"hash"`
 	Name     string  `json:"name"`
@@ -36,6 +38,109 @@ type Torrent struct {
 	UPSpeed  int64   `json:"upspeed"`
 	Size     int64   `json:"size"`
 	AddOn    int64   `json:"added_on"`
+
+	InfohashV1   string `json:"infohash_v1"`
+	InfohashV2   string `json:"infohash_v2"`
+	MagnetURI    string `json:"magnet_uri"`
+	Comment      string `json:"comment"`
+	Private      bool   `json:"private"`
+	HasMetadata  bool   `json:"has_metadata"`
+	SavePath     string `json:"save_path"`
+	DownloadPath string `json:"download_path"`
+	ContentPath  string `json:"content_path"`
+	RootPath     string `json:"root_path"`
+	TotalSize    int64  `json:"total_size"`
+
+	AmountLeft        int64   `json:"amount_left"`
+	Completed         int64   `json:"completed"`
+	Downloaded        int64   `json:"downloaded"`
+	DownloadedSession int64   `json:"downloaded_session"`
+	Uploaded          int64   `json:"uploaded"`
+	UploadedSession   int64   `json:"uploaded_session"`
+	Ratio             float64 `json:"ratio"`
+	Availability      float64 `json:"availability"`
+	Popularity        float64 `json:"popularity"`
+	// Eta is 8640000 if unknown
+	Eta          int64 `json:"eta"`
+	CompletionOn int64 `json:"completion_on"`
+	LastActivity int64 `json:"last_activity"`
+	SeenComplete int64 `json:"seen_complete"`
+	TimeActive   int64 `json:"time_active"`
+	SeedingTime  int64 `json:"seeding_time"`
+	Reannounce   int64 `json:"reannounce"`
+
+	Tracker       string `json:"tracker"`
+	TrackersCount int    `json:"trackers_count"`
+	NumSeeds      int    `json:"num_seeds"`
+	NumComplete   int    `json:"num_complete"`
+	NumLeechs     int    `json:"num_leechs"`
+	NumIncomplete int    `json:"num_incomplete"`
+
+	// DlLimit and UpLimit are bytes per second, -1 or 0 means no limit
+	DlLimit  int64 `json:"dl_limit"`
+	UpLimit  int64 `json:"up_limit"`
+	Priority int   `json:"priority"`
+	// limits of share, -2 means global limit, -1 means no limit
+	RatioLimit               float64 `json:"ratio_limit"`
+	SeedingTimeLimit         int64   `json:"seeding_time_limit"`
+	InactiveSeedingTimeLimit int64   `json:"inactive_seeding_time_limit"`
+	MaxRatio                 float64 `json:"max_ratio"`
+	MaxSeedingTime           int64   `json:"max_seeding_time"`
+	MaxInactiveSeedingTime   int64   `json:"max_inactive_seeding_time"`
+
+	AutoTMM      bool `json:"auto_tmm"`
+	ForceStart   bool `json:"force_start"`
+	SeqDl        bool `json:"seq_dl"`
+	FLPiecePrio  bool `json:"f_l_piece_prio"`
+	SuperSeeding bool `json:"super_seeding"`
+}
+
+// TorrentGenericProperties is the response of /torrents/properties, times are unix seconds and -1 means unknown.
+type TorrentGenericProperties struct {
+	Hash         string `json:"hash"`
+	InfohashV1   string `json:"infohash_v1"`
+	InfohashV2   string `json:"infohash_v2"`
+	Name         string `json:"name"`
+	SavePath     string `json:"save_path"`
+	DownloadPath string `json:"download_path"`
+	Comment      string `json:"comment"`
+	CreatedBy    string `json:"created_by"`
+	CreationDate int64  `json:"creation_date"`
+	IsPrivate    bool   `json:"is_private"`
+	HasMetadata  bool   `json:"has_metadata"`
+
+	PieceSize  int64 `json:"piece_size"`
+	PiecesHave int64 `json:"pieces_have"`
+	PiecesNum  int64 `json:"pieces_num"`
+	TotalSize  int64 `json:"total_size"`
+
+	AdditionDate   int64 `json:"addition_date"`
+	CompletionDate int64 `json:"completion_date"`
+	LastSeen       int64 `json:"last_seen"`
+	TimeElapsed    int64 `json:"time_elapsed"`
+	SeedingTime    int64 `json:"seeding_time"`
+	Eta            int64 `json:"eta"`
+	Reannounce     int64 `json:"reannounce"`
+
+	TotalDownloaded        int64   `json:"total_downloaded"`
+	TotalDownloadedSession int64   `json:"total_downloaded_session"`
+	TotalUploaded          int64   `json:"total_uploaded"`
+	TotalUploadedSession   int64   `json:"total_uploaded_session"`
+	TotalWasted            int64   `json:"total_wasted"`
+	ShareRatio             float64 `json:"share_ratio"`
+	DLSpeed                int64   `json:"dl_speed"`
+	DLSpeedAvg             int64   `json:"dl_speed_avg"`
+	UPSpeed                int64   `json:"up_speed"`
+	UPSpeedAvg             int64   `json:"up_speed_avg"`
+	DLLimit                int64   `json:"dl_limit"`
+	UPLimit                int64   `json:"up_limit"`
+
+	NbConnections      int `json:"nb_connections"`
+	NbConnectionsLimit int `json:"nb_connections_limit"`
+	Peers              int `json:"peers"`
+	PeersTotal         int `json:"peers_total"`
+	Seeds              int `json:"seeds"`
+	SeedsTotal         int `json:"seeds_total"`
 }
 
 type TorrentFile struct {
@@ -134,9 +239,53 @@ type TorrentPeer struct {
 	Uploaded     int64   `json:"uploaded"`
 }
 
+// PeerResult peers are partial objects keyed by ip:port unless full_update is true
 type PeerResult struct {
-	Peers      map[string]TorrentPeer `json:"peers"`
-	FullUpdate bool                   `json:"full_update"`
-	Rid        int64                  `json:"rid"`
-	ShowFlags  bool                   `json:"show_flags"`
+	Peers        map[string]json.RawMessage `json:"peers"`
+	PeersRemoved []string                   `json:"peers_removed"`
+	FullUpdate   bool                       `json:"full_update"`
+	Rid          int64                      `json:"rid"`
+	ShowFlags    bool                       `json:"show_flags"`
+}
+
+// MainData is the response of /sync/maindata.
+// Torrents, categories and server state are partial objects which only contain changed fields unless full_update is true.
+type MainData struct {
+	Rid               int64                      `json:"rid"`
+	FullUpdate        bool                       `json:"full_update"`
+	Torrents          map[string]json.RawMessage `json:"torrents"`
+	TorrentsRemoved   []string                   `json:"torrents_removed"`
+	Categories        map[string]json.RawMessage `json:"categories"`
+	CategoriesRemoved []string                   `json:"categories_removed"`
+	Tags              []string                   `json:"tags"`
+	TagsRemoved       []string                   `json:"tags_removed"`
+	ServerState       json.RawMessage            `json:"server_state"`
+}
+
+type ServerState struct {
+	AllTimeDL            int64  `json:"alltime_dl"`
+	AllTimeUL            int64  `json:"alltime_ul"`
+	ConnectionStatus     string `json:"connection_status"`
+	DHTNodes             int64  `json:"dht_nodes"`
+	DLInfoData           int64  `json:"dl_info_data"`
+	DLInfoSpeed          int64  `json:"dl_info_speed"`
+	DLRateLimit          int64  `json:"dl_rate_limit"`
+	UPInfoData           int64  `json:"up_info_data"`
+	UPInfoSpeed          int64  `json:"up_info_speed"`
+	UPRateLimit          int64  `json:"up_rate_limit"`
+	FreeSpaceOnDisk      int64  `json:"free_space_on_disk"`
+	GlobalRatio          string `json:"global_ratio"`
+	Queueing             bool   `json:"queueing"`
+	UseAltSpeedLimits    bool   `json:"use_alt_speed_limits"`
+	RefreshInterval      int64  `json:"refresh_interval"`
+	TotalPeerConnections int64  `json:"total_peer_connections"`
+	TotalWastedSession   int64  `json:"total_wasted_session"`
+	ReadCacheHits        string `json:"read_cache_hits"`
+	AverageTimeQueue     int64  `json:"average_time_queue"`
+	QueuedIOJobs         int64  `json:"queued_io_jobs"`
+	TotalBuffersSize     int64  `json:"total_buffers_size"`
+	TotalQueuedSize      int64  `json:"total_queued_size"`
+	WriteCacheOverload   string `json:"write_cache_overload"`
+	ReadCacheOverload    string `json:"read_cache_overload"`
+	LastExternalAddress  string `json:"last_external_address_v4"`
 }
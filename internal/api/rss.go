@@ -1,8 +1,8 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
-	"fmt"
 	"net/http"
 	"net/url"
 	"qbit-cli/pkg/utils"
@@ -11,27 +11,27 @@ import (
 
 // all /rss/* api here
 
-func RssAddSub(feedUrl string, path string) error {
+func RssAddSub(ctx context.Context, feedUrl string, path string) error {
 	params := url.Values{
 		"url":  {feedUrl},
 		"path": {path},
 	}
 
-	resp, err := GetQbitClient().Post("/api/v2/rss/addFeed", params)
+	resp, err := GetQbitClient().Post(ctx, "/api/v2/rss/addFeed", params)
 	if err != nil {
 		return err
 	}
 	defer utils.SafeClose(resp.Body)
 
 	if resp.StatusCode != http.StatusOK {
-		return &QbitClientError{resp.Status, "RssAddSub", nil}
+		return newQbitClientError("RssAddSub", resp, "")
 	}
 
 	return nil
 }
 
-func RssRuleList() (map[string]*RssRule, error) {
-	resp, err := GetQbitClient().Get("/api/v2/rss/rules", url.Values{})
+func RssRuleList(ctx context.Context) (map[string]*RssRule, error) {
+	resp, err := GetQbitClient().Get(ctx, "/api/v2/rss/rules", url.Values{})
 	if err != nil {
 		return nil, err
 	}
@@ -39,13 +39,12 @@ func RssRuleList() (map[string]*RssRule, error) {
 
 	var results map[string]*RssRule
 	if err := ParseJSON(resp, &results); err != nil {
-		fmt.Println(err.Error())
 		return nil, err
 	}
 	return results, nil
 }
 
-func RssSetRule(ruleName string, rule *RssRule) error {
+func RssSetRule(ctx context.Context, ruleName string, rule *RssRule) error {
 	j, err := json.Marshal(rule)
 	if err != nil {
 		return err
@@ -56,20 +55,21 @@ func RssSetRule(ruleName string, rule *RssRule) error {
 		"ruleDef": {string(j)},
 	}
 
-	resp, er := GetQbitClient().Post("/api/v2/rss/setRule", params)
-	if er != nil {
+	resp, err := GetQbitClient().Post(ctx, "/api/v2/rss/setRule", params)
+	if err != nil {
 		return err
 	}
+	defer utils.SafeClose(resp.Body)
 
 	if resp.StatusCode != http.StatusOK {
-		return &QbitClientError{resp.Status, "RssSetRule", nil}
+		return newQbitClientError("RssSetRule", resp, "")
 	}
 
 	return nil
 }
 
-func RssAllItems(withData bool) (map[string]RssSub, error) {
-	resp, err := GetQbitClient().Get("/api/v2/rss/items", url.Values{"withData": {strconv.FormatBool(withData)}})
+func RssAllItems(ctx context.Context, withData bool) (map[string]RssSub, error) {
+	resp, err := GetQbitClient().Get(ctx, "/api/v2/rss/items", url.Values{"withData": {strconv.FormatBool(withData)}})
 	if err != nil {
 		return nil, err
 	}
@@ -82,14 +82,14 @@ func RssAllItems(withData bool) (map[string]RssSub, error) {
 	return results, nil
 }
 
-func RssRmSub(path string) error {
-	resp, err := GetQbitClient().Post("/api/v2/rss/removeItem", url.Values{"path": {path}})
+func RssRmSub(ctx context.Context, path string) error {
+	resp, err := GetQbitClient().Post(ctx, "/api/v2/rss/removeItem", url.Values{"path": {path}})
 	if err != nil {
 		return err
 	}
 	defer utils.SafeClose(resp.Body)
 	if resp.StatusCode != http.StatusOK {
-		return &QbitClientError{resp.Status, "RssRmSub", nil}
+		return newQbitClientError("RssRmSub", resp, "")
 	}
 	return nil
 }
@@ -1,7 +1,7 @@
 package api
 
 import (
-	"fmt"
+	"context"
 	"net/http"
 	"net/url"
 	"qbit-cli/pkg/utils"
@@ -12,16 +12,16 @@ import (
 
 // all the /search/* api here
 
-func SearchStart(params url.Values) (SearchResult, error) {
+func SearchStart(ctx context.Context, params url.Values) (SearchResult, error) {
 	result := SearchResult{}
-	resp, err := GetQbitClient().Post("/api/v2/search/start", params)
+	resp, err := GetQbitClient().Post(ctx, "/api/v2/search/start", params)
 	if err != nil {
 		return result, err
 	}
 	defer utils.SafeClose(resp.Body)
 
 	if resp.StatusCode == http.StatusConflict {
-		return result, &QbitClientError{resp.Status, "SearchStart", nil}
+		return result, newQbitClientError("SearchStart", resp, "")
 	}
 
 	if err := ParseJSON(resp, &result); err != nil {
@@ -33,13 +33,22 @@ func SearchStart(params url.Values) (SearchResult, error) {
 // SearchDetails get all search results, slow(may take seconds)
 // Attention: you must use the same auth information to start search and get results.
 // Or you will get a 404 from /api/v2/search/results
-func SearchDetails(d time.Duration, resultID uint32) ([]*SearchDetail, error) {
+// Polling stops when ctx is done, the search job is stopped on server side as well.
+func SearchDetails(ctx context.Context, d time.Duration, resultID uint32) ([]*SearchDetail, error) {
 	client := GetQbitClient()
 	status := "Running"
 	// duplicate removal
 	m := make(map[string]SearchDetail)
 	for status == "Running" {
-		time.Sleep(d)
+		select {
+		case <-ctx.Done():
+			// ctx is done, use a detached one to stop the search job
+			stopCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+			_ = SearchStop(stopCtx, resultID)
+			cancel()
+			return nil, ctx.Err()
+		case <-time.After(d):
+		}
 
 		params := url.Values{}
 		params.Set("id", strconv.FormatUint(uint64(resultID), 10))
@@ -47,18 +56,19 @@ func SearchDetails(d time.Duration, resultID uint32) ([]*SearchDetail, error) {
 		// 通过状态来判断搜索是否结束 不使用offset分页获取结果
 		params.Set("offset", "0")
 
-		resp, err := client.Get("/api/v2/search/results", params)
+		resp, err := client.Get(ctx, "/api/v2/search/results", params)
 		if err != nil {
-			fmt.Println(err.Error())
-			break
+			if ctx.Err() != nil {
+				continue
+			}
+			return nil, searchResultsError(err)
 		}
 
 		var result SearchResults
 		err = ParseJSON(resp, &result)
 		utils.SafeClose(resp.Body)
 		if err != nil {
-			fmt.Println(err.Error())
-			continue
+			return nil, searchResultsError(err)
 		}
 
 		status = result.Status
@@ -77,8 +87,26 @@ func SearchDetails(d time.Duration, resultID uint32) ([]*SearchDetail, error) {
 	return details, nil
 }
 
-func SearchPlugins() (*[]SearchPlugin, error) {
-	resp, err := GetQbitClient().Get("/api/v2/search/plugins", nil)
+func searchResultsError(err error) error {
+	return &QbitClientError{message: "failed to get /search/results", err: err, Operation: "SearchDetails"}
+}
+
+func SearchStop(ctx context.Context, resultID uint32) error {
+	params := url.Values{}
+	params.Set("id", strconv.FormatUint(uint64(resultID), 10))
+	resp, err := GetQbitClient().Post(ctx, "/api/v2/search/stop", params)
+	if err != nil {
+		return err
+	}
+	defer utils.SafeClose(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return newQbitClientError("SearchStop", resp, "")
+	}
+	return nil
+}
+
+func SearchPlugins(ctx context.Context) (*[]SearchPlugin, error) {
+	resp, err := GetQbitClient().Get(ctx, "/api/v2/search/plugins", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -92,48 +120,57 @@ func SearchPlugins() (*[]SearchPlugin, error) {
 	return &result, nil
 }
 
-func UpdatePlugin() error {
-	resp, err := GetQbitClient().Get("/api/v2/search/updatePlugins", nil)
+func UpdatePlugin(ctx context.Context) error {
+	resp, err := GetQbitClient().Post(ctx, "/api/v2/search/updatePlugins", url.Values{})
 	if err != nil {
 		return err
 	}
 	defer utils.SafeClose(resp.Body)
-	if resp.StatusCode == http.StatusOK {
-		return &QbitClientError{resp.Status, "UpdatePlugin", nil}
+	if resp.StatusCode != http.StatusOK {
+		return newQbitClientError("UpdatePlugin", resp, "")
 	}
 	return nil
 }
 
-func InstallPlugin(sources []string) error {
+func InstallPlugin(ctx context.Context, sources []string) error {
 	params := url.Values{}
 	params.Set("sources", strings.Join(sources, "|"))
-	resp, err := GetQbitClient().Get("/api/v2/search/installPlugin", nil)
+	resp, err := GetQbitClient().Post(ctx, "/api/v2/search/installPlugin", params)
 	if err != nil {
 		return err
 	}
 	defer utils.SafeClose(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return newQbitClientError("InstallPlugin", resp, "")
+	}
 	return nil
 }
 
-func UninstallPlugin(hashes []string) error {
+func UninstallPlugin(ctx context.Context, hashes []string) error {
 	params := url.Values{}
 	params.Set("names", strings.Join(hashes, "|"))
-	resp, err := GetQbitClient().Get("/api/v2/search/uninstallPlugin", nil)
+	resp, err := GetQbitClient().Post(ctx, "/api/v2/search/uninstallPlugin", params)
 	if err != nil {
 		return err
 	}
 	defer utils.SafeClose(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return newQbitClientError("UninstallPlugin", resp, "")
+	}
 	return nil
 }
 
-func EnablePlugin(name []string, enable bool) error {
+func EnablePlugin(ctx context.Context, name []string, enable bool) error {
 	params := url.Values{}
 	params.Set("names", strings.Join(name, "|"))
 	params.Set("enable", strconv.FormatBool(enable))
-	resp, err := GetQbitClient().Post("/api/v2/search/enablePlugin", params)
+	resp, err := GetQbitClient().Post(ctx, "/api/v2/search/enablePlugin", params)
 	if err != nil {
 		return err
 	}
 	defer utils.SafeClose(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return newQbitClientError("EnablePlugin", resp, "")
+	}
 	return nil
 }
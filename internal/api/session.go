@@ -0,0 +1,72 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"qbit-cli/internal/config"
+)
+
+// qBittorrent bans IP after too many failed logins,
+// so WebUI session(SID cookie) is persisted to avoid logging in on every invocation.
+
+var sessionFile = "session.json"
+
+type qbitSession struct {
+	Username string `json:"username"`
+	Cookie   string `json:"cookie"`
+}
+
+func sessionPath() string {
+	return filepath.Join(filepath.Dir(config.GetConfig().ConfigPath()), sessionFile)
+}
+
+func loadSessions() map[string]qbitSession {
+	sessions := make(map[string]qbitSession)
+	data, err := os.ReadFile(sessionPath())
+	if err != nil {
+		return sessions
+	}
+	if err := json.Unmarshal(data, &sessions); err != nil {
+		log.Printf("parse session file failed: %v\n", err)
+	}
+	return sessions
+}
+
+func writeSessions(sessions map[string]qbitSession) error {
+	data, err := json.MarshalIndent(sessions, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(sessionPath(), data, 0600)
+}
+
+func loadSession(host, username string) string {
+	s, ok := loadSessions()[host]
+	if !ok || s.Username != username {
+		return ""
+	}
+	return s.Cookie
+}
+
+func saveSession(host, username, cookie string) error {
+	// replayed session is redacted, don't overwrite the real one
+	if config.ReplayDir != "" {
+		return nil
+	}
+	sessions := loadSessions()
+	sessions[host] = qbitSession{Username: username, Cookie: cookie}
+	return writeSessions(sessions)
+}
+
+func removeSession(host, username string) {
+	sessions := loadSessions()
+	if s, ok := sessions[host]; !ok || s.Username != username {
+		return
+	}
+	delete(sessions, host)
+	if err := writeSessions(sessions); err != nil {
+		log.Printf("remove session failed: %v\n", err)
+	}
+}
@@ -0,0 +1,319 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"qbit-cli/pkg/utils"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// all the /sync/* api here
+// https://github.com/qbittorrent/qBittorrent/wiki/WebUI-API-(qBittorrent-5.0)#sync
+
+func SyncMainData(ctx context.Context, rid int64) (*MainData, error) {
+	params := url.Values{"rid": {strconv.FormatInt(rid, 10)}}
+	resp, err := GetQbitClient().Get(ctx, "/api/v2/sync/maindata", params)
+	if err != nil {
+		return nil, err
+	}
+	defer utils.SafeClose(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, newQbitClientError("SyncMainData", resp, "")
+	}
+	var data MainData
+	if err := ParseJSON(resp, &data); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+func SyncTorrentPeers(ctx context.Context, hash string, rid int64) (*PeerResult, error) {
+	params := url.Values{
+		"hash": {hash},
+		"rid":  {strconv.FormatInt(rid, 10)},
+	}
+	resp, err := GetQbitClient().Get(ctx, "/api/v2/sync/torrentPeers", params)
+	if err != nil {
+		return nil, err
+	}
+	defer utils.SafeClose(resp.Body)
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, newQbitClientError("SyncTorrentPeers", resp, "torrent hash was not found")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, newQbitClientError("SyncTorrentPeers", resp, "")
+	}
+	var result PeerResult
+	if err := ParseJSON(resp, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// SyncEvent describes what changed after one sync.
+type SyncEvent struct {
+	FullUpdate bool
+	// hashes of torrents
+	Added, Updated, Removed []string
+	CategoriesChanged       bool
+	TagsChanged             bool
+	ServerStateChanged      bool
+	Err                     error
+}
+
+// Empty reports whether nothing changed.
+func (e *SyncEvent) Empty() bool {
+	return !e.FullUpdate && len(e.Added) == 0 && len(e.Updated) == 0 && len(e.Removed) == 0 &&
+		!e.CategoriesChanged && !e.TagsChanged && !e.ServerStateChanged
+}
+
+// MainDataSync keeps an in-memory model of torrents, categories, tags and server state.
+// Only changes since last rid are transferred, partial objects are merged into the model.
+type MainDataSync struct {
+	mu          sync.RWMutex
+	rid         int64
+	torrents    map[string]*Torrent
+	categories  map[string]*TorrentCategory
+	tags        map[string]struct{}
+	serverState ServerState
+}
+
+func NewMainDataSync() *MainDataSync {
+	return &MainDataSync{
+		torrents:   make(map[string]*Torrent),
+		categories: make(map[string]*TorrentCategory),
+		tags:       make(map[string]struct{}),
+	}
+}
+
+// Update fetches changes since last update and applies them.
+func (s *MainDataSync) Update(ctx context.Context) (*SyncEvent, error) {
+	s.mu.RLock()
+	rid := s.rid
+	s.mu.RUnlock()
+
+	data, err := SyncMainData(ctx, rid)
+	if err != nil {
+		return nil, err
+	}
+	return s.apply(data)
+}
+
+func (s *MainDataSync) apply(data *MainData) (*SyncEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	event := &SyncEvent{FullUpdate: data.FullUpdate}
+	if data.FullUpdate {
+		s.torrents = make(map[string]*Torrent, len(data.Torrents))
+		s.categories = make(map[string]*TorrentCategory, len(data.Categories))
+		s.tags = make(map[string]struct{}, len(data.Tags))
+		s.serverState = ServerState{}
+	}
+	s.rid = data.Rid
+
+	for hash, raw := range data.Torrents {
+		t, exists := s.torrents[hash]
+		if !exists {
+			t = &Torrent{}
+		}
+		// partial object only contains changed fields, unmarshal keeps the others
+		if err := json.Unmarshal(raw, t); err != nil {
+			return nil, err
+		}
+		t.Hash = hash
+		s.torrents[hash] = t
+		if exists {
+			event.Updated = append(event.Updated, hash)
+		} else {
+			event.Added = append(event.Added, hash)
+		}
+	}
+	for _, hash := range data.TorrentsRemoved {
+		if _, exists := s.torrents[hash]; exists {
+			delete(s.torrents, hash)
+			event.Removed = append(event.Removed, hash)
+		}
+	}
+
+	for name, raw := range data.Categories {
+		c, exists := s.categories[name]
+		if !exists {
+			c = &TorrentCategory{}
+		}
+		if err := json.Unmarshal(raw, c); err != nil {
+			return nil, err
+		}
+		c.Name = name
+		s.categories[name] = c
+		event.CategoriesChanged = true
+	}
+	for _, name := range data.CategoriesRemoved {
+		delete(s.categories, name)
+		event.CategoriesChanged = true
+	}
+
+	for _, tag := range data.Tags {
+		s.tags[tag] = struct{}{}
+		event.TagsChanged = true
+	}
+	for _, tag := range data.TagsRemoved {
+		delete(s.tags, tag)
+		event.TagsChanged = true
+	}
+
+	if len(data.ServerState) > 0 {
+		if err := json.Unmarshal(data.ServerState, &s.serverState); err != nil {
+			return nil, err
+		}
+		event.ServerStateChanged = true
+	}
+
+	sort.Strings(event.Added)
+	sort.Strings(event.Updated)
+	sort.Strings(event.Removed)
+	return event, nil
+}
+
+// Watch updates every interval until ctx is done, events are sent to the returned channel.
+// Empty events are skipped, errors are sent as event with Err set.
+func (s *MainDataSync) Watch(ctx context.Context, interval time.Duration) <-chan SyncEvent {
+	events := make(chan SyncEvent)
+	go func() {
+		defer close(events)
+		for {
+			event, err := s.Update(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				event = &SyncEvent{Err: err}
+			}
+			if !event.Empty() || event.Err != nil {
+				select {
+				case events <- *event:
+				case <-ctx.Done():
+					return
+				}
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(interval):
+			}
+		}
+	}()
+	return events
+}
+
+// Torrents returns a copy of torrents matched by filter, sorted by added time. nil filter matches all.
+func (s *MainDataSync) Torrents(filter func(t *Torrent) bool) []Torrent {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	list := make([]Torrent, 0, len(s.torrents))
+	for _, t := range s.torrents {
+		if filter == nil || filter(t) {
+			list = append(list, *t)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].AddOn == list[j].AddOn {
+			return list[i].Hash < list[j].Hash
+		}
+		return list[i].AddOn < list[j].AddOn
+	})
+	return list
+}
+
+// Torrent returns a copy of torrent by hash.
+func (s *MainDataSync) Torrent(hash string) (Torrent, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	t, ok := s.torrents[hash]
+	if !ok {
+		return Torrent{}, false
+	}
+	return *t, true
+}
+
+func (s *MainDataSync) Categories() []TorrentCategory {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	list := make([]TorrentCategory, 0, len(s.categories))
+	for _, c := range s.categories {
+		list = append(list, *c)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+func (s *MainDataSync) Tags() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	list := make([]string, 0, len(s.tags))
+	for tag := range s.tags {
+		list = append(list, tag)
+	}
+	sort.Strings(list)
+	return list
+}
+
+func (s *MainDataSync) ServerState() ServerState {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.serverState
+}
+
+// PeerSync keeps peers of a torrent through /sync/torrentPeers.
+type PeerSync struct {
+	Hash  string
+	rid   int64
+	peers map[string]*TorrentPeer
+}
+
+func NewPeerSync(hash string) *PeerSync {
+	return &PeerSync{Hash: hash, peers: make(map[string]*TorrentPeer)}
+}
+
+// Update fetches changes since last update and returns all peers sorted by address.
+func (s *PeerSync) Update(ctx context.Context) ([]TorrentPeer, error) {
+	result, err := SyncTorrentPeers(ctx, s.Hash, s.rid)
+	if err != nil {
+		return nil, err
+	}
+	if result.FullUpdate {
+		s.peers = make(map[string]*TorrentPeer, len(result.Peers))
+	}
+	s.rid = result.Rid
+	for addr, raw := range result.Peers {
+		p, exists := s.peers[addr]
+		if !exists {
+			p = &TorrentPeer{}
+		}
+		if err := json.Unmarshal(raw, p); err != nil {
+			return nil, err
+		}
+		s.peers[addr] = p
+	}
+	for _, addr := range result.PeersRemoved {
+		delete(s.peers, addr)
+	}
+
+	addrs := make([]string, 0, len(s.peers))
+	for addr := range s.peers {
+		addrs = append(addrs, addr)
+	}
+	sort.Strings(addrs)
+	peers := make([]TorrentPeer, 0, len(addrs))
+	for _, addr := range addrs {
+		peers = append(peers, *s.peers[addr])
+	}
+	return peers, nil
+}
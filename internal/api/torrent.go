@@ -1,11 +1,12 @@
 package api
 
 import (
-	"errors"
-	"fmt"
+	"context"
+	"io"
 	"net/http"
 	"net/url"
 	"os"
+	"qbit-cli/pkg/metainfo"
 	"qbit-cli/pkg/utils"
 	"strconv"
 	"strings"
@@ -14,71 +15,107 @@ import (
 // https://github.com/qbittorrent/qBittorrent/wiki/WebUI-API-(qBittorrent-5.0)
 // all the /torrent/* api here
 
-func TorrentList(params url.Values) ([]Torrent, error) {
-	resp, err := GetQbitClient().Get("/api/v2/torrents/info", params)
+func TorrentList(ctx context.Context, params url.Values) ([]Torrent, error) {
+	if params.Has("filter") {
+		filter, err := stateFilter(ctx, params.Get("filter"))
+		if err != nil {
+			return nil, err
+		}
+		params.Set("filter", filter)
+	}
+	resp, err := GetQbitClient().Get(ctx, "/api/v2/torrents/info", params)
 	if err != nil {
 		return nil, err
 	}
 	defer utils.SafeClose(resp.Body)
 
 	var torrentList []Torrent
+	if resp.StatusCode != http.StatusOK {
+		return nil, newQbitClientError("TorrentList", resp, "")
+	}
 	if err := ParseJSON(resp, &torrentList); err != nil {
 		return nil, err
 	}
 	return torrentList, nil
 }
 
-func TorrentAdd(urls []string, params url.Values) error {
+func TorrentAdd(ctx context.Context, urls []string, params url.Values) error {
 	var localFiles = make([]*os.File, 0, len(urls))
 	var netUrl = make([]string, 0, len(urls))
+	defer func() {
+		for _, file := range localFiles {
+			utils.SafeClose(file)
+		}
+	}()
 	for _, path := range urls {
 		file, _ := os.Open(path)
-		if file != nil {
-			localFiles = append(localFiles, file)
-		} else {
+		if file == nil {
 			netUrl = append(netUrl, path)
+			continue
+		}
+		localFiles = append(localFiles, file)
+		// local files are checked before uploading, qBittorrent only answers 415 for invalid ones
+		if _, err := metainfo.Load(path); err != nil {
+			return err
 		}
 	}
+	if err := renameAddParams(ctx, params); err != nil {
+		return err
+	}
 	c := GetQbitClient()
 	if len(localFiles) > 0 {
 		params.Del("urls")
-		resp, err := c.PostForm("/api/v2/torrents/add", params, "torrents", localFiles)
+		resp, err := c.PostForm(ctx, "/api/v2/torrents/add", params, "torrents", localFiles)
 		if err != nil {
-			fmt.Println(err)
-		} else if resp.StatusCode == http.StatusUnsupportedMediaType {
-			fmt.Println("file is not valid")
-		} else if resp.StatusCode != http.StatusOK {
-			fmt.Println(resp.Status)
+			return err
 		}
+		err = torrentAddResult(resp)
 		utils.SafeClose(resp.Body)
-		for _, file := range localFiles {
-			utils.SafeClose(file)
+		if err != nil {
+			return err
 		}
 	}
 
 	if len(netUrl) > 0 {
 		params.Set("urls", strings.Join(netUrl, "\n"))
-		resp, err := c.Post("/api/v2/torrents/add", params)
+		resp, err := c.Post(ctx, "/api/v2/torrents/add", params)
 		if err != nil {
 			return err
 		}
 		defer utils.SafeClose(resp.Body)
-
-		if resp.StatusCode != http.StatusOK {
-			return fmt.Errorf("torrent add fail: %s", resp.Status)
-		}
+		return torrentAddResult(resp)
 	}
 
 	return nil
 }
 
-func TorrentFiles(params url.Values) ([]TorrentFile, error) {
-	resp, err := GetQbitClient().Get("/api/v2/torrents/files", params)
+// torrentAddResult checks /torrents/add response, qBittorrent answers "Fails." with 200 if no torrent is added.
+func torrentAddResult(resp *http.Response) error {
+	if resp.StatusCode == http.StatusUnsupportedMediaType {
+		return newQbitClientError("TorrentAdd", resp, "torrent file is not valid")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return newQbitClientError("TorrentAdd", resp, "")
+	}
+	body, _ := ParseString(resp)
+	if strings.TrimSpace(body) == "Fails." {
+		e := newQbitClientError("TorrentAdd", resp, "torrent add failed, it may already exist")
+		e.kind = ErrConflict
+		return e
+	}
+	return nil
+}
+
+func TorrentFiles(ctx context.Context, params url.Values) ([]TorrentFile, error) {
+	resp, err := GetQbitClient().Get(ctx, "/api/v2/torrents/files", params)
 	if err != nil {
 		return nil, err
 	}
 	defer utils.SafeClose(resp.Body)
 
+	if resp.StatusCode != http.StatusOK {
+		return nil, newQbitClientError("TorrentFiles", resp, "")
+	}
 	var torrentFiles []TorrentFile
 	if err := ParseJSON(resp, &torrentFiles); err != nil {
 		return nil, err
@@ -86,75 +123,86 @@ func TorrentFiles(params url.Values) ([]TorrentFile, error) {
 	return torrentFiles, nil
 }
 
-func TorrentRenameFolder(hash string, old string, new string) error {
+func TorrentRenameFolder(ctx context.Context, hash string, old string, new string) error {
 	params := url.Values{
 		"hash":    {hash},
 		"oldPath": {old},
 		"newPath": {new},
 	}
-	resp, err := GetQbitClient().Post("/api/v2/torrents/renameFolder", params)
+	if err := RequireFeature(ctx, FeatureRenameFolder); err != nil {
+		return err
+	}
+	resp, err := GetQbitClient().Post(ctx, "/api/v2/torrents/renameFolder", params)
 	if err != nil {
 		return err
 	}
 	defer utils.SafeClose(resp.Body)
 
 	if resp.StatusCode != http.StatusOK {
-		return &QbitClientError{resp.Status, "TorrentRenameFolder", nil}
+		return newQbitClientError("TorrentRenameFolder", resp, "")
 	}
 	return nil
 }
 
-func TorrentRenameFile(hash string, old string, new string) error {
+func TorrentRenameFile(ctx context.Context, hash string, old string, new string) error {
 	params := url.Values{
 		"hash":    {hash},
 		"oldPath": {old},
 		"newPath": {new},
 	}
-	resp, err := GetQbitClient().Post("/api/v2/torrents/renameFile", params)
+	if err := RequireFeature(ctx, FeatureRenameFolder); err != nil {
+		return err
+	}
+	resp, err := GetQbitClient().Post(ctx, "/api/v2/torrents/renameFile", params)
 	if err != nil {
 		return err
 	}
 	defer utils.SafeClose(resp.Body)
 
 	if resp.StatusCode != http.StatusOK {
-		return &QbitClientError{resp.Status, "TorrentRenameFile", nil}
+		return newQbitClientError("TorrentRenameFile", resp, "")
 	}
 	return nil
 }
 
-func RenameTorrent(hash string, name string) error {
+func RenameTorrent(ctx context.Context, hash string, name string) error {
 	params := url.Values{
 		"hash": {hash},
 		"name": {name},
 	}
-	resp, err := GetQbitClient().Post("/api/v2/torrents/rename", params)
+	resp, err := GetQbitClient().Post(ctx, "/api/v2/torrents/rename", params)
 	if err != nil {
 		return err
 	}
 	defer utils.SafeClose(resp.Body)
 	if resp.StatusCode == http.StatusNotFound {
-		return &QbitClientError{"hash is invalid", "TorrentRename", nil}
+		return newQbitClientError("TorrentRename", resp, "hash is invalid")
 	}
 	if resp.StatusCode != http.StatusOK {
-		return &QbitClientError{resp.Status, "RenameTorrent", nil}
+		return newQbitClientError("RenameTorrent", resp, "")
 	}
 	return nil
 }
 
-func UpdateTorrent(operation string, params url.Values) error {
-	resp, err := GetQbitClient().Post("/api/v2/torrents/"+operation, params)
+// UpdateTorrent post /torrents/{operation}, operation is renamed for older servers, e.g. stop to pause.
+func UpdateTorrent(ctx context.Context, operation string, params url.Values) error {
+	endpoint, err := torrentEndpoint(ctx, operation)
+	if err != nil {
+		return err
+	}
+	resp, err := GetQbitClient().Post(ctx, "/api/v2/torrents/"+endpoint, params)
 	if err != nil {
 		return err
 	}
 	defer utils.SafeClose(resp.Body)
 	if resp.StatusCode != http.StatusOK {
-		return &QbitClientError{resp.Status, "TorrentUpdate: " + operation, nil}
+		return newQbitClientError("TorrentUpdate: "+operation, resp, "")
 	}
 	return nil
 }
 
-func TagList() ([]string, error) {
-	resp, err := GetQbitClient().Get("/api/v2/torrents/tags", url.Values{})
+func TagList(ctx context.Context) ([]string, error) {
+	resp, err := GetQbitClient().Get(ctx, "/api/v2/torrents/tags", url.Values{})
 	if err != nil {
 		return nil, err
 	}
@@ -167,19 +215,22 @@ func TagList() ([]string, error) {
 }
 
 // TagUpdate deleteTags createTags
-func TagUpdate(operation string, name []string) error {
+func TagUpdate(ctx context.Context, operation string, name []string) error {
 	params := url.Values{}
 	params.Set("tags", strings.Join(name, ","))
-	resp, err := GetQbitClient().Post("/api/v2/torrents/"+operation, params)
+	resp, err := GetQbitClient().Post(ctx, "/api/v2/torrents/"+operation, params)
 	if err != nil {
 		return err
 	}
 	defer utils.SafeClose(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return newQbitClientError("TagUpdate: "+operation, resp, "")
+	}
 	return nil
 }
 
-func CategoryList() (*[]TorrentCategory, error) {
-	resp, err := GetQbitClient().Get("/api/v2/torrents/categories", url.Values{})
+func CategoryList(ctx context.Context) (*[]TorrentCategory, error) {
+	resp, err := GetQbitClient().Get(ctx, "/api/v2/torrents/categories", url.Values{})
 	if err != nil {
 		return nil, err
 	}
@@ -195,81 +246,93 @@ func CategoryList() (*[]TorrentCategory, error) {
 	return &results, nil
 }
 
-func CategoryAdd(name string, path string) error {
+func CategoryAdd(ctx context.Context, name string, path string) error {
 	params := url.Values{}
 	params.Set("category", name)
 	params.Set("savePath", path)
-	resp, err := GetQbitClient().Post("/api/v2/torrents/createCategory", params)
+	resp, err := GetQbitClient().Post(ctx, "/api/v2/torrents/createCategory", params)
 	if err != nil {
 		return err
 	}
 	defer utils.SafeClose(resp.Body)
 	if resp.StatusCode == http.StatusConflict {
-		return errors.New("category already exists")
+		return newQbitClientError("CategoryAdd", resp, "category already exists")
 	}
-	if resp.StatusCode == http.StatusBadRequest {
-		return errors.New(resp.Status)
+	if resp.StatusCode != http.StatusOK {
+		return newQbitClientError("CategoryAdd", resp, "")
 	}
 	return nil
 }
 
-func CategoryDelete(names []string) error {
+func CategoryDelete(ctx context.Context, names []string) error {
 	params := url.Values{}
 	params.Set("categories", strings.Join(names, "\n"))
-	resp, err := GetQbitClient().Post("/api/v2/torrents/removeCategories", params)
+	resp, err := GetQbitClient().Post(ctx, "/api/v2/torrents/removeCategories", params)
 	if err != nil {
 		return err
 	}
-	utils.SafeClose(resp.Body)
+	defer utils.SafeClose(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return newQbitClientError("CategoryDelete", resp, "")
+	}
 	return nil
 }
 
-func CategoryUpdate(name string, path string) error {
+func CategoryUpdate(ctx context.Context, name string, path string) error {
 	params := url.Values{}
 	params.Set("category", name)
 	params.Set("savePath", path)
-	resp, err := GetQbitClient().Post("/api/v2/torrents/editCategory", params)
+	resp, err := GetQbitClient().Post(ctx, "/api/v2/torrents/editCategory", params)
 	if err != nil {
 		return err
 	}
 	defer utils.SafeClose(resp.Body)
 	if resp.StatusCode == http.StatusConflict {
-		return errors.New("category not exists")
+		e := newQbitClientError("CategoryUpdate", resp, "category not exists")
+		e.kind = ErrNotFound
+		return e
 	}
-	if resp.StatusCode == http.StatusBadRequest {
-		return errors.New(resp.Status)
+	if resp.StatusCode != http.StatusOK {
+		return newQbitClientError("CategoryUpdate", resp, "")
 	}
 	return nil
 }
 
-func SetTorrentFilePriority(hash, ids string, priority int) error {
+func SetTorrentFilePriority(ctx context.Context, hash, ids string, priority int) error {
 	params := url.Values{}
 	params.Set("hash", hash)
 	params.Set("id", ids)
 	params.Set("priority", strconv.FormatInt(int64(priority), 10))
-	resp, err := GetQbitClient().Post("/api/v2/torrents/filePrio", params)
-	if err != nil {
+	if err := RequireFeature(ctx, FeatureFileIndex); err != nil {
 		return err
 	}
-	utils.SafeClose(resp.Body)
-	if resp.StatusCode == http.StatusConflict {
-		return errors.New("torrent metadata hasn't downloaded yet or at least one file id was not found")
-	}
-	if resp.StatusCode == http.StatusBadRequest {
-		return errors.New("priority is invalid or at least one file id is not a valid integer")
-	}
-	if resp.StatusCode == http.StatusNotFound {
-		return errors.New("torrent hash was not found")
+	resp, err := GetQbitClient().Post(ctx, "/api/v2/torrents/filePrio", params)
+	if err != nil {
+		return err
 	}
-	return nil
+	defer utils.SafeClose(resp.Body)
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusConflict:
+		return newQbitClientError("SetTorrentFilePriority", resp, "torrent metadata hasn't downloaded yet or at least one file id was not found")
+	case http.StatusBadRequest:
+		return newQbitClientError("SetTorrentFilePriority", resp, "priority is invalid or at least one file id is not a valid integer")
+	case http.StatusNotFound:
+		return newQbitClientError("SetTorrentFilePriority", resp, "torrent hash was not found")
+	}
+	return newQbitClientError("SetTorrentFilePriority", resp, "")
 }
 
-func TorrentTrackers(hash string) (*[]TorrentTracker, error) {
-	resp, err := GetQbitClient().Get("/api/v2/torrents/trackers", url.Values{"hash": {hash}})
+func TorrentTrackers(ctx context.Context, hash string) (*[]TorrentTracker, error) {
+	resp, err := GetQbitClient().Get(ctx, "/api/v2/torrents/trackers", url.Values{"hash": {hash}})
 	if err != nil {
 		return nil, err
 	}
 	defer utils.SafeClose(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, newQbitClientError("TorrentTrackers", resp, "")
+	}
 	var trackers []TorrentTracker
 	if err := ParseJSON(resp, &trackers); err != nil {
 		return nil, err
@@ -277,21 +340,106 @@ func TorrentTrackers(hash string) (*[]TorrentTracker, error) {
 	return &trackers, nil
 }
 
-func TorrentPeers(hash string) (*[]TorrentPeer, error) {
-	params := url.Values{}
-	params.Set("hash", hash)
-	resp, err := GetQbitClient().Get("/api/v2/sync/torrentPeers", params)
+// TorrentAddTrackers adds trackers to a torrent, ErrNotFound if hash is unknown.
+func TorrentAddTrackers(ctx context.Context, hash string, urls []string) error {
+	params := url.Values{
+		"hash": {hash},
+		"urls": {strings.Join(urls, "\n")},
+	}
+	resp, err := GetQbitClient().Post(ctx, "/api/v2/torrents/addTrackers", params)
+	if err != nil {
+		return err
+	}
+	defer utils.SafeClose(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return newQbitClientError("TorrentAddTrackers", resp, "")
+	}
+	return nil
+}
+
+// TorrentEditTracker replaces url of a tracker, ErrConflict if newUrl exists or origUrl is not found.
+func TorrentEditTracker(ctx context.Context, hash, origUrl, newUrl string) error {
+	params := url.Values{
+		"hash":    {hash},
+		"origUrl": {origUrl},
+		"newUrl":  {newUrl},
+	}
+	resp, err := GetQbitClient().Post(ctx, "/api/v2/torrents/editTracker", params)
+	if err != nil {
+		return err
+	}
+	defer utils.SafeClose(resp.Body)
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusBadRequest:
+		return newQbitClientError("TorrentEditTracker", resp, "invalid new url "+newUrl)
+	case http.StatusConflict:
+		return newQbitClientError("TorrentEditTracker", resp, "new url already exists or original url is not found")
+	}
+	return newQbitClientError("TorrentEditTracker", resp, "")
+}
+
+// TorrentRemoveTrackers removes trackers of a torrent, ErrConflict if none of urls is found.
+func TorrentRemoveTrackers(ctx context.Context, hash string, urls []string) error {
+	params := url.Values{
+		"hash": {hash},
+		"urls": {strings.Join(urls, "|")},
+	}
+	resp, err := GetQbitClient().Post(ctx, "/api/v2/torrents/removeTrackers", params)
+	if err != nil {
+		return err
+	}
+	defer utils.SafeClose(resp.Body)
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusConflict:
+		return newQbitClientError("TorrentRemoveTrackers", resp, "trackers are not found")
+	}
+	return newQbitClientError("TorrentRemoveTrackers", resp, "")
+}
+
+// TorrentExport returns content of the .torrent file, ErrConflict if metadata is not downloaded yet.
+func TorrentExport(ctx context.Context, hash string) ([]byte, error) {
+	if err := RequireFeature(ctx, FeatureExport); err != nil {
+		return nil, err
+	}
+	resp, err := GetQbitClient().Get(ctx, "/api/v2/torrents/export", url.Values{"hash": {hash}})
 	if err != nil {
 		return nil, err
 	}
 	defer utils.SafeClose(resp.Body)
-	var result PeerResult
-	if err := ParseJSON(resp, &result); err != nil {
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return io.ReadAll(resp.Body)
+	case http.StatusConflict:
+		return nil, newQbitClientError("TorrentExport", resp, "torrent metadata hasn't downloaded yet")
+	}
+	return nil, newQbitClientError("TorrentExport", resp, "")
+}
+
+// TorrentProperties returns generic properties of a torrent, ErrNotFound if hash is unknown.
+func TorrentProperties(ctx context.Context, hash string) (*TorrentGenericProperties, error) {
+	resp, err := GetQbitClient().Get(ctx, "/api/v2/torrents/properties", url.Values{"hash": {hash}})
+	if err != nil {
+		return nil, err
+	}
+	defer utils.SafeClose(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, newQbitClientError("TorrentProperties", resp, "")
+	}
+	var properties TorrentGenericProperties
+	if err := ParseJSON(resp, &properties); err != nil {
 		return nil, err
 	}
-	var peers = make([]TorrentPeer, 0, len(result.Peers))
-	for _, peer := range result.Peers {
-		peers = append(peers, peer)
+	return &properties, nil
+}
+
+func TorrentPeers(ctx context.Context, hash string) (*[]TorrentPeer, error) {
+	peers, err := NewPeerSync(hash).Update(ctx)
+	if err != nil {
+		return nil, err
 	}
 	return &peers, nil
 }
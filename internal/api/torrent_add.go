@@ -0,0 +1,228 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/url"
+	"qbit-cli/pkg/metainfo"
+	"qbit-cli/pkg/utils"
+	"slices"
+	"strings"
+)
+
+// statuses of AddResult
+const (
+	AddStatusAdded   = "added"
+	AddStatusPresent = "present"
+	AddStatusMerged  = "merged"
+	AddStatusFailed  = "failed"
+	// AddStatusRefused torrents would leave less free space than the reserve of SpaceGuard
+	AddStatusRefused = "refused"
+)
+
+// DuplicateAction is what TorrentAddChecked does with torrents which already exist.
+type DuplicateAction string
+
+const (
+	DuplicateSkip DuplicateAction = "skip"
+	// DuplicateMerge adds the trackers of the source which the existing torrent doesn't have.
+	DuplicateMerge DuplicateAction = "merge"
+)
+
+var DuplicateActions = []string{string(DuplicateSkip), string(DuplicateMerge)}
+
+// AddOptions are options of TorrentAddChecked.
+type AddOptions struct {
+	OnDuplicate DuplicateAction
+	// Sizes are the sizes of sources which can't be parsed locally, e.g. http urls of search results
+	Sizes map[string]int64
+	// Space checks the free space before adding sources of known size, nil disables it
+	Space *SpaceGuard
+}
+
+// AddResult is the result of a source of TorrentAddChecked.
+// Hash and Name are empty for http urls, they can't be known without downloading the .torrent.
+type AddResult struct {
+	Source string `json:"source"`
+	Hash   string `json:"hash"`
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	// Size is 0 if it's unknown
+	Size int64 `json:"size,omitempty"`
+	// Trackers are the merged trackers
+	Trackers []string `json:"trackers,omitempty"`
+	Error    string   `json:"error,omitempty"`
+	// Warning is set when a torrent is added despite low free space
+	Warning string `json:"warning,omitempty"`
+}
+
+// addSource is a source of TorrentAddChecked with the info parsed from magnet link or .torrent file.
+type addSource struct {
+	*AddResult
+	hashes   []string
+	trackers []string
+}
+
+// TorrentAddChecked adds torrents one by one after comparing info hashes of magnet links and local .torrent files
+// with existing torrents, duplicates are skipped or merged by opts.OnDuplicate. Sources of known size are checked against
+// the free space of the server by opts.Space, free space is read once and reduced by every added torrent.
+// Failed and refused sources are returned by PartialError.
+func TorrentAddChecked(ctx context.Context, urls []string, params url.Values, opts AddOptions) ([]AddResult, error) {
+	results := make([]AddResult, len(urls))
+	sources := make([]addSource, len(urls))
+	perr := &PartialError{Total: len(urls)}
+	known := false
+	for i, u := range urls {
+		results[i] = AddResult{Source: u, Size: opts.Sizes[u]}
+		sources[i] = addSource{AddResult: &results[i]}
+		if !strings.HasPrefix(u, "magnet:") && !utils.FileExists(u) {
+			continue
+		}
+		m, err := metainfo.Load(u)
+		if err != nil {
+			results[i].Status, results[i].Error = AddStatusFailed, err.Error()
+			perr.Add(u, err)
+			continue
+		}
+		sources[i].hashes = infoHashes(m.InfoHash, m.InfoHashV2)
+		sources[i].trackers = slices.Concat(m.Trackers...)
+		// qBittorrent identifies torrents by v1 hash, or truncated v2 hash of v2 only torrents
+		results[i].Hash, results[i].Name = sources[i].hashes[0], m.Name
+		if m.Size > 0 {
+			results[i].Size = m.Size
+		}
+		if m.InfoHash == "" {
+			results[i].Hash = sources[i].hashes[1]
+		}
+		known = true
+	}
+
+	// existing torrents are keyed by all of their info hashes
+	existing := make(map[string]string)
+	if known {
+		torrents, err := TorrentList(ctx, url.Values{})
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range torrents {
+			for _, h := range infoHashes(t.Hash, t.InfohashV1, t.InfohashV2) {
+				existing[h] = t.Hash
+			}
+		}
+	}
+
+	var (
+		free      int64
+		freeKnown bool
+		freeRead  bool
+	)
+	for _, src := range sources {
+		if src.Status != "" {
+			continue
+		}
+		if hash, ok := findHash(existing, src.hashes); ok {
+			src.Hash = hash
+			err := mergeTrackers(ctx, src, opts.OnDuplicate)
+			if err != nil {
+				src.Status, src.Error = AddStatusFailed, err.Error()
+			}
+			perr.Add(src.Source, err)
+			continue
+		}
+		// params are changed by TorrentAdd
+		addParams := maps.Clone(params)
+		if addParams == nil {
+			addParams = url.Values{}
+		}
+		checked := opts.Space.enabled() && src.Size > 0
+		if checked && !freeRead {
+			var err error
+			if free, freeKnown, err = FreeSpace(ctx); err != nil {
+				return nil, err
+			}
+			freeRead = true
+		}
+		if checked && freeKnown {
+			if reason := opts.Space.check(free, src.Size); reason != "" {
+				switch opts.Space.Action {
+				case LowSpaceWarn:
+					src.Warning = reason
+				case LowSpacePause:
+					src.Warning = reason + ", added stopped"
+					addParams.Set("stopped", "true")
+				default:
+					src.Status, src.Error = AddStatusRefused, reason
+					perr.Add(src.Source, fmt.Errorf("%w: %s", ErrNoSpace, reason))
+					continue
+				}
+			}
+		}
+		err := TorrentAdd(ctx, []string{src.Source}, addParams)
+		switch {
+		case err == nil:
+			src.Status = AddStatusAdded
+			free -= src.Size
+			for _, h := range src.hashes {
+				existing[h] = src.Hash
+			}
+		case errors.Is(err, ErrConflict):
+			// qBittorrent refuses torrents which exist
+			src.Status = AddStatusPresent
+		default:
+			src.Status, src.Error = AddStatusFailed, err.Error()
+			perr.Add(src.Source, err)
+		}
+	}
+	return results, perr.Err()
+}
+
+// infoHashes returns the non-empty hashes, v2 hashes are truncated to 40 characters as qBittorrent identifies v2 torrents.
+func infoHashes(hashes ...string) []string {
+	var result []string
+	for _, h := range hashes {
+		if h == "" {
+			continue
+		}
+		result = append(result, strings.ToLower(h))
+		if len(h) == 64 {
+			result = append(result, strings.ToLower(h[:40]))
+		}
+	}
+	return result
+}
+
+func findHash(existing map[string]string, hashes []string) (string, bool) {
+	for _, h := range hashes {
+		if hash, ok := existing[h]; ok {
+			return hash, true
+		}
+	}
+	return "", false
+}
+
+func mergeTrackers(ctx context.Context, src addSource, onDuplicate DuplicateAction) error {
+	src.Status = AddStatusPresent
+	if onDuplicate != DuplicateMerge || len(src.trackers) == 0 {
+		return nil
+	}
+	trackers, err := TorrentTrackers(ctx, src.Hash)
+	if err != nil {
+		return err
+	}
+	var missing []string
+	for _, tracker := range src.trackers {
+		if !slices.ContainsFunc(*trackers, func(t TorrentTracker) bool { return t.URL == tracker }) && !slices.Contains(missing, tracker) {
+			missing = append(missing, tracker)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	if err := TorrentAddTrackers(ctx, src.Hash, missing); err != nil {
+		return err
+	}
+	src.Status, src.Trackers = AddStatusMerged, missing
+	return nil
+}
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"cmp"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// torrentFields maps json names of Torrent to field indexes, they are the fields of --where expressions.
+var torrentFields = func() map[string]int {
+	fields := make(map[string]int)
+	typ := reflect.TypeOf(Torrent{})
+	for i := 0; i < typ.NumField(); i++ {
+		name, _, _ := strings.Cut(typ.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			fields[name] = i
+		}
+	}
+	return fields
+}()
+
+// computedFields are calculated on client side, age and idle are seconds.
+var computedFields = map[string]func(t *Torrent) any{
+	"age":          func(t *Torrent) any { return time.Now().Unix() - t.AddOn },
+	"idle":         func(t *Torrent) any { return time.Now().Unix() - t.LastActivity },
+	"tracker_host": func(t *Torrent) any { return t.TrackerHost() },
+}
+
+// TorrentFieldNames returns json names of all Torrent fields and the computed ones.
+func TorrentFieldNames() []string {
+	names := make([]string, 0, len(torrentFields)+len(computedFields))
+	for name := range torrentFields {
+		names = append(names, name)
+	}
+	for name := range computedFields {
+		names = append(names, name)
+	}
+	return names
+}
+
+// IsComputedField reports whether the field is not known by qBittorrent, e.g. it can't be the sort param.
+func IsComputedField(name string) bool {
+	_, ok := computedFields[name]
+	return ok
+}
+
+// Field returns the value of a field by json name, tags is returned as a list.
+func (t *Torrent) Field(name string) (any, bool) {
+	if name == "tags" {
+		return t.TagList(), true
+	}
+	if f, ok := computedFields[name]; ok {
+		return f(t), true
+	}
+	i, ok := torrentFields[name]
+	if !ok {
+		return nil, false
+	}
+	return reflect.ValueOf(t).Elem().Field(i).Interface(), true
+}
+
+// TagList returns tags of torrent, tags are separated by comma
+func (t *Torrent) TagList() []string {
+	tags := make([]string, 0)
+	for _, v := range strings.Split(t.Tags, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			tags = append(tags, v)
+		}
+	}
+	return tags
+}
+
+// TrackerHost returns host of the current tracker, empty if torrent has no working tracker.
+func (t *Torrent) TrackerHost() string {
+	u, err := url.Parse(t.Tracker)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
+
+// CompareTorrents compares a field of torrents, strings are compared case-insensitively and lists item by item.
+func CompareTorrents(a, b *Torrent, field string) int {
+	x, _ := a.Field(field)
+	y, _ := b.Field(field)
+	return compareValues(reflect.ValueOf(x), reflect.ValueOf(y))
+}
+
+func compareValues(x, y reflect.Value) int {
+	if !x.IsValid() || !y.IsValid() || x.Kind() != y.Kind() {
+		return cmp.Compare(x.Kind(), y.Kind())
+	}
+	switch x.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return cmp.Compare(x.Int(), y.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return cmp.Compare(x.Uint(), y.Uint())
+	case reflect.Float32, reflect.Float64:
+		return cmp.Compare(x.Float(), y.Float())
+	case reflect.Bool:
+		return cmp.Compare(boolInt(x.Bool()), boolInt(y.Bool()))
+	case reflect.String:
+		return cmp.Compare(strings.ToLower(x.String()), strings.ToLower(y.String()))
+	case reflect.Slice:
+		for i := 0; i < x.Len() && i < y.Len(); i++ {
+			if c := compareValues(x.Index(i), y.Index(i)); c != 0 {
+				return c
+			}
+		}
+		return cmp.Compare(x.Len(), y.Len())
+	}
+	return 0
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"qbit-cli/pkg/utils"
+	"strconv"
+)
+
+// special values of share limits
+const (
+	LimitGlobal = -2
+	LimitNone   = -1
+)
+
+// ShareLimitAction is what qBittorrent does with a torrent when its share limit is reached.
+type ShareLimitAction string
+
+const (
+	// ShareLimitActionDefault uses the action of the global share limits
+	ShareLimitActionDefault            ShareLimitAction = "Default"
+	ShareLimitActionStop               ShareLimitAction = "Stop"
+	ShareLimitActionRemove             ShareLimitAction = "Remove"
+	ShareLimitActionRemoveWithContent  ShareLimitAction = "RemoveWithContent"
+	ShareLimitActionEnableSuperSeeding ShareLimitAction = "EnableSuperSeeding"
+)
+
+// ShareLimits are the share limits of torrents, LimitGlobal uses the global limit and LimitNone means no limit.
+// Times are in minutes, empty Action keeps the current action.
+type ShareLimits struct {
+	RatioLimit               float64
+	SeedingTimeLimit         int64
+	InactiveSeedingTimeLimit int64
+	Action                   ShareLimitAction
+}
+
+// TorrentDownloadLimits returns download limits of torrents keyed by hash, in bytes per second and 0 means no limit.
+func TorrentDownloadLimits(ctx context.Context, hashes string) (map[string]int64, error) {
+	return torrentSpeedLimits(ctx, "downloadLimit", hashes)
+}
+
+// TorrentUploadLimits returns upload limits of torrents keyed by hash, in bytes per second and 0 means no limit.
+func TorrentUploadLimits(ctx context.Context, hashes string) (map[string]int64, error) {
+	return torrentSpeedLimits(ctx, "uploadLimit", hashes)
+}
+
+func torrentSpeedLimits(ctx context.Context, endpoint, hashes string) (map[string]int64, error) {
+	resp, err := GetQbitClient().Post(ctx, "/api/v2/torrents/"+endpoint, url.Values{"hashes": {hashes}})
+	if err != nil {
+		return nil, err
+	}
+	defer utils.SafeClose(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, newQbitClientError("TorrentSpeedLimits: "+endpoint, resp, "")
+	}
+	limits := make(map[string]int64)
+	if err := ParseJSON(resp, &limits); err != nil {
+		return nil, err
+	}
+	return limits, nil
+}
+
+// SetTorrentDownloadLimit sets download limit of torrents in bytes per second, 0 means no limit.
+func SetTorrentDownloadLimit(ctx context.Context, hashes string, limit int64) error {
+	return UpdateTorrent(ctx, "setDownloadLimit", url.Values{"hashes": {hashes}, "limit": {strconv.FormatInt(limit, 10)}})
+}
+
+// SetTorrentUploadLimit sets upload limit of torrents in bytes per second, 0 means no limit.
+func SetTorrentUploadLimit(ctx context.Context, hashes string, limit int64) error {
+	return UpdateTorrent(ctx, "setUploadLimit", url.Values{"hashes": {hashes}, "limit": {strconv.FormatInt(limit, 10)}})
+}
+
+// SetTorrentShareLimits sets share limits of torrents, all limits are required by qBittorrent.
+// Inactive seeding time isn't sent to servers before FeatureInactiveSeedingTime, the action requires FeatureShareLimitAction.
+func SetTorrentShareLimits(ctx context.Context, hashes string, limits ShareLimits) error {
+	params := url.Values{
+		"hashes":           {hashes},
+		"ratioLimit":       {strconv.FormatFloat(limits.RatioLimit, 'f', -1, 64)},
+		"seedingTimeLimit": {strconv.FormatInt(limits.SeedingTimeLimit, 10)},
+	}
+	inactive, err := Supports(ctx, FeatureInactiveSeedingTime)
+	if err != nil {
+		return err
+	}
+	if inactive {
+		params.Set("inactiveSeedingTimeLimit", strconv.FormatInt(limits.InactiveSeedingTimeLimit, 10))
+	}
+	if limits.Action != "" {
+		if err := RequireFeature(ctx, FeatureShareLimitAction); err != nil {
+			return err
+		}
+		params.Set("shareLimitAction", string(limits.Action))
+	}
+	resp, err := GetQbitClient().Post(ctx, "/api/v2/torrents/setShareLimits", params)
+	if err != nil {
+		return err
+	}
+	defer utils.SafeClose(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return newQbitClientError("SetTorrentShareLimits", resp, "")
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package api
+
+import "strings"
+
+// client side version of /torrents/info filter param, used when torrents come from /sync/maindata
+// which has no filter support. pausedXX states are kept for qBittorrent < 5.0
+
+var downloadingStates = []string{"downloading", "metaDL", "forcedMetaDL", "stalledDL", "checkingDL", "stoppedDL",
+	"pausedDL", "queuedDL", "forcedDL", "allocating"}
+var seedingStates = []string{"uploading", "stalledUP", "checkingUP", "queuedUP", "forcedUP"}
+var completedStates = append([]string{"stoppedUP", "pausedUP"}, seedingStates...)
+var stoppedStates = []string{"stoppedDL", "stoppedUP", "pausedDL", "pausedUP"}
+var erroredStates = []string{"error", "missingFiles"}
+var checkingStates = []string{"checkingDL", "checkingUP", "checkingResumeData"}
+
+func stateIn(state string, states []string) bool {
+	for _, s := range states {
+		if s == state {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *Torrent) active() bool {
+	return t.DLSpeed > 0 || t.UPSpeed > 0
+}
+
+// MatchStateFilter reports whether torrent matches the state filter of /torrents/info
+func (t *Torrent) MatchStateFilter(filter string) bool {
+	switch filter {
+	case "", "all":
+		return true
+	case "downloading":
+		return stateIn(t.State, downloadingStates)
+	case "seeding":
+		return stateIn(t.State, seedingStates)
+	case "completed":
+		return stateIn(t.State, completedStates)
+	case "stopped", "paused":
+		return stateIn(t.State, stoppedStates)
+	case "running", "resumed":
+		return !stateIn(t.State, stoppedStates)
+	case "active":
+		return t.active()
+	case "inactive":
+		return !t.active()
+	case "stalled":
+		return t.State == "stalledUP" || t.State == "stalledDL"
+	case "stalled_uploading":
+		return t.State == "stalledUP"
+	case "stalled_downloading":
+		return t.State == "stalledDL"
+	case "checking":
+		return stateIn(t.State, checkingStates)
+	case "moving":
+		return t.State == "moving"
+	case "errored":
+		return stateIn(t.State, erroredStates)
+	}
+	return false
+}
+
+// MatchTag reports whether torrent has the tag, tags of torrent are separated by comma
+func (t *Torrent) MatchTag(tag string) bool {
+	if tag == "" {
+		return true
+	}
+	for _, v := range strings.Split(t.Tags, ",") {
+		if strings.TrimSpace(v) == tag {
+			return true
+		}
+	}
+	return false
+}
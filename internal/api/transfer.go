@@ -0,0 +1,123 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"qbit-cli/pkg/utils"
+	"strconv"
+	"strings"
+)
+
+// TransferInfo is the response of /transfer/info, data of the session are in bytes and limits are bytes per second.
+type TransferInfo struct {
+	DLInfoSpeed      int64  `json:"dl_info_speed"`
+	DLInfoData       int64  `json:"dl_info_data"`
+	UPInfoSpeed      int64  `json:"up_info_speed"`
+	UPInfoData       int64  `json:"up_info_data"`
+	DLRateLimit      int64  `json:"dl_rate_limit"`
+	UPRateLimit      int64  `json:"up_rate_limit"`
+	DHTNodes         int64  `json:"dht_nodes"`
+	ConnectionStatus string `json:"connection_status"`
+}
+
+func GetTransferInfo(ctx context.Context) (*TransferInfo, error) {
+	resp, err := GetQbitClient().Get(ctx, "/api/v2/transfer/info", url.Values{})
+	if err != nil {
+		return nil, err
+	}
+	defer utils.SafeClose(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, newQbitClientError("TransferInfo", resp, "")
+	}
+	var info TransferInfo
+	if err := ParseJSON(resp, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// TransferDownloadLimit returns the global download limit in bytes per second, 0 means no limit.
+// It's the alternative limit if alternative speed limits are enabled.
+func TransferDownloadLimit(ctx context.Context) (int64, error) {
+	return transferLimit(ctx, "downloadLimit")
+}
+
+// TransferUploadLimit returns the global upload limit in bytes per second, 0 means no limit.
+// It's the alternative limit if alternative speed limits are enabled.
+func TransferUploadLimit(ctx context.Context) (int64, error) {
+	return transferLimit(ctx, "uploadLimit")
+}
+
+func transferLimit(ctx context.Context, endpoint string) (int64, error) {
+	resp, err := GetQbitClient().Get(ctx, "/api/v2/transfer/"+endpoint, url.Values{})
+	if err != nil {
+		return 0, err
+	}
+	defer utils.SafeClose(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return 0, newQbitClientError("TransferLimit: "+endpoint, resp, "")
+	}
+	s, err := ParseString(resp)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+}
+
+// SetTransferDownloadLimit sets the global download limit in bytes per second, 0 means no limit.
+func SetTransferDownloadLimit(ctx context.Context, limit int64) error {
+	return transferUpdate(ctx, "setDownloadLimit", url.Values{"limit": {strconv.FormatInt(limit, 10)}})
+}
+
+// SetTransferUploadLimit sets the global upload limit in bytes per second, 0 means no limit.
+func SetTransferUploadLimit(ctx context.Context, limit int64) error {
+	return transferUpdate(ctx, "setUploadLimit", url.Values{"limit": {strconv.FormatInt(limit, 10)}})
+}
+
+// AltSpeedMode reports whether alternative speed limits are enabled.
+func AltSpeedMode(ctx context.Context) (bool, error) {
+	resp, err := GetQbitClient().Get(ctx, "/api/v2/transfer/speedLimitsMode", url.Values{})
+	if err != nil {
+		return false, err
+	}
+	defer utils.SafeClose(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return false, newQbitClientError("AltSpeedMode", resp, "")
+	}
+	mode, err := ParseString(resp)
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(mode) == "1", nil
+}
+
+func ToggleAltSpeedMode(ctx context.Context) error {
+	return transferUpdate(ctx, "toggleSpeedLimitsMode", url.Values{})
+}
+
+// SetAltSpeedMode enables or disables alternative speed limits, it's toggled only if the mode differs.
+func SetAltSpeedMode(ctx context.Context, enabled bool) error {
+	current, err := AltSpeedMode(ctx)
+	if err != nil || current == enabled {
+		return err
+	}
+	return ToggleAltSpeedMode(ctx)
+}
+
+// BanPeers bans peers permanently, peers are host:port.
+func BanPeers(ctx context.Context, peers []string) error {
+	return transferUpdate(ctx, "banPeers", url.Values{"peers": {strings.Join(peers, "|")}})
+}
+
+func transferUpdate(ctx context.Context, operation string, params url.Values) error {
+	resp, err := GetQbitClient().Post(ctx, "/api/v2/transfer/"+operation, params)
+	if err != nil {
+		return err
+	}
+	defer utils.SafeClose(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return newQbitClientError("TransferUpdate: "+operation, resp, "")
+	}
+	return nil
+}
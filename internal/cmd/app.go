@@ -31,7 +31,12 @@ func AppInfo() *cobra.Command {
 	}
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
-		fmt.Println(api.GetQbitServerInfo())
+		ctx := cmd.Context()
+		info, err := api.GetQbitServerInfo(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Println(info)
 		return nil
 	}
 
@@ -54,7 +59,8 @@ So take care when you modify this preference.`,
 	cmd.Flags().StringVar(&filter, "filter", "", "filter to search preferences")
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
-		d, err := api.QbitAppPreference()
+		ctx := cmd.Context()
+		d, err := api.QbitAppPreference(ctx)
 		if err != nil {
 			return err
 		}
@@ -119,6 +125,7 @@ You can get details from https://github.com/qbittorrent/qBittorrent/wiki/WebUI-A
 	cmd.Flags().StringSliceVar(&scanDirs, "scan-dirs", []string{}, "scan dirs")
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
 		var v interface{}
 		if args[0] == "scan_dirs" {
 			if len(scanDirs) < 1 {
@@ -142,7 +149,7 @@ You can get details from https://github.com/qbittorrent/qBittorrent/wiki/WebUI-A
 			args[0]: v,
 		})
 		fmt.Println(string(p))
-		err := api.QbitSetAppPreference(string(p))
+		err := api.QbitSetAppPreference(ctx, string(p))
 		if err != nil {
 			return err
 		}
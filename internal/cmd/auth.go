@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"fmt"
+	"qbit-cli/internal/api"
+
+	"github.com/spf13/cobra"
+)
+
+func AuthCmd() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "auth [command]",
+		Short: "Manage WebUI session",
+		Long: `WebUI session(SID) is cached in session.json which is located in the same directory as config file.
+Cached session is reused by every command and refreshed automatically when expired.`,
+	}
+
+	cmd.AddCommand(AuthLogin())
+	cmd.AddCommand(AuthLogout())
+	cmd.AddCommand(AuthStatus())
+
+	return cmd
+}
+
+func AuthLogin() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "login",
+		Short: "Login and cache the session",
+	}
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		c := api.GetQbitClient()
+		if c.AuthMode() != "cookie" {
+			fmt.Printf("auth mode is %s, no need to login\n", c.AuthMode())
+			return nil
+		}
+		if err := c.Login(cmd.Context()); err != nil {
+			return err
+		}
+		fmt.Println("login success")
+		return nil
+	}
+
+	return cmd
+}
+
+func AuthLogout() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "logout",
+		Short: "Logout and remove the cached session",
+	}
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		if err := api.GetQbitClient().Logout(cmd.Context()); err != nil {
+			return err
+		}
+		fmt.Println("logout success")
+		return nil
+	}
+
+	return cmd
+}
+
+func AuthStatus() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "status",
+		Short: "Show auth status",
+	}
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		c := api.GetQbitClient()
+		cached := "no"
+		if c.CachedSession() != "" {
+			cached = "yes"
+		}
+
+		status := "valid"
+		valid, err := c.SessionValid(cmd.Context())
+		if err != nil {
+			status = err.Error()
+		} else if !valid {
+			status = "expired"
+			if c.CachedSession() == "" {
+				status = "not logged in"
+			}
+		}
+
+		fmt.Printf("host: %s\nuser: %s\nauth mode: %s\nsession cached: %s\nstatus: %s\n",
+			c.Host(), c.User(), c.AuthMode(), cached, status)
+		return nil
+	}
+
+	return cmd
+}
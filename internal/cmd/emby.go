@@ -71,6 +71,7 @@ func ItemRefreshCommand() *cobra.Command {
 	imageRefreshMode.RegisterCompletion(cmd)
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
 		params := url.Values{
 			"Recursive":           {strconv.FormatBool(recursive)},
 			"MetadataRefreshMode": {metadataRefreshMode.Value},
@@ -78,20 +79,18 @@ func ItemRefreshCommand() *cobra.Command {
 			"ReplaceAllMetadata":  {strconv.FormatBool(replaceAllMetadata)},
 			"ReplaceAllImages":    {strconv.FormatBool(replaceAllImages)},
 		}
+		perr := &api.PartialError{Total: len(args)}
 		for _, arg := range args {
 			if resetBeforeRefresh {
-				err := emby.ResetItemMetadata(arg)
-				if err != nil {
-					fmt.Printf("%s reset failed: %s\n", arg, err)
+				if err := emby.ResetItemMetadata(ctx, arg); err != nil {
+					perr.Add(arg, err)
+					continue
 				}
 			}
-			err := emby.RefreshItem(arg, params)
-			if err != nil {
-				fmt.Printf("%s refresh failed: %s\n", arg, err)
-			}
+			perr.Add(arg, emby.RefreshItem(ctx, arg, params))
 		}
 
-		return nil
+		return perr.Err()
 	}
 
 	return cmd
@@ -142,6 +141,7 @@ func ItemList() *cobra.Command {
 	sortOrder.RegisterCompletion(cmd)
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
 		fields := []string{"PremiereDate", "ProductionYear", "Overview", "DateCreated", "People", "ProviderIds"}
 		if emptyActorItem {
 			fields = append(fields, "People")
@@ -188,7 +188,7 @@ func ItemList() *cobra.Command {
 			params.Add("ParentId", strconv.Itoa(parentId))
 		}
 
-		items, err := emby.Items(params)
+		items, err := emby.Items(ctx, params)
 		if err != nil {
 			return err
 		}
@@ -218,28 +218,36 @@ func ItemList() *cobra.Command {
 		if size <= 0 {
 			size = len(items.Items)
 		}
-		fmt.Printf("total items: %d\n", size)
-		headers := []string{"ID", "Name", "Type", "IDX", "Created"}
-		var data = make([][]string, len(items.Items))
-		for i, item := range items.Items {
-			create := ""
-			if !item.CreatedDate.IsZero() {
-				create = item.CreatedDate.Format("2006-01-02")
-			}
-			idx := ""
-			if item.IndexNumber > 0 {
-				idx = strconv.Itoa(item.IndexNumber)
-			}
-			data[i] = []string{item.ID, item.Name, item.Type, idx, create}
+		if tableOutput() {
+			fmt.Printf("total items: %d\n", size)
 		}
-		utils.PrintListWithColWidth(headers, &data, map[int]int{1: 50}, false)
-
-		return nil
+		return printList(embyItemColumns, items.Items)
 	}
 
 	return cmd
 }
 
+var embyItemColumns = []utils.Column[api.EmbyItem]{
+	{Name: "id", Header: "ID", Value: func(i api.EmbyItem) any { return i.ID }},
+	{Name: "name", Header: "Name", Value: func(i api.EmbyItem) any { return i.Name }, Width: 50},
+	{Name: "type", Header: "Type", Value: func(i api.EmbyItem) any { return i.Type }},
+	{Name: "index", Header: "IDX", Value: func(i api.EmbyItem) any { return i.IndexNumber }, Text: func(i api.EmbyItem) string {
+		if i.IndexNumber > 0 {
+			return strconv.Itoa(i.IndexNumber)
+		}
+		return ""
+	}},
+	{Name: "created", Header: "Created", Value: func(i api.EmbyItem) any { return i.CreatedDate }, Text: func(i api.EmbyItem) string {
+		if i.CreatedDate.IsZero() {
+			return ""
+		}
+		return i.CreatedDate.Format("2006-01-02")
+	}},
+	{Name: "parent_id", Value: func(i api.EmbyItem) any { return i.ParentId }, Hidden: true},
+	{Name: "year", Value: func(i api.EmbyItem) any { return i.ProductionYear }, Hidden: true},
+	{Name: "path", Value: func(i api.EmbyItem) any { return i.Path }, Hidden: true},
+}
+
 func ItemInfo() *cobra.Command {
 	var cmd = &cobra.Command{
 		Use:   "info <item>",
@@ -262,7 +270,8 @@ func ItemInfo() *cobra.Command {
 	cmd.Flags().IntVar(&childrenLimit, "children-limit", 100, "limit number of children")
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
-		item, err := emby.Item(args[0])
+		ctx := cmd.Context()
+		item, err := emby.Item(ctx, args[0])
 		if err != nil {
 			return err
 		}
@@ -319,7 +328,7 @@ func ItemInfo() *cobra.Command {
 				_ = subCmd.Flags().Set("include-item-types", childrenType)
 			}
 			subCmd.SetArgs([]string{})
-			_ = subCmd.Execute()
+			_ = subCmd.ExecuteContext(ctx)
 		}
 
 		if showSourceList {
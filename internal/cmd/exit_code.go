@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"errors"
+	"qbit-cli/internal/api"
+	"qbit-cli/internal/config"
+)
+
+// exit codes are stable, scripts can rely on them
+const (
+	ExitOK       = 0
+	ExitError    = 1
+	ExitConfig   = 2
+	ExitAuth     = 3
+	ExitNotFound = 4
+	ExitConflict = 5
+	ExitPartial  = 6
+	// ExitUnsupported server WebUI api version is too old
+	ExitUnsupported = 7
+	// ExitNoSpace torrents are refused because server disk would be left with less free space than the reserve
+	ExitNoSpace = 8
+	// ExitTimeout torrent wait timed out
+	ExitTimeout = 9
+	// ExitTorrentError a waited torrent entered error or missingFiles state
+	ExitTorrentError = 10
+)
+
+// ExitCode maps error to process exit code.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	var cfgErr *config.ConfigError
+	if errors.As(err, &cfgErr) {
+		return ExitConfig
+	}
+	// partial failure is checked before kinds, because it wraps errors of items
+	var partial *api.PartialError
+	if errors.As(err, &partial) && partial.Partial() {
+		return ExitPartial
+	}
+	switch {
+	case errors.Is(err, api.ErrAuth):
+		return ExitAuth
+	case errors.Is(err, api.ErrNotFound):
+		return ExitNotFound
+	case errors.Is(err, api.ErrConflict):
+		return ExitConflict
+	case errors.Is(err, api.ErrUnsupported):
+		return ExitUnsupported
+	case errors.Is(err, api.ErrNoSpace):
+		return ExitNoSpace
+	case errors.Is(err, errWaitTimeout):
+		return ExitTimeout
+	case errors.Is(err, errTorrentErrored):
+		return ExitTorrentError
+	}
+	return ExitError
+}
@@ -1,7 +1,9 @@
 package cmd
 
 import (
+	"context"
 	"qbit-cli/internal/api"
+	"qbit-cli/internal/config"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -15,7 +17,7 @@ type FlagsProperty[T any] struct {
 }
 
 type FlagsPropertyRegister interface {
-	complete(toComplete string) []string
+	complete(ctx context.Context, toComplete string) []string
 }
 
 func (f *FlagsProperty[T]) RegisterCompletion(cmd *cobra.Command) {
@@ -27,7 +29,7 @@ func (f *FlagsProperty[T]) RegisterCompletion(cmd *cobra.Command) {
 	}
 	if f.Register != nil && f.Flag != "" {
 		_ = cmd.RegisterFlagCompletionFunc(f.Flag, func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-			return f.Register.complete(toComplete), cobra.ShellCompDirectiveNoFileComp
+			return f.Register.complete(cmd.Context(), toComplete), cobra.ShellCompDirectiveNoFileComp
 		})
 		return
 	}
@@ -35,8 +37,8 @@ func (f *FlagsProperty[T]) RegisterCompletion(cmd *cobra.Command) {
 
 type TorrentPluginsFlagRegister struct{}
 
-func (f *TorrentPluginsFlagRegister) complete(toComplete string) []string {
-	plugins, err := api.SearchPlugins()
+func (f *TorrentPluginsFlagRegister) complete(ctx context.Context, toComplete string) []string {
+	plugins, err := api.SearchPlugins(ctx)
 	if err != nil {
 		return nil
 	}
@@ -59,8 +61,8 @@ func (f *TorrentPluginsFlagRegister) complete(toComplete string) []string {
 
 type TorrentCategoryFlagRegister struct{}
 
-func (f *TorrentCategoryFlagRegister) complete(toComplete string) []string {
-	categories, err := api.CategoryList()
+func (f *TorrentCategoryFlagRegister) complete(ctx context.Context, toComplete string) []string {
+	categories, err := api.CategoryList(ctx)
 	if err != nil {
 		return nil
 	}
@@ -79,8 +81,8 @@ func (f *TorrentCategoryFlagRegister) complete(toComplete string) []string {
 
 type JackettIndexerFlagRegister struct{}
 
-func (f *JackettIndexerFlagRegister) complete(toComplete string) []string {
-	indexers, err := api.JackettIndexers(true)
+func (f *JackettIndexerFlagRegister) complete(ctx context.Context, toComplete string) []string {
+	indexers, err := api.JackettIndexers(ctx, true)
 	if err != nil {
 		return nil
 	}
@@ -96,3 +98,20 @@ func (f *JackettIndexerFlagRegister) complete(toComplete string) []string {
 	}
 	return data
 }
+
+type ProfileFlagRegister struct{}
+
+func (f *ProfileFlagRegister) complete(ctx context.Context, toComplete string) []string {
+	names := config.GetConfig().ProfileNames()
+	var data = make([]string, 0, len(names))
+	for _, name := range names {
+		if toComplete != "" {
+			if strings.Contains(name, toComplete) {
+				data = append(data, name)
+			}
+		} else {
+			data = append(data, name)
+		}
+	}
+	return data
+}
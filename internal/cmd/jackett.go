@@ -1,12 +1,9 @@
 package cmd
 
 import (
-	"encoding/json"
-	"fmt"
 	"github.com/spf13/cobra"
 	"qbit-cli/internal/api"
 	"qbit-cli/pkg/utils"
-	"strconv"
 	"strings"
 )
 
@@ -33,10 +30,12 @@ func JackettIndexers() *cobra.Command {
 	var filter string
 	cmd.Flags().BoolVar(&enabled, "enabled", false, "filter enabled indexers")
 	cmd.Flags().BoolVar(&jsonFormat, "json", false, "display results in json format")
+	_ = cmd.Flags().MarkDeprecated("json", "use --output json instead")
 	cmd.Flags().StringVar(&filter, "filter", "", "filter the indexer by id(name)")
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
-		indexers, err := api.JackettIndexers(enabled)
+		ctx := cmd.Context()
+		indexers, err := api.JackettIndexers(ctx, enabled)
 		if err != nil {
 			return err
 		}
@@ -52,23 +51,18 @@ func JackettIndexers() *cobra.Command {
 		}
 
 		if jsonFormat {
-			str, err := json.MarshalIndent(results, "", "  ")
-			if err != nil {
-				return err
-			}
-			fmt.Println(string(str))
-		} else {
-			header := []string{"id", "configured", "LANG", "site"}
-			var data = make([][]string, 0, len(results))
-			for _, indexer := range results {
-				data = append(data, []string{indexer.ID, strconv.FormatBool(indexer.Configured),
-					indexer.Language, indexer.SiteLink})
-			}
-			utils.PrintList(header, &data)
+			outputFormat.Value = utils.OutputJSON
 		}
-
-		return nil
+		return printList(jackettIndexerColumns, results)
 	}
 
 	return cmd
 }
+
+var jackettIndexerColumns = []utils.Column[api.JackettIndexer]{
+	{Name: "id", Value: func(i api.JackettIndexer) any { return i.ID }},
+	{Name: "configured", Value: func(i api.JackettIndexer) any { return i.Configured }},
+	{Name: "language", Header: "LANG", Value: func(i api.JackettIndexer) any { return i.Language }},
+	{Name: "site", Value: func(i api.JackettIndexer) any { return i.SiteLink }},
+	{Name: "type", Value: func(i api.JackettIndexer) any { return i.Type }, Hidden: true},
+}
@@ -2,8 +2,8 @@ package cmd
 
 import (
 	"errors"
-	"fmt"
 	"github.com/spf13/cobra"
+	"qbit-cli/internal/api"
 	"qbit-cli/internal/config"
 )
 
@@ -37,11 +37,15 @@ Feed name is your keyword.
 			return errors.New("--indexer flag is required")
 		}
 
-		cfg := config.GetConfig()
+		cfg, err := config.GetProfile()
+		if err != nil {
+			return err
+		}
 		if cfg.Jackett.Host == "" || cfg.Jackett.ApiKey == "" {
-			return errors.New("jackett host or api key is empty")
+			return config.NewConfigError("jackett host or api key is empty")
 		}
 
+		perr := &api.PartialError{Total: len(args)}
 		for _, arg := range args {
 			url := cfg.Jackett.Host + "/api/v2.0/indexers/" + indexer +
 				"/results/torznab/api?t=search&cat=" + category + "&q=" + arg +
@@ -51,12 +55,10 @@ Feed name is your keyword.
 			_ = subCmd.Flags().Set("rule", rule)
 			_ = subCmd.Flags().Set("path", arg)
 			subCmd.SetArgs([]string{url})
-			if err := subCmd.Execute(); err != nil {
-				fmt.Printf("%s add sub error: %s\n", arg, err)
-			}
+			perr.Add(arg, subCmd.ExecuteContext(c.Context()))
 		}
 
-		return nil
+		return perr.Err()
 	}
 
 	return cmd
@@ -1,7 +1,7 @@
 package cmd
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
 	"fmt"
 	tea "github.com/charmbracelet/bubbletea"
@@ -45,6 +45,7 @@ func JackettSearch() *cobra.Command {
 	searchCmd.Flags().StringVar(&indexer.Value, indexer.Flag, "all", "indexer")
 	searchCmd.Flags().StringVar(&torrentRegex, "torrent-regex", "", "result title filter")
 	searchCmd.Flags().BoolVar(&jsonFormat, "json", false, "display results as json format")
+	_ = searchCmd.Flags().MarkDeprecated("json", "use --output json instead")
 	searchCmd.Flags().StringSliceVar(&category, "indexer-category", []string{}, "indexer category")
 	searchCmd.Flags().BoolVarP(&interactive, "interactive", "i", false, "interactive mode")
 
@@ -52,7 +53,8 @@ func JackettSearch() *cobra.Command {
 	indexer.RegisterCompletion(searchCmd)
 
 	searchCmd.RunE = func(cmd *cobra.Command, args []string) error {
-		result, err := api.JackettSearch(indexer.Value, category, args[0])
+		ctx := cmd.Context()
+		result, err := api.JackettSearch(ctx, indexer.Value, category, args[0])
 		if err != nil {
 			return err
 		}
@@ -61,10 +63,9 @@ func JackettSearch() *cobra.Command {
 		if torrentRegex != "" {
 			r, err := regexp.Compile(torrentRegex)
 			if err != nil {
-				fmt.Printf("regex: %s compile failed\n", torrentRegex)
-			} else {
-				re = r
+				return fmt.Errorf("regex: %s compile failed: %w", torrentRegex, err)
 			}
+			re = r
 		}
 		downloadList := make([]*api.JackettResult, 0, len(*result.Results))
 		for _, t := range *result.Results {
@@ -84,50 +85,45 @@ func JackettSearch() *cobra.Command {
 		if autoDownload {
 			if len(downloadList) > 0 {
 				var d = make([]string, len(downloadList))
+				sizes := make(map[string]int64, len(downloadList))
 				for i, t := range downloadList {
 					url := t.MagnetUri
 					if url == "" {
 						url = t.Link
 					}
 					d[i] = url
+					sizes[url] = t.Size
 				}
-				AutoDownload(d, savePath, saveCategory.Value, saveTags, autoMM)
+				return AutoDownload(ctx, d, sizes, savePath, saveCategory.Value, saveTags, autoMM)
 			} else {
 				fmt.Println("no results found")
 			}
+		} else if interactive && len(downloadList) > 0 {
+			header := []string{"tracker", "title", "size", "category", "S", "L"}
+			var data = make([][]string, 0, len(downloadList))
+			for _, r := range downloadList {
+				data = append(data, []string{r.TrackerId, r.Title, utils.FormatFileSizeAuto(uint64(r.Size), 1),
+					r.CategoryDesc, strconv.FormatInt(int64(r.Seeders), 10), strconv.FormatInt(int64(r.Peers), 10)})
+			}
+			model := utils.InteractiveTableModel{
+				Rows:     &data,
+				Header:   &header,
+				WidthMap: map[int]int{0: 10, 1: 50, 2: 10, 3: 20, 4: 10, 5: 10},
+				Delegate: &jackettMsgDelegate{
+					ctx,
+					autoDownload, autoMM,
+					savePath, saveCategory.Value, saveTags,
+					downloadList,
+				},
+			}
+			if _, e := tea.NewProgram(&model, tea.WithAltScreen(), tea.WithContext(ctx)).Run(); e != nil {
+				return e
+			}
 		} else {
 			if jsonFormat {
-				data, err := json.MarshalIndent(downloadList, "", "  ")
-				if err != nil {
-					return err
-				}
-				fmt.Println(string(data))
-			} else {
-				header := []string{"tracker", "title", "size", "category", "S", "L"}
-				var data = make([][]string, 0, len(downloadList))
-				for _, r := range downloadList {
-					data = append(data, []string{r.TrackerId, r.Title, utils.FormatFileSizeAuto(uint64(r.Size), 1),
-						r.CategoryDesc, strconv.FormatInt(int64(r.Seeders), 10), strconv.FormatInt(int64(r.Peers), 10)})
-				}
-				interactive = interactive && len(downloadList) > 0
-				if interactive {
-					model := utils.InteractiveTableModel{
-						Rows:     &data,
-						Header:   &header,
-						WidthMap: map[int]int{0: 10, 1: 50, 2: 10, 3: 20, 4: 10, 5: 10},
-						Delegate: &jackettMsgDelegate{
-							autoDownload, autoMM,
-							savePath, saveCategory.Value, saveTags,
-							downloadList,
-						},
-					}
-					if _, e := tea.NewProgram(&model, tea.WithAltScreen()).Run(); e != nil {
-						return e
-					}
-				} else {
-					utils.PrintListWithColWidth(header, &data, map[int]int{1: 50}, false)
-				}
+				outputFormat.Value = utils.OutputJSON
 			}
+			return printList(jackettResultColumns, downloadList)
 		}
 
 		return nil
@@ -136,7 +132,23 @@ func JackettSearch() *cobra.Command {
 	return searchCmd
 }
 
+var jackettResultColumns = []utils.Column[*api.JackettResult]{
+	{Name: "tracker", Value: func(r *api.JackettResult) any { return r.TrackerId }},
+	{Name: "title", Value: func(r *api.JackettResult) any { return r.Title }, Width: 50},
+	{Name: "size", Value: func(r *api.JackettResult) any { return r.Size },
+		Text: func(r *api.JackettResult) string { return utils.FormatFileSizeAuto(uint64(r.Size), 1) }},
+	{Name: "category", Value: func(r *api.JackettResult) any { return r.CategoryDesc }},
+	{Name: "seeders", Header: "S", Value: func(r *api.JackettResult) any { return r.Seeders }},
+	{Name: "peers", Header: "L", Value: func(r *api.JackettResult) any { return r.Peers }},
+	{Name: "publish_date", Value: func(r *api.JackettResult) any { return r.PublishDate }, Hidden: true},
+	{Name: "info_hash", Value: func(r *api.JackettResult) any { return r.InfoHash }, Hidden: true},
+	{Name: "link", Value: func(r *api.JackettResult) any { return r.Link }, Hidden: true},
+	{Name: "magnet_uri", Value: func(r *api.JackettResult) any { return r.MagnetUri }, Hidden: true},
+	{Name: "details", Value: func(r *api.JackettResult) any { return r.Details }, Hidden: true},
+}
+
 type jackettMsgDelegate struct {
+	ctx                              context.Context
 	autoDownload, autoMM             bool
 	savePath, saveCategory, saveTags string
 	data                             []*api.JackettResult
@@ -156,7 +168,8 @@ func (j *jackettMsgDelegate) Operation(msg tea.KeyMsg, cursor int) *utils.KeyMsg
 		if torrents == "" {
 			torrents = j.data[cursor].Link
 		}
-		str := InteractiveDownload([]string{torrents}, j.savePath, j.saveCategory, j.saveTags, j.autoMM)
+		sizes := map[string]int64{torrents: j.data[cursor].Size}
+		str := InteractiveDownload(j.ctx, []string{torrents}, sizes, j.savePath, j.saveCategory, j.saveTags, j.autoMM)
 		return &utils.KeyMsgDelegateModel{
 			RenderClicked: true,
 			NotifyMsg:     utils.NotifyMsg{Msg: str, Duration: time.Second},
@@ -5,7 +5,6 @@ import (
 	"qbit-cli/internal/api"
 	"qbit-cli/pkg/utils"
 	"sort"
-	"strings"
 )
 
 func JobCmd() *cobra.Command {
@@ -29,31 +28,31 @@ func JobList() *cobra.Command {
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
 
 		jobs := api.ListJobs()
-		header := []string{"name", "tags", "description"}
-		data := make([][]string, len(jobs))
 		sort.Slice(jobs, func(i, j int) bool {
 			return jobs[i].JobName() < jobs[j].JobName()
 		})
-		for _, v := range jobs {
-			description := ""
-			if d, ok := v.(api.Description); ok {
-				description = d.Description()
-			}
-			var tags []string
-			if t, ok := v.(api.Tag); ok {
-				tags = t.Tags()
-			}
-			data = append(data, []string{v.JobName(), strings.Join(tags, ","), description})
-		}
-
-		utils.PrintListWithColWidth(header, &data, map[int]int{2: 50}, true)
-
-		return nil
+		return printList(jobColumns, jobs)
 	}
 
 	return cmd
 }
 
+var jobColumns = []utils.Column[api.Job]{
+	{Name: "name", Value: func(j api.Job) any { return j.JobName() }},
+	{Name: "tags", Value: func(j api.Job) any {
+		if t, ok := j.(api.Tag); ok {
+			return t.Tags()
+		}
+		return []string{}
+	}},
+	{Name: "description", Value: func(j api.Job) any {
+		if d, ok := j.(api.Description); ok {
+			return d.Description()
+		}
+		return ""
+	}, Width: 50, Wrap: true},
+}
+
 func RunJob() *cobra.Command {
 	var run = &cobra.Command{
 		Use:   "run [command]",
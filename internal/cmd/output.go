@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"os"
+	"qbit-cli/pkg/utils"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	outputFormat = FlagsProperty[string]{Flag: "output", Options: utils.OutputFormats}
+	outputCols   []string
+)
+
+// registerOutputFlags adds the global --output and --columns flags which are used by all list commands.
+func registerOutputFlags(root *cobra.Command) {
+	root.PersistentFlags().StringVarP(&outputFormat.Value, outputFormat.Flag, "o", utils.OutputTable,
+		"output format of lists: table, json, yaml, csv, tsv or template=<go template>, e.g. template='{{.name}} {{.hash}}'")
+	root.PersistentFlags().StringSliceVar(&outputCols, "columns", nil,
+		"columns of lists separated by comma, e.g. name,hash,state. Unknown column lists the available ones")
+	outputFormat.RegisterCompletion(root)
+}
+
+func currentOutput() (utils.Output, error) {
+	o, err := utils.ParseOutput(outputFormat.Value)
+	if err != nil {
+		return o, err
+	}
+	o.Columns = outputCols
+	return o, nil
+}
+
+// tableOutput reports whether list is rendered as table, totals and hints are only printed then.
+func tableOutput() bool {
+	o, err := currentOutput()
+	return err != nil || o.IsTable()
+}
+
+// printList renders items by --output and --columns.
+func printList[T any](columns []utils.Column[T], items []T) error {
+	o, err := currentOutput()
+	if err != nil {
+		return err
+	}
+	return utils.Render(os.Stdout, o, columns, items)
+}
+
+// printDetail renders a single object, table output is printed by printTable and the others are rendered from json of v.
+func printDetail(v any, printTable func()) error {
+	o, err := currentOutput()
+	if err != nil {
+		return err
+	}
+	if o.IsTable() {
+		printTable()
+		return nil
+	}
+	return utils.RenderObject(os.Stdout, o, v)
+}
@@ -33,7 +33,8 @@ func InstallPlugin() *cobra.Command {
 		},
 	}
 	cmd.RunE = func(c *cobra.Command, args []string) error {
-		err := api.InstallPlugin(args)
+		ctx := c.Context()
+		err := api.InstallPlugin(ctx, args)
 		if err != nil {
 			return err
 		}
@@ -55,7 +56,8 @@ func UninstallPlugin() *cobra.Command {
 		},
 	}
 	cmd.RunE = func(c *cobra.Command, args []string) error {
-		err := api.UninstallPlugin(args)
+		ctx := c.Context()
+		err := api.UninstallPlugin(ctx, args)
 		if err != nil {
 			return err
 		}
@@ -80,7 +82,8 @@ func EnablePlugin() *cobra.Command {
 	cmd.Flags().BoolVar(&enable, "enable", true, "enable plugin")
 
 	cmd.RunE = func(c *cobra.Command, args []string) error {
-		err := api.EnablePlugin(args, enable)
+		ctx := c.Context()
+		err := api.EnablePlugin(ctx, args, enable)
 		if err != nil {
 			return err
 		}
@@ -95,7 +98,8 @@ func UpdatePlugin() *cobra.Command {
 		Short: "Update all plugins",
 	}
 	cmd.RunE = func(c *cobra.Command, args []string) error {
-		if err := api.UpdatePlugin(); err != nil {
+		ctx := c.Context()
+		if err := api.UpdatePlugin(ctx); err != nil {
 			return err
 		}
 		return nil
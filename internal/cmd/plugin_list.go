@@ -6,7 +6,6 @@ import (
 	"qbit-cli/internal/api"
 	"qbit-cli/pkg/utils"
 	"regexp"
-	"strconv"
 	"strings"
 )
 
@@ -25,7 +24,8 @@ func PluginList() *cobra.Command {
 	cmd.Flags().BoolVar(&enabled, "enabled", false, "list enabled plugins")
 
 	cmd.RunE = func(c *cobra.Command, args []string) error {
-		plugins, err := api.SearchPlugins()
+		ctx := c.Context()
+		plugins, err := api.SearchPlugins(ctx)
 		if err != nil {
 			return err
 		}
@@ -55,20 +55,26 @@ func PluginList() *cobra.Command {
 			}
 		}
 
-		fmt.Printf("total plugin size: %d\n", len(printPlugins))
-		headers := []string{"name", "enabled", "url", "category"}
-		var data = make([][]string, len(printPlugins))
-		for i, plugin := range printPlugins {
-			cat := make([]string, 0, len(plugin.SupportedCategories))
-			for _, v := range plugin.SupportedCategories {
-				cat = append(cat, v.ID)
-			}
-			data[i] = []string{plugin.Name, strconv.FormatBool(plugin.Enabled), plugin.Url, fmt.Sprintf("%s", cat)}
+		if tableOutput() {
+			fmt.Printf("total plugin size: %d\n", len(printPlugins))
 		}
-		utils.PrintList(headers, &data)
-
-		return nil
+		return printList(pluginColumns, printPlugins)
 	}
 
 	return cmd
 }
+
+var pluginColumns = []utils.Column[api.SearchPlugin]{
+	{Name: "name", Value: func(p api.SearchPlugin) any { return p.Name }},
+	{Name: "enabled", Value: func(p api.SearchPlugin) any { return p.Enabled }},
+	{Name: "url", Value: func(p api.SearchPlugin) any { return p.Url }},
+	{Name: "category", Value: func(p api.SearchPlugin) any {
+		cat := make([]string, 0, len(p.SupportedCategories))
+		for _, v := range p.SupportedCategories {
+			cat = append(cat, v.ID)
+		}
+		return cat
+	}},
+	{Name: "full_name", Value: func(p api.SearchPlugin) any { return p.FullName }, Hidden: true},
+	{Name: "version", Value: func(p api.SearchPlugin) any { return p.Version }, Hidden: true},
+}
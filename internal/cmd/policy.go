@@ -0,0 +1,393 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/spf13/cobra"
+	"net/url"
+	"os"
+	"qbit-cli/internal/api"
+	"qbit-cli/internal/config"
+	"qbit-cli/pkg/utils"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// actions of policy rules
+const (
+	policyKeep        = "keep"
+	policyStop        = "stop"
+	policyDelete      = "delete"
+	policyDeleteFiles = "delete-files"
+)
+
+var PolicyActions = []string{policyKeep, policyStop, policyDelete, policyDeleteFiles}
+
+// statuses of policy decisions, only due ones are applied
+const (
+	policyDue     = "due"
+	policyWaiting = "waiting"
+	policyKept    = "kept"
+	// policyDone is a stop decision of a torrent which is already stopped
+	policyDone = "done"
+)
+
+func PolicyCmd() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "policy",
+		Short: "Plan and apply seeding policy rules",
+		Long: `A policy is an ordered list of rules in the policy block of config file, or a separate file of --file:
+
+policy:
+  audit-log: /var/log/qbit-policy.log
+  rules:
+    - name: tracker-x
+      match: 'tracker =~ "tracker\\.x\\.org"'
+      action: keep
+    - name: tv
+      match: 'category == "tv" && !tags.contains("keep")'
+      when: 'ratio >= 2 || seeding_time >= 30d'
+      action: delete-files
+    - name: public
+      match: '!private'
+      when: 'seeding_time >= 7d'
+      action: stop
+
+match and when are --where expressions, empty ones match all torrents.
+Each torrent is decided by the first rule it matches, so keep rules protect torrents from the rules below them.
+The action runs once when is true: keep, stop, delete(the torrent) or delete-files(the torrent and its files).`,
+	}
+
+	cmd.AddCommand(PolicyPlan())
+	cmd.AddCommand(PolicyApply())
+
+	return cmd
+}
+
+func PolicyPlan() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "plan",
+		Short: "Show what each policy rule would do, nothing is changed",
+		Example: `qbit policy plan
+qbit policy plan --all -o json`,
+	}
+
+	var (
+		file string
+		all  bool
+	)
+	cmd.Flags().StringVar(&file, "file", "", "policy file, the policy block of config file is used if empty")
+	cmd.Flags().BoolVar(&all, "all", false, "also show torrents which are kept, waiting or done")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		p, err := loadPolicy(file)
+		if err != nil {
+			return err
+		}
+		decisions, err := p.plan(ctx)
+		if err != nil {
+			return err
+		}
+		shown := decisions
+		if !all {
+			shown = slices.DeleteFunc(slices.Clone(decisions), func(d policyDecision) bool { return d.Status != policyDue })
+		}
+		if err := printList(policyColumns, shown); err != nil {
+			return err
+		}
+		if tableOutput() {
+			p.printSummary(decisions)
+		}
+		return nil
+	}
+
+	return cmd
+}
+
+func PolicyApply() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "apply",
+		Short: "Execute the due actions of policy rules and append them to the audit log",
+		Long: `Apply runs the due actions of policy plan, every action is appended to the audit log as a json line
+of time, profile, rule, action, hash, name and result. Run policy plan first to review them.`,
+		Example: `qbit policy apply
+qbit policy apply --file /etc/qbit/policy.yaml --audit-log /var/log/qbit-policy.log`,
+	}
+
+	var file, auditLog string
+	cmd.Flags().StringVar(&file, "file", "", "policy file, the policy block of config file is used if empty")
+	cmd.Flags().StringVar(&auditLog, "audit-log", "", "audit log file, overrides audit-log of the policy")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		p, err := loadPolicy(file)
+		if err != nil {
+			return err
+		}
+		if auditLog != "" {
+			p.auditLog = auditLog
+		}
+		decisions, err := p.plan(ctx)
+		if err != nil {
+			return err
+		}
+		due := slices.DeleteFunc(decisions, func(d policyDecision) bool { return d.Status != policyDue })
+		if len(due) == 0 {
+			fmt.Println("nothing to do.")
+			return nil
+		}
+		applyErr := p.apply(ctx, due)
+		if err := printList(policyApplyColumns, due); err != nil {
+			return err
+		}
+		if tableOutput() {
+			counts := make(map[string]int)
+			for _, d := range due {
+				if d.Result == "ok" {
+					counts[d.Action]++
+				} else {
+					counts["failed"]++
+				}
+			}
+			fmt.Printf("stop: %d, delete: %d, delete-files: %d, failed: %d\n",
+				counts[policyStop], counts[policyDelete], counts[policyDeleteFiles], counts["failed"])
+		}
+		return applyErr
+	}
+
+	return cmd
+}
+
+// policyRule is a rule with compiled expressions.
+type policyRule struct {
+	config.PolicyRule
+	match, when TorrentWhere
+}
+
+type policy struct {
+	rules    []*policyRule
+	auditLog string
+}
+
+// loadPolicy loads and validates the policy, invalid rules are config errors.
+func loadPolicy(file string) (*policy, error) {
+	cfg, err := config.LoadPolicy(file)
+	if err != nil {
+		return nil, err
+	}
+	invalid := func(format string, args ...any) error {
+		e := config.NewConfigError(fmt.Sprintf(format, args...))
+		if file != "" {
+			e.Path = file
+		}
+		return e
+	}
+	if len(cfg.Rules) == 0 {
+		return nil, invalid("policy has no rules")
+	}
+	p := &policy{auditLog: cfg.AuditLog}
+	names := make(map[string]bool)
+	for i, r := range cfg.Rules {
+		if r.Name == "" {
+			r.Name = "rule-" + strconv.Itoa(i+1)
+		}
+		if names[r.Name] {
+			return nil, invalid("duplicate policy rule %s", r.Name)
+		}
+		names[r.Name] = true
+		if !slices.Contains(PolicyActions, r.Action) {
+			return nil, invalid("policy rule %s: unsupported action %q, use one of %s", r.Name, r.Action, strings.Join(PolicyActions, ","))
+		}
+		rule := &policyRule{PolicyRule: r, match: TorrentWhere{Value: r.Match}, when: TorrentWhere{Value: r.When}}
+		if err := rule.match.Compile(); err != nil {
+			return nil, invalid("policy rule %s: match: %v", r.Name, err)
+		}
+		if err := rule.when.Compile(); err != nil {
+			return nil, invalid("policy rule %s: when: %v", r.Name, err)
+		}
+		p.rules = append(p.rules, rule)
+	}
+	return p, nil
+}
+
+// policyDecision is what the first rule matching a torrent does with it.
+type policyDecision struct {
+	Rule        string  `json:"rule"`
+	Action      string  `json:"action"`
+	Status      string  `json:"status"`
+	Hash        string  `json:"hash"`
+	Name        string  `json:"name"`
+	Category    string  `json:"category"`
+	Tags        string  `json:"tags"`
+	State       string  `json:"state"`
+	Ratio       float64 `json:"ratio"`
+	SeedingTime int64   `json:"seeding_time"`
+	// Result is ok or the error of policy apply
+	Result string `json:"result,omitempty"`
+}
+
+// plan decides all torrents, torrents matching no rule are left out. Decisions are sorted by rule order and name.
+func (p *policy) plan(ctx context.Context) ([]policyDecision, error) {
+	torrents, err := api.TorrentList(ctx, url.Values{})
+	if err != nil {
+		return nil, err
+	}
+	var decisions []policyDecision
+	order := make(map[string]int, len(p.rules))
+	for i, r := range p.rules {
+		order[r.Name] = i
+	}
+	for i := range torrents {
+		t := &torrents[i]
+		rule, err := p.firstMatch(t)
+		if err != nil {
+			return nil, err
+		}
+		if rule == nil {
+			continue
+		}
+		d := policyDecision{
+			Rule: rule.Name, Action: rule.Action, Status: policyDue,
+			Hash: t.Hash, Name: t.Name, Category: t.Category, Tags: t.Tags, State: t.State,
+			Ratio: t.Ratio, SeedingTime: t.SeedingTime,
+		}
+		due, err := rule.when.Match(t)
+		if err != nil {
+			return nil, fmt.Errorf("policy rule %s: when: %s: %w", rule.Name, t.Hash, err)
+		}
+		switch {
+		case rule.Action == policyKeep:
+			d.Status = policyKept
+		case !due:
+			d.Status = policyWaiting
+		case rule.Action == policyStop && t.MatchStateFilter("stopped"):
+			d.Status = policyDone
+		}
+		decisions = append(decisions, d)
+	}
+	slices.SortStableFunc(decisions, func(a, b policyDecision) int {
+		if c := order[a.Rule] - order[b.Rule]; c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	return decisions, nil
+}
+
+func (p *policy) firstMatch(t *api.Torrent) (*policyRule, error) {
+	for _, r := range p.rules {
+		ok, err := r.match.Match(t)
+		if err != nil {
+			return nil, fmt.Errorf("policy rule %s: match: %s: %w", r.Name, t.Hash, err)
+		}
+		if ok {
+			return r, nil
+		}
+	}
+	return nil, nil
+}
+
+func (p *policy) printSummary(decisions []policyDecision) {
+	for _, r := range p.rules {
+		counts := make(map[string]int)
+		for _, d := range decisions {
+			if d.Rule == r.Name {
+				counts[d.Status]++
+			}
+		}
+		if r.Action == policyKeep {
+			fmt.Printf("rule %s: keep %d\n", r.Name, counts[policyKept])
+			continue
+		}
+		fmt.Printf("rule %s: %s %d, waiting %d", r.Name, r.Action, counts[policyDue], counts[policyWaiting])
+		if r.Action == policyStop {
+			fmt.Printf(", already stopped %d", counts[policyDone])
+		}
+		fmt.Println()
+	}
+}
+
+// policyAuditEntry is a line of the audit log.
+type policyAuditEntry struct {
+	Time    time.Time `json:"time"`
+	Profile string    `json:"profile"`
+	Rule    string    `json:"rule"`
+	Action  string    `json:"action"`
+	Hash    string    `json:"hash"`
+	Name    string    `json:"name"`
+	Result  string    `json:"result"`
+}
+
+// apply executes decisions by api.UpdateTorrent, torrents of the same rule are updated together.
+// The audit log is opened first, so that nothing is changed without being logged.
+func (p *policy) apply(ctx context.Context, decisions []policyDecision) error {
+	if p.auditLog == "" {
+		return config.NewConfigError("policy audit log is not set, use --audit-log")
+	}
+	log, err := os.OpenFile(p.auditLog, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer utils.SafeClose(log)
+	audit := json.NewEncoder(log)
+	profile := config.GetConfig().ActiveProfileName()
+
+	perr := &api.PartialError{Total: len(decisions)}
+	for _, r := range p.rules {
+		var group []*policyDecision
+		for i := range decisions {
+			if decisions[i].Rule == r.Name {
+				group = append(group, &decisions[i])
+			}
+		}
+		if len(group) == 0 {
+			continue
+		}
+		hashes := make([]string, len(group))
+		for i, d := range group {
+			hashes[i] = d.Hash
+		}
+		params := url.Values{"hashes": {strings.Join(hashes, "|")}}
+		operation := "stop"
+		if r.Action == policyDelete || r.Action == policyDeleteFiles {
+			operation = "delete"
+			params.Set("deleteFiles", strconv.FormatBool(r.Action == policyDeleteFiles))
+		}
+		err := api.UpdateTorrent(ctx, operation, params)
+		for _, d := range group {
+			d.Result = "ok"
+			if err != nil {
+				d.Result = err.Error()
+			}
+			perr.Add(d.Hash, err)
+			entry := policyAuditEntry{Time: time.Now(), Profile: profile, Rule: d.Rule, Action: d.Action,
+				Hash: d.Hash, Name: d.Name, Result: d.Result}
+			if err := audit.Encode(entry); err != nil {
+				return fmt.Errorf("write audit log: %w", err)
+			}
+		}
+	}
+	return perr.Err()
+}
+
+var policyColumns = []utils.Column[policyDecision]{
+	{Name: "rule", Value: func(d policyDecision) any { return d.Rule }},
+	{Name: "action", Value: func(d policyDecision) any { return d.Action }},
+	{Name: "status", Value: func(d policyDecision) any { return d.Status }},
+	{Name: "hash", Value: func(d policyDecision) any { return d.Hash }},
+	{Name: "name", Value: func(d policyDecision) any { return d.Name }, Width: 40},
+	{Name: "category", Value: func(d policyDecision) any { return d.Category }},
+	{Name: "tags", Value: func(d policyDecision) any { return d.Tags }, Hidden: true},
+	{Name: "state", Value: func(d policyDecision) any { return d.State }, Hidden: true},
+	{Name: "ratio", Value: func(d policyDecision) any { return d.Ratio },
+		Text: func(d policyDecision) string { return strconv.FormatFloat(d.Ratio, 'f', 2, 64) }},
+	{Name: "seeding_time", Header: "SEEDING", Value: func(d policyDecision) any { return d.SeedingTime },
+		Text: func(d policyDecision) string { return formatSeconds(d.SeedingTime) }},
+}
+
+var policyApplyColumns = append(slices.Clone(policyColumns),
+	utils.Column[policyDecision]{Name: "result", Value: func(d policyDecision) any { return d.Result }, Width: 40})
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"qbit-cli/internal/config"
+	"qbit-cli/internal/qbittest"
+	"slices"
+	"testing"
+)
+
+const testPolicy = `
+rules:
+  - name: tracker-x
+    match: 'tracker =~ "tracker\\.x\\.org"'
+    action: keep
+  - name: tv
+    match: 'category == "tv" && !tags.contains("keep")'
+    when: 'ratio >= 2 || seeding_time >= 30d'
+    action: delete-files
+  - name: public
+    match: '!private'
+    when: 'seeding_time >= 7d'
+    action: stop
+`
+
+func TestPolicy(t *testing.T) {
+	s := qbittest.NewServer(qbittest.Options{})
+	defer s.Close()
+	s.Configure(t)
+	const day = 86400
+	s.AddTorrent(qbittest.Torrent{Hash: "aaa", Name: "protected", Category: "tv", Ratio: 5, Tracker: "https://tracker.x.org/a", Private: true})
+	s.AddTorrent(qbittest.Torrent{Hash: "bbb", Name: "tv ratio", Category: "tv", Ratio: 2.5, Private: true, Progress: 1})
+	s.AddTorrent(qbittest.Torrent{Hash: "ccc", Name: "tv keep", Category: "tv", Tags: "keep", Ratio: 3, SeedingTime: 8 * day, Progress: 1})
+	s.AddTorrent(qbittest.Torrent{Hash: "ddd", Name: "tv new", Category: "tv", Ratio: 0.5, SeedingTime: day, Private: true, Progress: 1})
+	s.AddTorrent(qbittest.Torrent{Hash: "eee", Name: "public old", SeedingTime: 10 * day, Progress: 1})
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "policy.yaml")
+	if err := os.WriteFile(file, []byte(testPolicy), 0600); err != nil {
+		t.Fatal(err)
+	}
+	p, err := loadPolicy(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decisions, err := p.plan(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]string)
+	for _, d := range decisions {
+		got[d.Hash] = d.Rule + " " + d.Status
+	}
+	// ccc is tagged keep, so that the public rule decides it
+	want := map[string]string{"aaa": "tracker-x kept", "bbb": "tv due", "ccc": "public due", "ddd": "tv waiting", "eee": "public due"}
+	if len(got) != len(want) {
+		t.Fatalf("got decisions %v", got)
+	}
+	for hash, w := range want {
+		if got[hash] != w {
+			t.Errorf("%s is %q, want %q", hash, got[hash], w)
+		}
+	}
+
+	cmd := PolicyApply()
+	cmd.SetArgs([]string{"--file", file})
+	if err := cmd.ExecuteContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.Torrent("bbb"); ok {
+		t.Error("bbb is not deleted")
+	}
+	if requests := s.RequestsTo("torrents/delete"); len(requests) != 1 || requests[0].Form.Get("deleteFiles") != "true" {
+		t.Errorf("got delete requests %+v", requests)
+	}
+	for _, hash := range []string{"ccc", "eee"} {
+		if torrent, _ := s.Torrent(hash); torrent.State != "stoppedUP" {
+			t.Errorf("%s is %s, want stoppedUP", hash, torrent.State)
+		}
+	}
+
+	log, err := os.Open(filepath.Join(dir, "policy-audit.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer log.Close()
+	var logged []string
+	for scanner := bufio.NewScanner(log); scanner.Scan(); {
+		var entry policyAuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatal(err)
+		}
+		if entry.Result != "ok" {
+			t.Errorf("%s result is %s", entry.Hash, entry.Result)
+		}
+		logged = append(logged, entry.Hash+" "+entry.Action)
+	}
+	// decisions of a rule are sorted by name
+	if !slices.Equal(logged, []string{"bbb delete-files", "eee stop", "ccc stop"}) {
+		t.Fatalf("got audit log %v", logged)
+	}
+
+	// stopped torrents are done, applying again changes nothing
+	if decisions, err = p.plan(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if i := slices.IndexFunc(decisions, func(d policyDecision) bool { return d.Status == policyDue }); i >= 0 {
+		t.Fatalf("%s is still due", decisions[i].Hash)
+	}
+}
+
+func TestPolicyInvalid(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "policy.yaml")
+	for _, policy := range []string{
+		"rules: []",
+		"rules: [{name: a, action: pause}]",
+		"rules: [{name: a, match: 'ratio >=', action: stop}]",
+		"rules: [{name: a, action: stop}, {name: a, action: keep}]",
+	} {
+		if err := os.WriteFile(file, []byte(policy), 0600); err != nil {
+			t.Fatal(err)
+		}
+		var cerr *config.ConfigError
+		if _, err := loadPolicy(file); !errors.As(err, &cerr) {
+			t.Errorf("%s: got %v, want config error", policy, err)
+		}
+	}
+}
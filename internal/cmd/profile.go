@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"qbit-cli/internal/config"
+	"qbit-cli/pkg/utils"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+func ProfileCmd() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "profile [command]",
+		Short: "Manage server profiles",
+		Long: `Top level server, torrent, jackett and emby blocks in config file are the "default" profile.
+Named profiles are defined in profiles block, torrent, jackett and emby blocks omitted by a named profile
+are inherited from the default profile.
+Active profile is selected by --profile flag, then profile in config file, then "default".`,
+	}
+
+	cmd.AddCommand(ProfileList())
+	cmd.AddCommand(ProfileUse())
+	cmd.AddCommand(ProfileShow())
+
+	return cmd
+}
+
+func ProfileList() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "list",
+		Short: "List profiles",
+	}
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+		active := cfg.ActiveProfileName()
+		profiles := make([]profileRow, 0, len(cfg.Profiles)+1)
+		for _, name := range cfg.ProfileNames() {
+			p, err := cfg.GetProfile(name)
+			if err != nil {
+				return err
+			}
+			profiles = append(profiles, profileRow{name: name, active: name == active, profile: p})
+		}
+		return printList(profileColumns, profiles)
+	}
+
+	return cmd
+}
+
+type profileRow struct {
+	name    string
+	active  bool
+	profile *config.Profile
+}
+
+var profileColumns = []utils.Column[profileRow]{
+	{Name: "active", Value: func(p profileRow) any { return p.active }, Text: func(p profileRow) string {
+		if p.active {
+			return "*"
+		}
+		return ""
+	}},
+	{Name: "name", Value: func(p profileRow) any { return p.name }},
+	{Name: "server", Value: func(p profileRow) any { return p.profile.Server.Host }},
+	{Name: "jackett", Value: func(p profileRow) any { return p.profile.Jackett.Host }},
+	{Name: "emby", Value: func(p profileRow) any { return p.profile.Emby.Host }},
+}
+
+func ProfileUse() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "use <name>",
+		Short: "Set the current profile in config file",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return errors.New("requires a profile name")
+			}
+			return nil
+		},
+		ValidArgsFunction: profileCompletion,
+	}
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+		if err := cfg.UseProfile(args[0]); err != nil {
+			return err
+		}
+		fmt.Printf("switched to profile %s\n", args[0])
+		return nil
+	}
+
+	return cmd
+}
+
+func ProfileShow() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:               "show [name]",
+		Short:             "Show profile, active profile by default. Secrets are masked",
+		ValidArgsFunction: profileCompletion,
+	}
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+		name := cfg.ActiveProfileName()
+		if len(args) > 0 {
+			name = args[0]
+		}
+		p, err := cfg.GetProfile(name)
+		if err != nil {
+			return err
+		}
+
+		masked := *p
+		masked.Server.Password = mask(masked.Server.Password)
+		masked.Server.Token = mask(masked.Server.Token)
+		masked.Jackett.ApiKey = mask(masked.Jackett.ApiKey)
+		masked.Jackett.Cookie = mask(masked.Jackett.Cookie)
+		masked.Emby.ApiKey = mask(masked.Emby.ApiKey)
+
+		data, err := yaml.Marshal(masked)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("# profile: %s\n%s", name, string(data))
+		return nil
+	}
+
+	return cmd
+}
+
+func mask(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return "******"
+}
+
+func profileCompletion(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return (&ProfileFlagRegister{}).complete(cmd.Context(), toComplete), cobra.ShellCompDirectiveNoFileComp
+}
@@ -1,11 +1,15 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"os/signal"
 	"qbit-cli/internal/config"
+	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
 )
@@ -13,6 +17,8 @@ import (
 func Execute(version string) {
 	var (
 		showVersion, debugMode bool
+		timeout                time.Duration
+		cancelTimeout          context.CancelFunc
 	)
 
 	rootCmd := &cobra.Command{
@@ -20,7 +26,8 @@ func Execute(version string) {
 		Short: "qbit is a CLI for qBittorrent",
 		Long: `Developed and tested on qBittorrent webui api 5.0.
 
-You may found working properly on other version of qBittorrent.
+Older servers(qBittorrent 4.x) are supported, endpoints renamed in 5.0 are picked by server api version,
+commands which need a newer server fail with a "requires WebUI API x.y.z" error.
 You can find webui api here:
 https://github.com/qbittorrent/qBittorrent/wiki/WebUI-API-%28qBittorrent-5.0%29
 
@@ -37,12 +44,25 @@ same as executable file named config.yaml
 				log.SetFlags(0)
 				log.SetOutput(io.Discard)
 			}
+			if timeout > 0 {
+				ctx, cancel := context.WithTimeout(cmd.Context(), timeout)
+				cmd.SetContext(ctx)
+				cancelTimeout = cancel
+			}
 		},
 	}
 
 	rootCmd.PersistentFlags().StringVarP(&config.CfgPath, "config", "c", "", "qbit config file path")
 	rootCmd.Flags().BoolVarP(&showVersion, "version", "v", false, "qbit cli version")
 	rootCmd.PersistentFlags().BoolVarP(&debugMode, "debug", "d", false, "enable debug")
+	profile := FlagsProperty[string]{Flag: "profile", Register: &ProfileFlagRegister{}}
+	rootCmd.PersistentFlags().StringVar(&config.ProfileName, profile.Flag, "", "server profile to use, overrides profile in config file")
+	profile.RegisterCompletion(rootCmd)
+	rootCmd.PersistentFlags().StringVar(&config.RecordDir, "record", "", "record http requests and responses to dir, credentials are redacted")
+	rootCmd.PersistentFlags().StringVar(&config.ReplayDir, "replay", "", "replay http responses recorded by --record from dir without network")
+	rootCmd.MarkFlagsMutuallyExclusive("record", "replay")
+	registerOutputFlags(rootCmd)
+	rootCmd.PersistentFlags().DurationVar(&timeout, "timeout", 0, "timeout of the whole command, e.g. 30s, 5m. 0 means no timeout")
 
 	rootCmd.RunE = func(cmd *cobra.Command, args []string) error {
 		if showVersion {
@@ -52,21 +72,30 @@ same as executable file named config.yaml
 	}
 
 	rootCmd.AddCommand(AppCmd())
+	rootCmd.AddCommand(AuthCmd())
 	rootCmd.AddCommand(TorrentCmd())
+	rootCmd.AddCommand(TransferCmd())
 	rootCmd.AddCommand(RssCmd())
 	rootCmd.AddCommand(PluginCmd())
 	rootCmd.AddCommand(JackettCmd())
 	rootCmd.AddCommand(EmbyCmd())
 	rootCmd.AddCommand(JobCmd())
+	rootCmd.AddCommand(PolicyCmd())
+	rootCmd.AddCommand(ProfileCmd())
 
-	defer func() {
-		if r := recover(); r != nil {
-			fmt.Printf("%s\n", r)
-			os.Exit(1)
-		}
+	// first Ctrl-C cancels in-flight requests, a second one kills the process
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
 	}()
 
-	if err := rootCmd.Execute(); err != nil {
-		os.Exit(1)
+	err := rootCmd.ExecuteContext(ctx)
+	if cancelTimeout != nil {
+		cancelTimeout()
+	}
+	stop()
+	if err != nil {
+		os.Exit(ExitCode(err))
 	}
 }
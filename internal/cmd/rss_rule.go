@@ -1,10 +1,10 @@
 package cmd
 
 import (
-	"encoding/json"
-	"fmt"
 	"github.com/spf13/cobra"
 	"qbit-cli/internal/api"
+	"qbit-cli/pkg/utils"
+	"sort"
 	"strings"
 )
 
@@ -22,35 +22,51 @@ func RssRule() *cobra.Command {
 func RuleList() *cobra.Command {
 	var cmd = &cobra.Command{
 		Use:   "list",
-		Short: "RSS rule list display as formated json",
+		Short: "List RSS rules",
 	}
 
 	var filter string
 	cmd.Flags().StringVar(&filter, "filter", "", "filter rule name")
 
 	cmd.RunE = func(c *cobra.Command, args []string) error {
+		ctx := c.Context()
 
-		ruleMap, err := api.RssRuleList()
+		ruleMap, err := api.RssRuleList(ctx)
 		if err != nil {
 			return err
 		}
-		if filter != "" {
-			for name, rule := range ruleMap {
-				if strings.Contains(name, filter) {
-					data, _ := json.MarshalIndent(rule, "", "  ")
-					fmt.Println(string(data))
-				}
+		names := make([]string, 0, len(ruleMap))
+		for name := range ruleMap {
+			if strings.Contains(name, filter) {
+				names = append(names, name)
 			}
-			return nil
 		}
-		data, err := json.MarshalIndent(ruleMap, "", "  ")
-		if err != nil {
-			return err
-		}
-		fmt.Println(string(data))
-
-		return nil
+		sort.Strings(names)
+		return printList(rssRuleColumns(ruleMap), names)
 	}
 
 	return cmd
 }
+
+// rssRuleColumns are columns of rule names.
+func rssRuleColumns(rules map[string]*api.RssRule) []utils.Column[string] {
+	column := func(name string, value func(r *api.RssRule) any, hidden bool) utils.Column[string] {
+		return utils.Column[string]{Name: name, Value: func(n string) any { return value(rules[n]) }, Hidden: hidden}
+	}
+	return []utils.Column[string]{
+		{Name: "name", Value: func(n string) any { return n }},
+		column("enabled", func(r *api.RssRule) any { return r.Enabled }, false),
+		column("must_contain", func(r *api.RssRule) any { return r.MustContain }, false),
+		column("must_not_contain", func(r *api.RssRule) any { return r.MustNotContain }, false),
+		column("use_regex", func(r *api.RssRule) any { return r.UseRegex }, false),
+		column("category", func(r *api.RssRule) any { return r.AssignedCategory }, false),
+		column("save_path", func(r *api.RssRule) any { return r.SavePath }, false),
+		column("affected_feeds", func(r *api.RssRule) any { return r.AffectedFeeds }, false),
+		column("episode_filter", func(r *api.RssRule) any { return r.EpisodeFilter }, true),
+		column("smart_filter", func(r *api.RssRule) any { return r.SmartFilter }, true),
+		column("ignore_days", func(r *api.RssRule) any { return r.IgnoreDays }, true),
+		column("add_paused", func(r *api.RssRule) any { return r.AddPaused }, true),
+		column("last_match", func(r *api.RssRule) any { return r.LastMatch }, true),
+		column("previously_matched_episodes", func(r *api.RssRule) any { return r.PreviouslyMatchedEpisodes }, true),
+	}
+}
@@ -1,10 +1,11 @@
 package cmd
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 	"qbit-cli/internal/api"
+	"qbit-cli/pkg/utils"
+	"sort"
 
 	"github.com/spf13/cobra"
 )
@@ -43,22 +44,23 @@ func SubAdd() *cobra.Command {
 	cmd.Flags().StringVar(&path, "path", "", "feed name. name will auto generated by url index")
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
 
 		url := args[0]
-		if err := api.RssAddSub(url, path); err != nil {
+		if err := api.RssAddSub(ctx, url, path); err != nil {
 			return err
 		}
 
 		var rssRule *api.RssRule
 		if rule != "" {
-			ruleMap, err := api.RssRuleList()
+			ruleMap, err := api.RssRuleList(ctx)
 			if err != nil {
 				return err
 			}
 			if r := ruleMap[rule]; r != nil {
 				r.AffectedFeeds = append(r.AffectedFeeds, url)
 				rssRule = r
-				if err := api.RssSetRule(rule, rssRule); err != nil {
+				if err := api.RssSetRule(ctx, rule, rssRule); err != nil {
 					return err
 				}
 			} else {
@@ -74,25 +76,35 @@ func SubAdd() *cobra.Command {
 func SubList() *cobra.Command {
 	var cmd = &cobra.Command{
 		Use:   "list",
-		Short: "Display subscription in formated json",
+		Short: "List subscriptions",
 	}
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
-		results, err := api.RssAllItems(true)
+		ctx := cmd.Context()
+		results, err := api.RssAllItems(ctx, true)
 		if err != nil {
 			return err
 		}
-		str, err := json.MarshalIndent(results, "", "  ")
-		if err != nil {
-			return err
+		paths := make([]string, 0, len(results))
+		for path := range results {
+			paths = append(paths, path)
 		}
-		fmt.Println(string(str))
-		return nil
+		sort.Strings(paths)
+		return printList(rssSubColumns(results), paths)
 	}
 
 	return cmd
 }
 
+// rssSubColumns are columns of feed paths.
+func rssSubColumns(items map[string]api.RssSub) []utils.Column[string] {
+	return []utils.Column[string]{
+		{Name: "path", Value: func(path string) any { return path }},
+		{Name: "url", Value: func(path string) any { return items[path].URL }},
+		{Name: "uid", Value: func(path string) any { return items[path].UID }, Hidden: true},
+	}
+}
+
 func DeleteSub() *cobra.Command {
 	var cmd = &cobra.Command{
 		Use:   "delete <rss>",
@@ -106,27 +118,25 @@ func DeleteSub() *cobra.Command {
 	}
 
 	cmd.RunE = func(c *cobra.Command, args []string) error {
-		results, err := api.RssAllItems(false)
+		ctx := c.Context()
+		results, err := api.RssAllItems(ctx, false)
 		if err != nil {
 			return err
 		}
 
+		perr := &api.PartialError{Total: len(args)}
 		for _, url := range args {
 			if url == "" {
 				continue
 			}
 			_, exists := results[url]
 			if !exists {
-				fmt.Printf("[%s] not exists, remove failed\n", url)
-				continue
-			}
-			err := api.RssRmSub(url)
-			if err != nil {
-				fmt.Printf("[%s] remov failed: %v\n", url, err)
+				perr.Add(url, api.ErrNotFound)
 				continue
 			}
+			perr.Add(url, api.RssRmSub(ctx, url))
 		}
-		return nil
+		return perr.Err()
 	}
 
 	return cmd
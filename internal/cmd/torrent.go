@@ -2,12 +2,12 @@ package cmd
 
 import (
 	"errors"
+	"fmt"
 	"github.com/spf13/cobra"
 	"net/url"
 	"qbit-cli/internal/api"
 	"qbit-cli/pkg/utils"
 	"strconv"
-	"strings"
 )
 
 func TorrentCmd() *cobra.Command {
@@ -17,8 +17,14 @@ func TorrentCmd() *cobra.Command {
 	}
 
 	torrentCmd.AddCommand(TorrentAdd())
+	torrentCmd.AddCommand(TorrentCreate())
 	torrentCmd.AddCommand(TorrentList())
 	torrentCmd.AddCommand(TorrentFiles())
+	torrentCmd.AddCommand(TorrentExport())
+	torrentCmd.AddCommand(TorrentImport())
+	torrentCmd.AddCommand(TorrentOrphans())
+	torrentCmd.AddCommand(TorrentInfo())
+	torrentCmd.AddCommand(TorrentInspect())
 	torrentCmd.AddCommand(TorrentSearch())
 	torrentCmd.AddCommand(RenameTorrentCmd())
 	torrentCmd.AddCommand(TorrentUpdate())
@@ -27,7 +33,9 @@ func TorrentCmd() *cobra.Command {
 	torrentCmd.AddCommand(TorrentCategoryCmd())
 	torrentCmd.AddCommand(TorrentFilePriority())
 	torrentCmd.AddCommand(TorrentTracker())
+	torrentCmd.AddCommand(TorrentLimit())
 	torrentCmd.AddCommand(TorrentPeer())
+	torrentCmd.AddCommand(TorrentWait())
 
 	return torrentCmd
 }
@@ -38,24 +46,32 @@ func DeleteTorrents() *cobra.Command {
 		Short: "Delete torrents",
 	}
 
-	var deleteFiles, all bool
+	var (
+		deleteFiles, all bool
+		where            TorrentWhere
+	)
 	cmd.Flags().BoolVar(&deleteFiles, "delete-files", false, "delete files")
 	cmd.Flags().BoolVar(&all, "all", false, "delete all torrents")
+	where.RegisterFlag(cmd)
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
-		hashes := strings.Join(args, "|")
-		if all {
-			hashes = "all"
-		} else {
-			if len(args) < 1 {
-				return errors.New("torrent update requires at least a hash")
-			}
+		ctx := cmd.Context()
+		if err := where.Compile(); err != nil {
+			return err
+		}
+		hashes, err := selectTorrentHashes(ctx, args, all, &where)
+		if err != nil {
+			return err
+		}
+		if hashes == "" {
+			fmt.Println("no torrent matched.")
+			return nil
 		}
 
 		params := url.Values{}
 		params.Set("hashes", hashes)
-		params.Set("deleteFiles", strconv.FormatBool(all))
-		err := api.UpdateTorrent("delete", params)
+		params.Set("deleteFiles", strconv.FormatBool(deleteFiles))
+		err = api.UpdateTorrent(ctx, "delete", params)
 		if err != nil {
 			return err
 		}
@@ -79,7 +95,8 @@ func RenameTorrentCmd() *cobra.Command {
 	}
 
 	torrentCmd.RunE = func(cmd *cobra.Command, args []string) error {
-		err := api.RenameTorrent(args[0], args[1])
+		ctx := cmd.Context()
+		err := api.RenameTorrent(ctx, args[0], args[1])
 		if err != nil {
 			return err
 		}
@@ -93,8 +110,8 @@ func TorrentFilePriority() *cobra.Command {
 	var cmd = &cobra.Command{
 		Use:   "fp <hash>",
 		Short: "Set torrent file priority",
-		Long: `Make sure your qBittorrent webapi version is >= 2.8.2
-This command use file index which is return by torrent files from webapi 2.8.2`,
+		Long: `Requires WebUI API >= 2.8.2,
+this command use file index which is returned by torrent files since WebUI API 2.8.2`,
 		Args: func(cmd *cobra.Command, args []string) error {
 			if len(args) != 1 {
 				return errors.New("torrent hash is required")
@@ -113,10 +130,11 @@ This command use file index which is return by torrent files from webapi 2.8.2`,
 7	Maximal priority`)
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
 		if index == "" {
 			return errors.New("torrent file index is required")
 		}
-		err := api.SetTorrentFilePriority(args[0], index, priority)
+		err := api.SetTorrentFilePriority(ctx, args[0], index, priority)
 		if err != nil {
 			return err
 		}
@@ -129,7 +147,7 @@ This command use file index which is return by torrent files from webapi 2.8.2`,
 func TorrentTracker() *cobra.Command {
 	var cmd = &cobra.Command{
 		Use:   "tracker <hash>",
-		Short: "List torrent trackers",
+		Short: "List torrent trackers, or manage them by the sub commands",
 		Args: func(cmd *cobra.Command, args []string) error {
 			if len(args) != 1 {
 				return errors.New("torrent hash is required")
@@ -146,27 +164,24 @@ func TorrentTracker() *cobra.Command {
 3	Tracker is updating
 4	Tracker has been contacted, but it is not working (or doesn't send proper replies)
 `)
+	cmd.AddCommand(TrackerAdd())
+	cmd.AddCommand(TrackerEdit())
+	cmd.AddCommand(TrackerRemove())
+	cmd.AddCommand(TrackerReplace())
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
-		trackers, err := api.TorrentTrackers(args[0])
+		ctx := cmd.Context()
+		trackers, err := api.TorrentTrackers(ctx, args[0])
 		if err != nil {
 			return err
 		}
-		header := []string{"url", "status", "tier", "peers", "seeds", "leeches", "downloaded", "msg"}
-		var data = make([][]string, 0, len(*trackers))
+		var list = make([]api.TorrentTracker, 0, len(*trackers))
 		for _, t := range *trackers {
-			if status >= 0 {
-				if status == t.Status {
-					data = append(data, []string{t.URL, strconv.Itoa(t.Status), strconv.Itoa(t.Tier), strconv.Itoa(t.NumPeers),
-						strconv.Itoa(t.NumSeeds), strconv.Itoa(t.NumLeeches), strconv.Itoa(t.NumDownloaded), t.Msg})
-				}
-			} else {
-				data = append(data, []string{t.URL, strconv.Itoa(t.Status), strconv.Itoa(t.Tier), strconv.Itoa(t.NumPeers),
-					strconv.Itoa(t.NumSeeds), strconv.Itoa(t.NumLeeches), strconv.Itoa(t.NumDownloaded), t.Msg})
+			if status < 0 || status == t.Status {
+				list = append(list, t)
 			}
 		}
-		utils.PrintList(header, &data)
-		return nil
+		return printList(trackerColumns, list)
 	}
 
 	return cmd
@@ -184,23 +199,47 @@ func TorrentPeer() *cobra.Command {
 		},
 	}
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
 
-		peers, err := api.TorrentPeers(args[0])
+		peers, err := api.TorrentPeers(ctx, args[0])
 		if err != nil {
 			return err
 		}
 
-		header := []string{"area", "host", "conn", "flags", "client", "PROG", "DLS", "UPS", "DL", "UL", "REL", "files"}
-		var data = make([][]string, 0, len(*peers))
-		for _, t := range *peers {
-			data = append(data, []string{t.CountryCode, t.IP + ":" + strconv.Itoa(t.Port), t.Connection, t.Flags, t.Client,
-				utils.FormatPercent(t.Progress), utils.FormatFileSizeAuto(uint64(t.DLSpeed), 0) + "/s",
-				utils.FormatFileSizeAuto(uint64(t.UpSpeed), 0) + "/S", utils.FormatFileSizeAuto(uint64(t.Downloaded), 0),
-				utils.FormatFileSizeAuto(uint64(t.Uploaded), 0), utils.FormatPercent(t.Relevance), t.Files})
-		}
-		utils.PrintListWithColWidth(header, &data, map[int]int{1: 20, 4: 10}, true)
-		return nil
+		return printList(peerColumns, *peers)
 	}
 
 	return cmd
 }
+
+var trackerColumns = []utils.Column[api.TorrentTracker]{
+	{Name: "url", Value: func(t api.TorrentTracker) any { return t.URL }},
+	{Name: "status", Value: func(t api.TorrentTracker) any { return t.Status }},
+	{Name: "tier", Value: func(t api.TorrentTracker) any { return t.Tier }},
+	{Name: "peers", Value: func(t api.TorrentTracker) any { return t.NumPeers }},
+	{Name: "seeds", Value: func(t api.TorrentTracker) any { return t.NumSeeds }},
+	{Name: "leeches", Value: func(t api.TorrentTracker) any { return t.NumLeeches }},
+	{Name: "downloaded", Value: func(t api.TorrentTracker) any { return t.NumDownloaded }},
+	{Name: "msg", Value: func(t api.TorrentTracker) any { return t.Msg }},
+}
+
+var peerColumns = []utils.Column[api.TorrentPeer]{
+	{Name: "area", Value: func(p api.TorrentPeer) any { return p.CountryCode }},
+	{Name: "host", Value: func(p api.TorrentPeer) any { return p.IP + ":" + strconv.Itoa(p.Port) }, Width: 20, Wrap: true},
+	{Name: "conn", Value: func(p api.TorrentPeer) any { return p.Connection }},
+	{Name: "flags", Value: func(p api.TorrentPeer) any { return p.Flags }},
+	{Name: "client", Value: func(p api.TorrentPeer) any { return p.Client }, Width: 10},
+	{Name: "progress", Header: "PROG", Value: func(p api.TorrentPeer) any { return p.Progress },
+		Text: func(p api.TorrentPeer) string { return utils.FormatPercent(p.Progress) }},
+	{Name: "dl_speed", Header: "DLS", Value: func(p api.TorrentPeer) any { return p.DLSpeed },
+		Text: func(p api.TorrentPeer) string { return utils.FormatFileSizeAuto(uint64(p.DLSpeed), 0) + "/S" }},
+	{Name: "up_speed", Header: "UPS", Value: func(p api.TorrentPeer) any { return p.UpSpeed },
+		Text: func(p api.TorrentPeer) string { return utils.FormatFileSizeAuto(uint64(p.UpSpeed), 0) + "/S" }},
+	{Name: "downloaded", Header: "DL", Value: func(p api.TorrentPeer) any { return p.Downloaded },
+		Text: func(p api.TorrentPeer) string { return utils.FormatFileSizeAuto(uint64(p.Downloaded), 0) }},
+	{Name: "uploaded", Header: "UL", Value: func(p api.TorrentPeer) any { return p.Uploaded },
+		Text: func(p api.TorrentPeer) string { return utils.FormatFileSizeAuto(uint64(p.Uploaded), 0) }},
+	{Name: "relevance", Header: "REL", Value: func(p api.TorrentPeer) any { return p.Relevance },
+		Text: func(p api.TorrentPeer) string { return utils.FormatPercent(p.Relevance) }},
+	{Name: "files", Value: func(p api.TorrentPeer) any { return p.Files }},
+}
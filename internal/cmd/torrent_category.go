@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"errors"
-	"fmt"
 	"github.com/spf13/cobra"
 	"qbit-cli/internal/api"
 	"qbit-cli/pkg/utils"
@@ -36,7 +35,8 @@ func CategoryUpdate() *cobra.Command {
 	var savePath string
 	cmd.Flags().StringVar(&savePath, "save-path", "", "save path")
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
-		if err := api.CategoryUpdate(args[0], savePath); err != nil {
+		ctx := cmd.Context()
+		if err := api.CategoryUpdate(ctx, args[0], savePath); err != nil {
 			return err
 		}
 		return nil
@@ -58,7 +58,8 @@ func CategoryDelete() *cobra.Command {
 	}
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
-		if err := api.CategoryDelete(args); err != nil {
+		ctx := cmd.Context()
+		if err := api.CategoryDelete(ctx, args); err != nil {
 			return err
 		}
 		return nil
@@ -72,18 +73,13 @@ func CategoryList() *cobra.Command {
 		Short: "List category",
 	}
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
-		categories, err := api.CategoryList()
+		ctx := cmd.Context()
+		categories, err := api.CategoryList(ctx)
 		if err != nil {
 			return err
 		}
 
-		header := []string{"Name", "SavePath"}
-		var data = make([][]string, 0, len(*categories))
-		for _, category := range *categories {
-			data = append(data, []string{category.Name, category.SavePath})
-		}
-		utils.PrintList(header, &data)
-		return nil
+		return printList(categoryColumns, *categories)
 	}
 	return cmd
 }
@@ -105,14 +101,19 @@ func CategoryAdd() *cobra.Command {
 	cmd.Flags().StringVar(&savePath, "save-path", "", "Save path")
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
 
+		perr := &api.PartialError{Total: len(args)}
 		for _, arg := range args {
-			if err := api.CategoryAdd(arg, savePath); err != nil {
-				fmt.Printf("%s add failed: %s\n", arg, err.Error())
-			}
+			perr.Add(arg, api.CategoryAdd(ctx, arg, savePath))
 		}
 
-		return nil
+		return perr.Err()
 	}
 	return cmd
 }
+
+var categoryColumns = []utils.Column[api.TorrentCategory]{
+	{Name: "name", Header: "Name", Value: func(c api.TorrentCategory) any { return c.Name }},
+	{Name: "save_path", Header: "SavePath", Value: func(c api.TorrentCategory) any { return c.SavePath }},
+}
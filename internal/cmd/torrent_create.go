@@ -0,0 +1,197 @@
+package cmd
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"net/url"
+	"os"
+	"path/filepath"
+	"qbit-cli/internal/api"
+	"qbit-cli/pkg/metainfo"
+	"qbit-cli/pkg/utils"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var MetaVersions = []string{"v1", "hybrid"}
+
+// createdTorrent is printed by torrent create.
+type createdTorrent struct {
+	File        string `json:"file"`
+	Name        string `json:"name"`
+	InfoHash    string `json:"info_hash"`
+	InfoHashV2  string `json:"info_hash_v2,omitempty"`
+	PieceLength int64  `json:"piece_length"`
+	Pieces      int    `json:"pieces"`
+	Files       int    `json:"files"`
+	Size        int64  `json:"size"`
+	Added       bool   `json:"added"`
+}
+
+func TorrentCreate() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "create <path>",
+		Short: "Create a .torrent from a local file or directory",
+		Long: `Create hashes a local file or directory into a v1 or hybrid v1/v2 .torrent.
+Piece size is chosen by the total size unless --piece-size is set, it must be a power of two between 16KB and 64MB.
+--add adds the created torrent to qBittorrent with the default category, tags and save path of config file,
+set --save-path to the parent of <path> on the server and --skip-checking to seed it right away.
+--skip-checking requires --save-path, otherwise the torrent would be saved to the category path without the data.`,
+		Example: `qbit torrent create ./Release.2024 -t https://tracker.example.com/announce --private --source EXAMPLE
+qbit torrent create ./Release.2024 --meta-version hybrid --piece-size 4MB --add --save-path /downloads --skip-checking`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return errors.New("requires exactly one file or directory")
+			}
+			return nil
+		},
+	}
+
+	var (
+		out, name, pieceSize     string
+		trackers, webSeeds       []string
+		private, force, add      bool
+		comment, source          string
+		tags, savePath           string
+		skipChecking, noProgress bool
+	)
+	metaVersion := FlagsProperty[string]{Flag: "meta-version", Options: MetaVersions}
+	category := FlagsProperty[string]{Flag: "category", Register: &TorrentCategoryFlagRegister{}}
+	cmd.Flags().StringVar(&out, "out", "", "path of the .torrent file, default is <name>.torrent in current directory")
+	cmd.Flags().StringVar(&name, "name", "", "torrent name, default is the base name of path")
+	cmd.Flags().StringVar(&metaVersion.Value, metaVersion.Flag, "v1", "v1 or hybrid(v1 and v2)")
+	cmd.Flags().StringVar(&pieceSize, "piece-size", "auto", "piece size, e.g. 256KB, 4MB")
+	cmd.Flags().StringArrayVarP(&trackers, "tracker", "t", nil, "tracker announce url, can be repeated, each one is a tier")
+	cmd.Flags().StringArrayVarP(&webSeeds, "web-seed", "w", nil, "web seed url, can be repeated")
+	cmd.Flags().BoolVar(&private, "private", false, "set private flag, DHT and PeX are disabled by clients")
+	cmd.Flags().StringVar(&comment, "comment", "", "torrent comment")
+	cmd.Flags().StringVar(&source, "source", "", "info source, private trackers use it to make the info hash unique")
+	cmd.Flags().BoolVarP(&force, "force", "f", false, "overwrite the .torrent file if it exists")
+	cmd.Flags().BoolVar(&noProgress, "no-progress", false, "don't print hashing progress to stderr, it is only printed to terminals")
+	cmd.Flags().BoolVar(&add, "add", false, "add the created torrent to qBittorrent")
+	cmd.Flags().StringVar(&category.Value, category.Flag, "", "category of added torrent")
+	cmd.Flags().StringVar(&tags, "tags", "", "tags of added torrent split by ','")
+	cmd.Flags().StringVar(&savePath, "save-path", "", "save path of added torrent, disables automatic torrent management")
+	cmd.Flags().BoolVar(&skipChecking, "skip-checking", false, "skip hash checking of added torrent")
+	metaVersion.RegisterCompletion(cmd)
+	category.RegisterCompletion(cmd)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		opts := metainfo.CreateOptions{
+			Path:         args[0],
+			Name:         name,
+			Hybrid:       metaVersion.Value == "hybrid",
+			Trackers:     trackers,
+			WebSeeds:     webSeeds,
+			Private:      private,
+			Comment:      comment,
+			Source:       source,
+			CreatedBy:    "qbit-cli",
+			CreationDate: time.Now(),
+		}
+		if add && skipChecking && savePath == "" {
+			return errors.New("--skip-checking requires --save-path, the data must exist where the torrent is saved")
+		}
+		if metaVersion.Value != "v1" && !opts.Hybrid {
+			return fmt.Errorf("unsupported meta version %q, use one of %s", metaVersion.Value, strings.Join(MetaVersions, ","))
+		}
+		if pieceSize != "auto" {
+			size, err := utils.ParseFileSize(pieceSize)
+			if err != nil {
+				return err
+			}
+			if err := metainfo.CheckPieceLength(size); err != nil {
+				return err
+			}
+			opts.PieceLength = size
+		}
+		if out == "" {
+			abs, err := filepath.Abs(args[0])
+			if err != nil {
+				return err
+			}
+			out = cmp.Or(name, filepath.Base(abs)) + ".torrent"
+		}
+		if utils.FileExists(out) && !force {
+			return fmt.Errorf("%s exists, use --force to overwrite it", out)
+		}
+		if stat, err := os.Stderr.Stat(); err == nil && stat.Mode()&os.ModeCharDevice != 0 && !noProgress {
+			opts.Progress = hashProgress()
+		}
+
+		torrent, err := metainfo.Create(opts)
+		if opts.Progress != nil {
+			fmt.Fprintln(os.Stderr)
+		}
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(out, torrent.Data, 0o644); err != nil {
+			return err
+		}
+
+		result := createdTorrent{
+			File:        out,
+			Name:        torrent.Name,
+			InfoHash:    torrent.InfoHash,
+			InfoHashV2:  torrent.InfoHashV2,
+			PieceLength: torrent.PieceLength,
+			Pieces:      torrent.Pieces,
+			Files:       torrent.Files,
+			Size:        torrent.Size,
+		}
+		if add {
+			params := url.Values{
+				"autoTMM":       {strconv.FormatBool(savePath == "")},
+				"skip_checking": {strconv.FormatBool(skipChecking)},
+			}
+			if category.Value != "" {
+				params.Set("category", category.Value)
+			}
+			if tags != "" {
+				params.Set("tags", tags)
+			}
+			if savePath != "" {
+				params.Set("savepath", savePath)
+			}
+			if err := LoadTorrentAddDefault(params); err != nil {
+				return err
+			}
+			if err := api.TorrentAdd(cmd.Context(), []string{out}, params); err != nil {
+				return err
+			}
+			result.Added = true
+		}
+
+		return printDetail(result, func() {
+			fmt.Printf("created %s\n", result.File)
+			fmt.Printf("name: %s, size: %s, files: %d, pieces: %d x %s\n", result.Name,
+				utils.FormatFileSizeAuto(uint64(result.Size), 1), result.Files, result.Pieces,
+				utils.FormatFileSizeAuto(uint64(result.PieceLength), 0))
+			fmt.Printf("info hash: %s\n", result.InfoHash)
+			if result.InfoHashV2 != "" {
+				fmt.Printf("info hash v2: %s\n", result.InfoHashV2)
+			}
+			if result.Added {
+				fmt.Println("added to qBittorrent")
+			}
+		})
+	}
+
+	return cmd
+}
+
+// hashProgress prints percentage of hashed bytes to stderr when it changes.
+func hashProgress() func(done, total int64) {
+	last := int64(-1)
+	return func(done, total int64) {
+		if percent := done * 100 / total; percent != last {
+			last = percent
+			fmt.Fprintf(os.Stderr, "\rhashing %3d%% %s/%s", percent,
+				utils.FormatFileSizeAuto(uint64(done), 1), utils.FormatFileSizeAuto(uint64(total), 1))
+		}
+	}
+}
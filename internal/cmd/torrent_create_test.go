@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"qbit-cli/internal/qbittest"
+	"testing"
+)
+
+func TestCreateAdd(t *testing.T) {
+	s := qbittest.NewServer(qbittest.Options{})
+	defer s.Close()
+	s.Configure(t)
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "release.mkv"), make([]byte, 100000), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "release.torrent")
+
+	cmd := TorrentCreate()
+	cmd.SetArgs([]string{filepath.Join(dir, "release.mkv"), "--out", out, "--meta-version", "hybrid", "--private",
+		"--add", "--category", "movie", "--save-path", dir, "--skip-checking"})
+	if err := cmd.ExecuteContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	requests := s.RequestsTo("torrents/add")
+	if len(requests) != 1 {
+		t.Fatalf("got %d adds, want 1", len(requests))
+	}
+	form := requests[0].Form
+	if form.Get("category") != "movie" || form.Get("savepath") != dir || form.Get("skip_checking") != "true" || form.Get("autoTMM") != "false" {
+		t.Errorf("unexpected add params %v", form)
+	}
+	if torrents := s.Torrents(); len(torrents) != 1 || torrents[0].Name != "release.mkv" {
+		t.Errorf("got torrents %v", torrents)
+	}
+
+	// without save path the torrent would be added to the category path, where the data doesn't exist
+	cmd = TorrentCreate()
+	cmd.SetArgs([]string{filepath.Join(dir, "release.mkv"), "--out", out, "--force", "--add", "--skip-checking"})
+	if err := cmd.ExecuteContext(context.Background()); err == nil || len(s.RequestsTo("torrents/add")) != 1 {
+		t.Errorf("--skip-checking without --save-path should be rejected, got %v", err)
+	}
+
+	cmd = TorrentCreate()
+	cmd.SetArgs([]string{filepath.Join(dir, "release.mkv"), "--out", out})
+	if err := cmd.ExecuteContext(context.Background()); err == nil {
+		t.Error("existing torrent file should not be overwritten")
+	}
+}
@@ -0,0 +1,445 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+	"net/url"
+	"os"
+	"path/filepath"
+	"qbit-cli/internal/api"
+	"qbit-cli/pkg/utils"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// torrentSidecar is the qBittorrent state of an exported torrent, it is saved next to the .torrent file.
+type torrentSidecar struct {
+	Hash         string   `json:"hash" yaml:"hash"`
+	Name         string   `json:"name" yaml:"name"`
+	Torrent      string   `json:"torrent" yaml:"torrent"`
+	Category     string   `json:"category" yaml:"category"`
+	Tags         []string `json:"tags" yaml:"tags"`
+	SavePath     string   `json:"save_path" yaml:"save_path"`
+	DownloadPath string   `json:"download_path,omitempty" yaml:"download_path,omitempty"`
+	AutoTMM      bool     `json:"auto_tmm" yaml:"auto_tmm"`
+	Stopped      bool     `json:"stopped" yaml:"stopped"`
+	// share limits, -2 means the global limit and -1 means no limit, times are minutes
+	RatioLimit               float64       `json:"ratio_limit" yaml:"ratio_limit"`
+	SeedingTimeLimit         int64         `json:"seeding_time_limit" yaml:"seeding_time_limit"`
+	InactiveSeedingTimeLimit int64         `json:"inactive_seeding_time_limit" yaml:"inactive_seeding_time_limit"`
+	DlLimit                  int64         `json:"dl_limit" yaml:"dl_limit"`
+	UpLimit                  int64         `json:"up_limit" yaml:"up_limit"`
+	SeqDl                    bool          `json:"seq_dl" yaml:"seq_dl"`
+	FLPiecePrio              bool          `json:"f_l_piece_prio" yaml:"f_l_piece_prio"`
+	Files                    []sidecarFile `json:"files" yaml:"files"`
+}
+
+type sidecarFile struct {
+	Index    int32  `json:"index" yaml:"index"`
+	Name     string `json:"name" yaml:"name"`
+	Priority uint8  `json:"priority" yaml:"priority"`
+}
+
+var SidecarFormats = []string{"json", "yaml"}
+
+func newTorrentSidecar(t api.Torrent, files []api.TorrentFile) torrentSidecar {
+	s := torrentSidecar{
+		Hash:                     t.Hash,
+		Name:                     t.Name,
+		Torrent:                  t.Hash + ".torrent",
+		Category:                 t.Category,
+		Tags:                     t.TagList(),
+		SavePath:                 t.SavePath,
+		DownloadPath:             t.DownloadPath,
+		AutoTMM:                  t.AutoTMM,
+		Stopped:                  t.MatchStateFilter("stopped"),
+		RatioLimit:               t.RatioLimit,
+		SeedingTimeLimit:         t.SeedingTimeLimit,
+		InactiveSeedingTimeLimit: t.InactiveSeedingTimeLimit,
+		DlLimit:                  t.DlLimit,
+		UpLimit:                  t.UpLimit,
+		SeqDl:                    t.SeqDl,
+		FLPiecePrio:              t.FLPiecePrio,
+		Files:                    make([]sidecarFile, len(files)),
+	}
+	for i, f := range files {
+		s.Files[i] = sidecarFile{Index: f.Index, Name: f.Name, Priority: f.Priority}
+	}
+	return s
+}
+
+func TorrentExport() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "export [hash]...",
+		Short: "Export .torrent files with a sidecar of category, tags, save path, share limits and file priorities",
+		Long: `Export writes <hash>.torrent and <hash>.json(or .yaml) of every selected torrent to --dir,
+torrent import recreates them on another instance.`,
+		Example: `qbit torrent export --all --dir backup
+qbit torrent export --where 'category == "movie"' --dir backup --sidecar yaml`,
+	}
+
+	var (
+		all   bool
+		dir   string
+		where TorrentWhere
+	)
+	sidecar := FlagsProperty[string]{Flag: "sidecar", Options: SidecarFormats}
+	cmd.Flags().BoolVar(&all, "all", false, "export all torrents")
+	cmd.Flags().StringVar(&dir, "dir", ".", "directory of exported files")
+	cmd.Flags().StringVar(&sidecar.Value, sidecar.Flag, "json", "sidecar format: "+strings.Join(SidecarFormats, ","))
+	where.RegisterFlag(cmd)
+	sidecar.RegisterCompletion(cmd)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		if !slices.Contains(SidecarFormats, sidecar.Value) {
+			return fmt.Errorf("unsupported sidecar format %q, use one of %s", sidecar.Value, strings.Join(SidecarFormats, ","))
+		}
+		torrents, err := selectTorrents(ctx, args, all, &where)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+		perr := &api.PartialError{Total: len(torrents)}
+		for _, t := range torrents {
+			perr.Add(t.Hash, exportTorrent(ctx, t, dir, sidecar.Value))
+		}
+		fmt.Printf("exported %d of %d torrents to %s\n", len(torrents)-len(perr.Errs), len(torrents), dir)
+		return perr.Err()
+	}
+
+	return cmd
+}
+
+func exportTorrent(ctx context.Context, t api.Torrent, dir, format string) error {
+	metainfo, err := api.TorrentExport(ctx, t.Hash)
+	if err != nil {
+		return err
+	}
+	files, err := api.TorrentFiles(ctx, url.Values{"hash": {t.Hash}})
+	if err != nil {
+		return err
+	}
+	var data []byte
+	if format == "yaml" {
+		data, err = yaml.Marshal(newTorrentSidecar(t, files))
+	} else {
+		data, err = json.MarshalIndent(newTorrentSidecar(t, files), "", "  ")
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, t.Hash+".torrent"), metainfo, 0o644); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, t.Hash+"."+format), data, 0o644)
+}
+
+// importResult is the result of a sidecar, failed ones have the error message.
+type importResult struct {
+	hash, name, result string
+}
+
+func TorrentImport() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "import <dir|sidecar>...",
+		Short: "Import torrents exported by torrent export",
+		Long: `Import adds the .torrent of every sidecar(.json, .yaml or .yml) in dirs with its category, tags, save path,
+share limits and file priorities. Torrents which already exist are skipped.`,
+		Example: `qbit torrent import backup --path-map /downloads=/data/torrents --skip-checking`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) < 1 {
+				return errors.New("requires at least one dir or sidecar file")
+			}
+			return nil
+		},
+	}
+
+	var (
+		pathMap      []string
+		skipChecking bool
+	)
+	cmd.Flags().StringArrayVar(&pathMap, "path-map", nil, "rewrite save path prefix, e.g. /downloads=/data, can be repeated")
+	cmd.Flags().BoolVar(&skipChecking, "skip-checking", false, "skip hash checking of imported torrents")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		mapping, err := parsePathMap(pathMap)
+		if err != nil {
+			return err
+		}
+		sidecars, err := findSidecars(args)
+		if err != nil {
+			return err
+		}
+		im := &torrentImporter{mapping: mapping, skipChecking: skipChecking}
+		if err := im.load(ctx); err != nil {
+			return err
+		}
+
+		perr := &api.PartialError{Total: len(sidecars)}
+		results := make([]importResult, 0, len(sidecars))
+		for _, path := range sidecars {
+			r, err := im.importSidecar(ctx, path)
+			perr.Add(path, err)
+			if err != nil {
+				r.result = err.Error()
+			}
+			results = append(results, r)
+		}
+		if err := printList(importResultColumns, results); err != nil {
+			return err
+		}
+		if tableOutput() {
+			fmt.Printf("imported: %d, skipped: %d, failed: %d\n", im.imported, im.skipped, len(perr.Errs))
+		}
+		return perr.Err()
+	}
+
+	return cmd
+}
+
+var importResultColumns = []utils.Column[importResult]{
+	{Name: "hash", Value: func(r importResult) any { return r.hash }},
+	{Name: "name", Value: func(r importResult) any { return r.name }, Width: 30},
+	{Name: "result", Value: func(r importResult) any { return r.result }, Width: 50, Wrap: true},
+}
+
+// pathMapping rewrites the prefix from to to.
+type pathMapping struct {
+	from, to string
+}
+
+func parsePathMap(values []string) ([]pathMapping, error) {
+	mapping := make([]pathMapping, 0, len(values))
+	for _, v := range values {
+		from, to, ok := strings.Cut(v, "=")
+		if !ok || from == "" {
+			return nil, fmt.Errorf("invalid path map %q, use old=new", v)
+		}
+		mapping = append(mapping, pathMapping{from: from, to: to})
+	}
+	// the longest prefix wins
+	slices.SortStableFunc(mapping, func(a, b pathMapping) int { return len(b.from) - len(a.from) })
+	return mapping, nil
+}
+
+func mapPath(mapping []pathMapping, path string) string {
+	for _, m := range mapping {
+		if rest, ok := strings.CutPrefix(path, m.from); ok && (rest == "" || m.from[len(m.from)-1] == '/' || rest[0] == '/') {
+			return m.to + rest
+		}
+	}
+	return path
+}
+
+func findSidecars(args []string) ([]string, error) {
+	var sidecars []string
+	for _, arg := range args {
+		info, err := os.Stat(arg)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			sidecars = append(sidecars, arg)
+			continue
+		}
+		for _, ext := range []string{"*.json", "*.yaml", "*.yml"} {
+			matches, err := filepath.Glob(filepath.Join(arg, ext))
+			if err != nil {
+				return nil, err
+			}
+			sidecars = append(sidecars, matches...)
+		}
+	}
+	return sidecars, nil
+}
+
+func readSidecar(path string) (torrentSidecar, error) {
+	var s torrentSidecar
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return s, err
+	}
+	if ext := filepath.Ext(path); ext == ".yaml" || ext == ".yml" {
+		err = yaml.Unmarshal(data, &s)
+	} else {
+		err = json.Unmarshal(data, &s)
+	}
+	if err == nil && (s.Hash == "" || s.Torrent == "") {
+		err = errors.New("hash and torrent are required")
+	}
+	if err != nil {
+		return s, fmt.Errorf("invalid sidecar %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// torrentImporter keeps torrents, categories and tags of the server so that they are only created once.
+type torrentImporter struct {
+	mapping      []pathMapping
+	skipChecking bool
+	hashes       map[string]bool
+	categories   map[string]bool
+	tags         map[string]bool
+
+	imported, skipped int
+}
+
+func (im *torrentImporter) load(ctx context.Context) error {
+	torrents, err := api.TorrentList(ctx, url.Values{})
+	if err != nil {
+		return err
+	}
+	im.hashes = make(map[string]bool, len(torrents))
+	for _, t := range torrents {
+		im.hashes[t.Hash] = true
+	}
+	categories, err := api.CategoryList(ctx)
+	if err != nil {
+		return err
+	}
+	im.categories = make(map[string]bool, len(*categories))
+	for _, c := range *categories {
+		im.categories[c.Name] = true
+	}
+	tags, err := api.TagList(ctx)
+	if err != nil {
+		return err
+	}
+	im.tags = make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		im.tags[tag] = true
+	}
+	return nil
+}
+
+func (im *torrentImporter) importSidecar(ctx context.Context, path string) (importResult, error) {
+	s, err := readSidecar(path)
+	r := importResult{hash: s.Hash, name: s.Name}
+	if err != nil {
+		return r, err
+	}
+	if im.hashes[s.Hash] {
+		im.skipped++
+		r.result = "already exists"
+		return r, nil
+	}
+	if err := im.ensureCategoryAndTags(ctx, s); err != nil {
+		return r, err
+	}
+
+	// torrents with unselected files are added stopped, so that nothing is downloaded before priorities are set
+	priorities := filePriorities(s.Files)
+	params := url.Values{
+		"category":                 {s.Category},
+		"tags":                     {strings.Join(s.Tags, ",")},
+		"autoTMM":                  {strconv.FormatBool(s.AutoTMM)},
+		"rename":                   {s.Name},
+		"skip_checking":            {strconv.FormatBool(im.skipChecking)},
+		"stopped":                  {strconv.FormatBool(s.Stopped || len(priorities) > 0)},
+		"ratioLimit":               {strconv.FormatFloat(s.RatioLimit, 'f', -1, 64)},
+		"seedingTimeLimit":         {strconv.FormatInt(s.SeedingTimeLimit, 10)},
+		"inactiveSeedingTimeLimit": {strconv.FormatInt(s.InactiveSeedingTimeLimit, 10)},
+		"dlLimit":                  {strconv.FormatInt(s.DlLimit, 10)},
+		"upLimit":                  {strconv.FormatInt(s.UpLimit, 10)},
+		"sequentialDownload":       {strconv.FormatBool(s.SeqDl)},
+		"firstLastPiecePrio":       {strconv.FormatBool(s.FLPiecePrio)},
+	}
+	if !s.AutoTMM {
+		params.Set("savepath", mapPath(im.mapping, s.SavePath))
+		if s.DownloadPath != "" {
+			params.Set("downloadPath", mapPath(im.mapping, s.DownloadPath))
+		}
+	}
+	torrentFile := s.Torrent
+	if !filepath.IsAbs(torrentFile) {
+		torrentFile = filepath.Join(filepath.Dir(path), torrentFile)
+	}
+	if !utils.FileExists(torrentFile) {
+		return r, fmt.Errorf("torrent file %s: %w", torrentFile, api.ErrNotFound)
+	}
+	if err := api.TorrentAdd(ctx, []string{torrentFile}, params); err != nil {
+		return r, err
+	}
+	im.hashes[s.Hash] = true
+
+	if len(priorities) > 0 {
+		if err := waitTorrentAdded(ctx, s.Hash); err != nil {
+			return r, err
+		}
+		for priority, ids := range priorities {
+			if err := api.SetTorrentFilePriority(ctx, s.Hash, strings.Join(ids, "|"), priority); err != nil {
+				return r, err
+			}
+		}
+		if !s.Stopped {
+			if err := api.UpdateTorrent(ctx, "start", url.Values{"hashes": {s.Hash}}); err != nil {
+				return r, err
+			}
+		}
+	}
+	im.imported++
+	r.result = "imported"
+	return r, nil
+}
+
+func (im *torrentImporter) ensureCategoryAndTags(ctx context.Context, s torrentSidecar) error {
+	if s.Category != "" && !im.categories[s.Category] {
+		if err := api.CategoryAdd(ctx, s.Category, ""); err != nil && !errors.Is(err, api.ErrConflict) {
+			return err
+		}
+		im.categories[s.Category] = true
+	}
+	var missing []string
+	for _, tag := range s.Tags {
+		if !im.tags[tag] {
+			missing = append(missing, tag)
+			im.tags[tag] = true
+		}
+	}
+	if len(missing) > 0 {
+		return api.TagUpdate(ctx, "createTags", missing)
+	}
+	return nil
+}
+
+// filePriorities groups file ids by priority, files of normal priority are skipped.
+func filePriorities(files []sidecarFile) map[int][]string {
+	priorities := make(map[int][]string)
+	for _, f := range files {
+		if f.Priority != 1 {
+			priorities[int(f.Priority)] = append(priorities[int(f.Priority)], strconv.Itoa(int(f.Index)))
+		}
+	}
+	return priorities
+}
+
+// waitTorrentAdded waits until the torrent is listed, qBittorrent adds torrents asynchronously.
+func waitTorrentAdded(ctx context.Context, hash string) error {
+	for i := 0; ; i++ {
+		torrents, err := api.TorrentList(ctx, url.Values{"hashes": {hash}})
+		if err != nil {
+			return err
+		}
+		if len(torrents) > 0 {
+			return nil
+		}
+		if i == 20 {
+			return fmt.Errorf("torrent %s is not added in time: %w", hash, api.ErrNotFound)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(250 * time.Millisecond):
+		}
+	}
+}
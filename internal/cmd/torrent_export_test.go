@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"context"
+	"qbit-cli/internal/api"
+	"qbit-cli/internal/qbittest"
+	"qbit-cli/pkg/metainfo"
+	"strings"
+	"testing"
+)
+
+func TestExportImport(t *testing.T) {
+	data := []byte("d4:infod6:lengthi1024e4:name5:movie12:piece lengthi16384e6:pieces20:" + strings.Repeat("\x00", 20) + "ee")
+	m, err := metainfo.Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := m.InfoHash
+	src := qbittest.NewServer(qbittest.Options{})
+	defer src.Close()
+	src.Configure(t)
+	src.AddTorrent(qbittest.Torrent{
+		Hash: hash, Name: "movie", Category: "movie", Tags: "hd,keep", SavePath: "/downloads/movie",
+		RatioLimit: 2, SeedingTimeLimit: 1440, InactiveSeedingTimeLimit: -2, UpLimit: 1024,
+		Metainfo: data, Files: []api.TorrentFile{{Name: "movie", Priority: 0}},
+	})
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	cmd := TorrentExport()
+	cmd.SetArgs([]string{"--all", "--dir", dir, "--sidecar", "yaml"})
+	if err := cmd.ExecuteContext(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := qbittest.NewServer(qbittest.Options{})
+	defer dst.Close()
+	dst.Configure(t)
+	for range 2 {
+		cmd = TorrentImport()
+		cmd.SetArgs([]string{dir, "--path-map", "/downloads=/data", "--path-map", "/downloads/movie=/movies"})
+		if err := cmd.ExecuteContext(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := len(dst.RequestsTo("torrents/add")); n != 1 {
+		t.Fatalf("got %d adds, want 1", n)
+	}
+	got, ok := dst.Torrent(hash)
+	if !ok {
+		t.Fatal("torrent is not imported")
+	}
+	if got.Category != "movie" || got.Tags != "hd, keep" || got.SavePath != "/movies" {
+		t.Errorf("got category %q, tags %q, save path %q", got.Category, got.Tags, got.SavePath)
+	}
+	if got.RatioLimit != 2 || got.SeedingTimeLimit != 1440 || got.InactiveSeedingTimeLimit != -2 || got.UpLimit != 1024 {
+		t.Errorf("got limits %v %d %d %d", got.RatioLimit, got.SeedingTimeLimit, got.InactiveSeedingTimeLimit, got.UpLimit)
+	}
+	if got.Files[0].Priority != 0 {
+		t.Errorf("file priority is %d, want 0", got.Files[0].Priority)
+	}
+	if got.State == "stoppedDL" || got.State == "pausedDL" {
+		t.Errorf("torrent is not started, state %s", got.State)
+	}
+}
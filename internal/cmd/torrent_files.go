@@ -7,7 +7,6 @@ import (
 	"net/url"
 	"qbit-cli/internal/api"
 	"qbit-cli/pkg/utils"
-	"strconv"
 )
 
 func TorrentFiles() *cobra.Command {
@@ -24,26 +23,31 @@ func TorrentFiles() *cobra.Command {
 	}
 
 	filesCmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
 		hash := args[0]
 		var params = url.Values{"hash": {hash}}
 
-		torrentFiles, err := api.TorrentFiles(params)
+		torrentFiles, err := api.TorrentFiles(ctx, params)
 		if err != nil {
 			return err
 		}
 
-		fmt.Printf("total file size: %d\n", len(torrentFiles))
-		fmt.Println("priority = 0 means file is not selected to download")
-		headers := []string{"index", "name", "priority", "progress", "size"}
-		var data = make([][]string, len(torrentFiles))
-		for i, f := range torrentFiles {
-			size := utils.FormatFileSizeAuto(uint64(f.Size), 0)
-			data[i] = []string{strconv.FormatInt(int64(f.Index), 10), f.Name, strconv.Itoa(int(f.Priority)), utils.FormatPercent(f.Progress), size}
+		if tableOutput() {
+			fmt.Printf("total file size: %d\n", len(torrentFiles))
+			fmt.Println("priority = 0 means file is not selected to download")
 		}
-		utils.PrintList(headers, &data)
-
-		return nil
+		return printList(torrentFileColumns, torrentFiles)
 	}
 
 	return filesCmd
 }
+
+var torrentFileColumns = []utils.Column[api.TorrentFile]{
+	{Name: "index", Value: func(f api.TorrentFile) any { return f.Index }},
+	{Name: "name", Value: func(f api.TorrentFile) any { return f.Name }},
+	{Name: "priority", Value: func(f api.TorrentFile) any { return f.Priority }},
+	{Name: "progress", Value: func(f api.TorrentFile) any { return f.Progress },
+		Text: func(f api.TorrentFile) string { return utils.FormatPercent(f.Progress) }},
+	{Name: "size", Value: func(f api.TorrentFile) any { return f.Size },
+		Text: func(f api.TorrentFile) string { return utils.FormatFileSizeAuto(uint64(f.Size), 0) }},
+}
@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"net/url"
+	"os"
+	"qbit-cli/internal/api"
+	"qbit-cli/pkg/utils"
+	"strconv"
+	"time"
+)
+
+func TorrentInfo() *cobra.Command {
+	infoCmd := &cobra.Command{
+		Use:     "info <hash>",
+		Short:   "Show details of a torrent, including properties, trackers and files",
+		Example: `qbit torrent info <torrent hash> -o json`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return errors.New("torrent hash required")
+			}
+			return nil
+		},
+	}
+
+	infoCmd.RunE = func(cmd *cobra.Command, args []string) error {
+		detail, err := fetchTorrentDetail(cmd.Context(), args[0])
+		if err != nil {
+			return err
+		}
+		return printDetail(detail, detail.print)
+	}
+
+	return infoCmd
+}
+
+// torrentDetail merges /torrents/info, /torrents/properties, /torrents/trackers and /torrents/files of a torrent.
+type torrentDetail struct {
+	api.Torrent
+	Properties *api.TorrentGenericProperties `json:"properties"`
+	Trackers   []api.TorrentTracker          `json:"trackers"`
+	Files      []api.TorrentFile             `json:"files"`
+}
+
+func fetchTorrentDetail(ctx context.Context, hash string) (*torrentDetail, error) {
+	torrents, err := api.TorrentList(ctx, url.Values{"hashes": {hash}})
+	if err != nil {
+		return nil, err
+	}
+	if len(torrents) == 0 {
+		return nil, fmt.Errorf("torrent %s: %w", hash, api.ErrNotFound)
+	}
+	detail := &torrentDetail{Torrent: torrents[0]}
+	if detail.Properties, err = api.TorrentProperties(ctx, hash); err != nil {
+		return nil, err
+	}
+	trackers, err := api.TorrentTrackers(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	detail.Trackers = *trackers
+	if detail.Files, err = api.TorrentFiles(ctx, url.Values{"hash": {hash}}); err != nil {
+		return nil, err
+	}
+	return detail, nil
+}
+
+func (d *torrentDetail) print() {
+	t, p := d.Torrent, d.Properties
+	size := func(v int64) string { return utils.FormatFileSizeAuto(uint64(max(v, 0)), 1) }
+	speed := func(v int64) string { return size(v) + "/S" }
+	data := [][]string{
+		{"name", t.Name},
+		{"hash", t.Hash},
+		{"infohash v1", t.InfohashV1},
+		{"infohash v2", t.InfohashV2},
+		{"state", t.State},
+		{"progress", utils.FormatPercent(t.Progress)},
+		{"size", fmt.Sprintf("%s (total %s)", size(t.Size), size(p.TotalSize))},
+		{"pieces", fmt.Sprintf("%d/%d x %s", p.PiecesHave, p.PiecesNum, size(p.PieceSize))},
+		{"category", t.Category},
+		{"tags", t.Tags},
+		{"save path", t.SavePath},
+		{"content path", t.ContentPath},
+		{"private", strconv.FormatBool(p.IsPrivate)},
+		{"auto tmm", strconv.FormatBool(t.AutoTMM)},
+		{"comment", p.Comment},
+		{"created by", p.CreatedBy},
+		{"created on", formatUnixTime(p.CreationDate)},
+		{"added on", formatUnixTime(t.AddOn)},
+		{"completed on", formatUnixTime(t.CompletionOn)},
+		{"eta", formatSeconds(t.Eta)},
+		{"time active", formatSeconds(p.TimeElapsed)},
+		{"seeding time", formatSeconds(p.SeedingTime)},
+		{"ratio", strconv.FormatFloat(p.ShareRatio, 'f', 2, 64)},
+		{"downloaded", fmt.Sprintf("%s (session %s)", size(p.TotalDownloaded), size(p.TotalDownloadedSession))},
+		{"uploaded", fmt.Sprintf("%s (session %s)", size(p.TotalUploaded), size(p.TotalUploadedSession))},
+		{"wasted", size(p.TotalWasted)},
+		{"download speed", fmt.Sprintf("%s (avg %s)", speed(p.DLSpeed), speed(p.DLSpeedAvg))},
+		{"upload speed", fmt.Sprintf("%s (avg %s)", speed(p.UPSpeed), speed(p.UPSpeedAvg))},
+		{"download limit", formatSpeedLimit(p.DLLimit)},
+		{"upload limit", formatSpeedLimit(p.UPLimit)},
+		{"seeds", fmt.Sprintf("%d (%d total)", p.Seeds, p.SeedsTotal)},
+		{"peers", fmt.Sprintf("%d (%d total)", p.Peers, p.PeersTotal)},
+		{"connections", fmt.Sprintf("%d (%d max)", p.NbConnections, p.NbConnectionsLimit)},
+		{"tracker", t.Tracker},
+	}
+	utils.PrintListWithColWidth([]string{"property", "value"}, &data, map[int]int{1: 80}, true)
+
+	table := utils.Output{Format: utils.OutputTable}
+	fmt.Printf("trackers: %d\n", len(d.Trackers))
+	_ = utils.Render(os.Stdout, table, trackerColumns, d.Trackers)
+	fmt.Printf("files: %d\n", len(d.Files))
+	_ = utils.Render(os.Stdout, table, torrentFileColumns, d.Files)
+}
+
+// formatUnixTime formats unix seconds, qBittorrent uses 0 or -1 for unknown times.
+func formatUnixTime(sec int64) string {
+	if sec <= 0 {
+		return "-"
+	}
+	return time.Unix(sec, 0).Format(time.DateTime)
+}
+
+// infiniteEta is the eta of qBittorrent for torrents which never finish
+const infiniteEta = 8640000
+
+// formatSeconds formats durations in seconds, e.g. 1h2m3s.
+func formatSeconds(sec int64) string {
+	if sec < 0 || sec >= infiniteEta {
+		return "∞"
+	}
+	return (time.Duration(sec) * time.Second).String()
+}
+
+func formatSpeedLimit(limit int64) string {
+	if limit <= 0 {
+		return "unlimited"
+	}
+	return utils.FormatFileSizeAuto(uint64(limit), 1) + "/S"
+}
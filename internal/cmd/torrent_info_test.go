@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"qbit-cli/internal/api"
+	"qbit-cli/internal/qbittest"
+	"testing"
+)
+
+func TestFetchTorrentDetail(t *testing.T) {
+	s := qbittest.NewServer(qbittest.Options{})
+	defer s.Close()
+	s.Configure(t)
+	s.AddTorrent(qbittest.Torrent{
+		Hash: "aaa", Name: "ubuntu", SavePath: "/data", Size: 10 << 20, Progress: 1, Ratio: 1.5, Private: true,
+		Trackers: []api.TorrentTracker{{URL: "https://tracker.example.com/announce", Status: 2}},
+	})
+	ctx := context.Background()
+
+	detail, err := fetchTorrentDetail(ctx, "aaa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if detail.ContentPath != "/data/ubuntu" || detail.Ratio != 1.5 || !detail.Private {
+		t.Errorf("unexpected torrent %+v", detail.Torrent)
+	}
+	if p := detail.Properties; p.PiecesNum != 3 || p.TotalSize != 10<<20 || !p.IsPrivate {
+		t.Errorf("unexpected properties %+v", p)
+	}
+	if len(detail.Trackers) != 1 || len(detail.Files) != 1 || detail.Files[0].Name != "ubuntu" {
+		t.Errorf("unexpected trackers %+v files %+v", detail.Trackers, detail.Files)
+	}
+
+	if _, err := fetchTorrentDetail(ctx, "bbb"); !errors.Is(err, api.ErrNotFound) {
+		t.Fatalf("got %v, want not found", err)
+	}
+}
@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"qbit-cli/pkg/metainfo"
+	"qbit-cli/pkg/utils"
+	"strconv"
+	"strings"
+)
+
+func TorrentInspect() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "inspect <file|magnet>",
+		Short: "Show info hashes, files, trackers and more of a local .torrent file or a magnet link",
+		Long: `Inspect parses a .torrent file or a magnet link offline, nothing is sent to qBittorrent.
+Magnet links only have info hashes, name, trackers and web seeds.`,
+		Example: `qbit torrent inspect ./ubuntu.torrent
+qbit torrent inspect 'magnet:?xt=urn:btih:...' -o json`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return errors.New("requires exactly one .torrent file or magnet link")
+			}
+			return nil
+		},
+	}
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		m, err := metainfo.Load(args[0])
+		if err != nil {
+			return err
+		}
+		return printDetail(m, func() { printMetainfo(m) })
+	}
+
+	return cmd
+}
+
+func printMetainfo(m *metainfo.Metainfo) {
+	size := func(v int64) string { return utils.FormatFileSizeAuto(uint64(max(v, 0)), 1) }
+	orNone := func(s string) string {
+		if s == "" {
+			return "-"
+		}
+		return s
+	}
+	tiers := make([]string, len(m.Trackers))
+	for i, tier := range m.Trackers {
+		tiers[i] = strings.Join(tier, " ")
+	}
+	data := [][]string{
+		{"name", orNone(m.Name)},
+		{"infohash v1", orNone(m.InfoHash)},
+		{"infohash v2", orNone(m.InfoHashV2)},
+		{"size", size(m.Size)},
+	}
+	if !m.Magnet {
+		data = append(data,
+			[]string{"pieces", fmt.Sprintf("%d x %s", m.Pieces, size(m.PieceLength))},
+			[]string{"private", strconv.FormatBool(m.Private)},
+			[]string{"source", orNone(m.Source)},
+			[]string{"comment", orNone(m.Comment)},
+			[]string{"created by", orNone(m.CreatedBy)},
+			[]string{"created on", formatUnixTime(m.CreationDate)},
+		)
+	}
+	data = append(data,
+		[]string{"trackers", orNone(strings.Join(tiers, "\n"))},
+		[]string{"web seeds", orNone(strings.Join(m.WebSeeds, "\n"))},
+	)
+	utils.PrintListWithColWidth([]string{"property", "value"}, &data, map[int]int{1: 80}, true)
+
+	if len(m.Files) > 0 {
+		fmt.Printf("files: %d\n", len(m.Files))
+		printFileTree(m.Name, m.Files)
+	}
+}
+
+// fileNode is a file or a directory of the file tree, sizes of directories are the sum of their files.
+type fileNode struct {
+	name     string
+	size     int64
+	children []*fileNode
+	dir      bool
+}
+
+func (n *fileNode) child(name string, dir bool) *fileNode {
+	for _, c := range n.children {
+		if c.name == name && c.dir == dir {
+			return c
+		}
+	}
+	c := &fileNode{name: name, dir: dir}
+	n.children = append(n.children, c)
+	return c
+}
+
+// printFileTree prints files like tree, single file torrents are printed as a file.
+func printFileTree(name string, files []metainfo.File) {
+	if len(files) == 1 && files[0].Path == name {
+		fmt.Printf("%s  %s\n", name, utils.FormatFileSizeAuto(uint64(files[0].Length), 1))
+		return
+	}
+	root := &fileNode{name: name, dir: true}
+	for _, f := range files {
+		parts := strings.Split(f.Path, "/")
+		n := root
+		n.size += f.Length
+		for i, part := range parts {
+			n = n.child(part, i < len(parts)-1)
+			n.size += f.Length
+		}
+	}
+	var print func(n *fileNode, prefix, branch, indent string)
+	print = func(n *fileNode, prefix, branch, indent string) {
+		name := n.name
+		if n.dir {
+			name += "/"
+		}
+		fmt.Printf("%s%s%s  %s\n", prefix, branch, name, utils.FormatFileSizeAuto(uint64(n.size), 1))
+		for i, c := range n.children {
+			if i == len(n.children)-1 {
+				print(c, prefix+indent, "└── ", "    ")
+			} else {
+				print(c, prefix+indent, "├── ", "│   ")
+			}
+		}
+	}
+	print(root, "", "", "")
+}
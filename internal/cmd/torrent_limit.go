@@ -0,0 +1,347 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"maps"
+	"qbit-cli/internal/api"
+	"qbit-cli/pkg/utils"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// torrentLimits are the speed and share limits of a torrent, speeds are bytes per second and times are minutes.
+type torrentLimits struct {
+	Hash                     string  `json:"hash"`
+	Name                     string  `json:"name"`
+	DlLimit                  int64   `json:"dl_limit"`
+	UpLimit                  int64   `json:"up_limit"`
+	RatioLimit               float64 `json:"ratio_limit"`
+	SeedingTimeLimit         int64   `json:"seeding_time_limit"`
+	InactiveSeedingTimeLimit int64   `json:"inactive_seeding_time_limit"`
+}
+
+// shareLimitActions maps --action to the values of qBittorrent
+var shareLimitActions = map[string]api.ShareLimitAction{
+	"default":             api.ShareLimitActionDefault,
+	"stop":                api.ShareLimitActionStop,
+	"remove":              api.ShareLimitActionRemove,
+	"remove-with-content": api.ShareLimitActionRemoveWithContent,
+	"super-seeding":       api.ShareLimitActionEnableSuperSeeding,
+}
+
+var ShareLimitActions = slices.Sorted(maps.Keys(shareLimitActions))
+
+func TorrentLimit() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "limit [hash...]",
+		Short: "List speed and share limits of torrents, or set them by the sub command",
+		Long: `Speed limits are per second, 0 means unlimited.
+Share limits are ratio, seeding time and inactive seeding time, global uses the global limit and none means no limit.`,
+		Example: `qbit torrent limit --all
+qbit torrent limit --where 'category == "movies"' -o json`,
+	}
+
+	var (
+		all   bool
+		where TorrentWhere
+	)
+	cmd.Flags().BoolVar(&all, "all", false, "list all torrents")
+	where.RegisterFlag(cmd)
+	cmd.AddCommand(TorrentLimitSet())
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		torrents, err := selectTorrents(ctx, args, all, &where)
+		if err != nil {
+			return err
+		}
+		limits, err := listTorrentLimits(ctx, torrents)
+		if err != nil {
+			return err
+		}
+		return printList(torrentLimitColumns, limits)
+	}
+
+	return cmd
+}
+
+func TorrentLimitSet() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "set [hash...]",
+		Short: "Set speed and share limits of torrents",
+		Long: `Only the given limits are changed, share limits which are not given keep their current values.
+Speeds are sizes per second like 512K or 4MiB, 0 means unlimited.
+Ratio is a number, times are durations like 90m, 12h or 14d, both accept global and none.
+--inactive-seeding-time requires WebUI API 2.9.2 and --action requires WebUI API 2.11.4.`,
+		Example: `qbit torrent limit set <hash> --download 4MiB --upload 1MiB
+qbit torrent limit set --where 'tracker =~ "example"' --ratio 2 --seeding-time 14d --action stop
+qbit torrent limit set --all --ratio global --seeding-time global`,
+	}
+
+	var (
+		all                                     bool
+		download, upload                        string
+		ratio, seedingTime, inactiveSeedingTime string
+		where                                   TorrentWhere
+	)
+	action := FlagsProperty[string]{Flag: "action", Options: ShareLimitActions}
+
+	cmd.Flags().BoolVar(&all, "all", false, "set limits of all torrents")
+	where.RegisterFlag(cmd)
+	cmd.Flags().StringVar(&download, "download", "", "download limit per second, 0 means unlimited")
+	cmd.Flags().StringVar(&upload, "upload", "", "upload limit per second, 0 means unlimited")
+	cmd.Flags().StringVar(&ratio, "ratio", "", "ratio limit, global or none")
+	cmd.Flags().StringVar(&seedingTime, "seeding-time", "", "seeding time limit, global or none")
+	cmd.Flags().StringVar(&inactiveSeedingTime, "inactive-seeding-time", "", "inactive seeding time limit, global or none")
+	cmd.Flags().StringVar(&action.Value, action.Flag, "", "action when a share limit is reached: "+strings.Join(ShareLimitActions, ", "))
+	action.RegisterCompletion(cmd)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		flags := cmd.Flags()
+		var (
+			dlLimit, upLimit int64
+			share            shareLimitsChange
+			err              error
+		)
+		if flags.Changed("download") {
+			if dlLimit, err = utils.ParseFileSize(download); err != nil {
+				return err
+			}
+		}
+		if flags.Changed("upload") {
+			if upLimit, err = utils.ParseFileSize(upload); err != nil {
+				return err
+			}
+		}
+		if flags.Changed("ratio") {
+			if share.ratio, err = parseRatioLimit(ratio); err != nil {
+				return err
+			}
+		}
+		if flags.Changed("seeding-time") {
+			if share.seedingTime, err = parseTimeLimit(seedingTime); err != nil {
+				return err
+			}
+		}
+		if flags.Changed("inactive-seeding-time") {
+			if share.inactiveSeedingTime, err = parseTimeLimit(inactiveSeedingTime); err != nil {
+				return err
+			}
+			if err := api.RequireFeature(ctx, api.FeatureInactiveSeedingTime); err != nil {
+				return err
+			}
+		}
+		if flags.Changed(action.Flag) {
+			var ok bool
+			if share.action, ok = shareLimitActions[action.Value]; !ok {
+				return fmt.Errorf("unsupported --action %q, use one of %s", action.Value, strings.Join(ShareLimitActions, ","))
+			}
+			if err := api.RequireFeature(ctx, api.FeatureShareLimitAction); err != nil {
+				return err
+			}
+		}
+		speed := flags.Changed("download") || flags.Changed("upload")
+		if !speed && share.empty() {
+			return errors.New("requires at least one of --download, --upload, --ratio, --seeding-time, --inactive-seeding-time or --action")
+		}
+
+		torrents, err := selectTorrents(ctx, args, all, &where)
+		if err != nil {
+			return err
+		}
+		if len(torrents) == 0 {
+			fmt.Println("no torrent matched.")
+			return nil
+		}
+		hashes := make([]string, len(torrents))
+		for i, t := range torrents {
+			hashes[i] = t.Hash
+		}
+		joined := strings.Join(hashes, "|")
+
+		// each request is counted as an item
+		perr := &api.PartialError{}
+		apply := func(operation string, err error) {
+			perr.Total++
+			perr.Add(operation, err)
+		}
+		if flags.Changed("download") {
+			apply("download limit", api.SetTorrentDownloadLimit(ctx, joined, dlLimit))
+		}
+		if flags.Changed("upload") {
+			apply("upload limit", api.SetTorrentUploadLimit(ctx, joined, upLimit))
+		}
+		if !share.empty() {
+			// qBittorrent requires all share limits, torrents with the same resulting limits are set together
+			groups := make(map[api.ShareLimits][]string)
+			var order []api.ShareLimits
+			for _, t := range torrents {
+				limits := share.apply(t)
+				if _, ok := groups[limits]; !ok {
+					order = append(order, limits)
+				}
+				groups[limits] = append(groups[limits], t.Hash)
+			}
+			for _, limits := range order {
+				hashes := strings.Join(groups[limits], "|")
+				apply("share limits of "+hashes, api.SetTorrentShareLimits(ctx, hashes, limits))
+			}
+		}
+		if err := perr.Err(); err != nil {
+			return err
+		}
+		fmt.Println("done.")
+		return nil
+	}
+
+	return cmd
+}
+
+// shareLimitsChange is the share limits given by flags, nil limits keep the current values of torrents.
+type shareLimitsChange struct {
+	ratio                            *float64
+	seedingTime, inactiveSeedingTime *int64
+	action                           api.ShareLimitAction
+}
+
+func (c shareLimitsChange) empty() bool {
+	return c.ratio == nil && c.seedingTime == nil && c.inactiveSeedingTime == nil && c.action == ""
+}
+
+func (c shareLimitsChange) apply(t api.Torrent) api.ShareLimits {
+	limits := api.ShareLimits{
+		RatioLimit:               t.RatioLimit,
+		SeedingTimeLimit:         t.SeedingTimeLimit,
+		InactiveSeedingTimeLimit: t.InactiveSeedingTimeLimit,
+		Action:                   c.action,
+	}
+	if c.ratio != nil {
+		limits.RatioLimit = *c.ratio
+	}
+	if c.seedingTime != nil {
+		limits.SeedingTimeLimit = *c.seedingTime
+	}
+	if c.inactiveSeedingTime != nil {
+		limits.InactiveSeedingTimeLimit = *c.inactiveSeedingTime
+	}
+	return limits
+}
+
+// parseSpecialLimit parses global and none of share limits.
+func parseSpecialLimit(s string) (int64, bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "global":
+		return api.LimitGlobal, true
+	case "none", "unlimited":
+		return api.LimitNone, true
+	}
+	return 0, false
+}
+
+func parseRatioLimit(s string) (*float64, error) {
+	if v, ok := parseSpecialLimit(s); ok {
+		ratio := float64(v)
+		return &ratio, nil
+	}
+	ratio, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || ratio < 0 {
+		return nil, fmt.Errorf("invalid ratio limit %q, use a number, global or none", s)
+	}
+	return &ratio, nil
+}
+
+// parseTimeLimit parses durations like 90m, 12h or 14d into minutes, bare numbers are minutes.
+func parseTimeLimit(s string) (*int64, error) {
+	if v, ok := parseSpecialLimit(s); ok {
+		return &v, nil
+	}
+	s = strings.TrimSpace(s)
+	invalid := fmt.Errorf("invalid time limit %q, use a duration like 90m, 12h or 14d, global or none", s)
+	var d time.Duration
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		d = time.Duration(n) * time.Minute
+	} else if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.ParseFloat(days, 64)
+		if err != nil {
+			return nil, invalid
+		}
+		d = time.Duration(n * float64(24*time.Hour))
+	} else if d, err = time.ParseDuration(s); err != nil {
+		return nil, invalid
+	}
+	if d < 0 {
+		return nil, invalid
+	}
+	minutes := int64(d / time.Minute)
+	return &minutes, nil
+}
+
+// listTorrentLimits reads speed limits by torrents/downloadLimit and torrents/uploadLimit, share limits are from torrent info.
+func listTorrentLimits(ctx context.Context, torrents []api.Torrent) ([]torrentLimits, error) {
+	if len(torrents) == 0 {
+		return []torrentLimits{}, nil
+	}
+	hashes := make([]string, len(torrents))
+	for i, t := range torrents {
+		hashes[i] = t.Hash
+	}
+	dlLimits, err := api.TorrentDownloadLimits(ctx, strings.Join(hashes, "|"))
+	if err != nil {
+		return nil, err
+	}
+	upLimits, err := api.TorrentUploadLimits(ctx, strings.Join(hashes, "|"))
+	if err != nil {
+		return nil, err
+	}
+	limits := make([]torrentLimits, len(torrents))
+	for i, t := range torrents {
+		limits[i] = torrentLimits{
+			Hash:                     t.Hash,
+			Name:                     t.Name,
+			DlLimit:                  dlLimits[t.Hash],
+			UpLimit:                  upLimits[t.Hash],
+			RatioLimit:               t.RatioLimit,
+			SeedingTimeLimit:         t.SeedingTimeLimit,
+			InactiveSeedingTimeLimit: t.InactiveSeedingTimeLimit,
+		}
+	}
+	return limits, nil
+}
+
+// formatShareLimit formats the special values of share limits, or the limit by format.
+func formatShareLimit[T int64 | float64](limit T, format func(T) string) string {
+	switch limit {
+	case api.LimitGlobal:
+		return "global"
+	case api.LimitNone:
+		return "none"
+	}
+	return format(limit)
+}
+
+func formatTimeLimit(minutes int64) string {
+	return formatShareLimit(minutes, func(m int64) string { return formatSeconds(m * 60) })
+}
+
+var torrentLimitColumns = []utils.Column[torrentLimits]{
+	{Name: "hash", Value: func(l torrentLimits) any { return l.Hash }},
+	{Name: "name", Value: func(l torrentLimits) any { return l.Name }, Width: 40},
+	{Name: "dl_limit", Header: "DL LIMIT", Value: func(l torrentLimits) any { return l.DlLimit },
+		Text: func(l torrentLimits) string { return formatSpeedLimit(l.DlLimit) }},
+	{Name: "up_limit", Header: "UP LIMIT", Value: func(l torrentLimits) any { return l.UpLimit },
+		Text: func(l torrentLimits) string { return formatSpeedLimit(l.UpLimit) }},
+	{Name: "ratio_limit", Header: "RATIO", Value: func(l torrentLimits) any { return l.RatioLimit },
+		Text: func(l torrentLimits) string {
+			return formatShareLimit(l.RatioLimit, func(r float64) string { return strconv.FormatFloat(r, 'f', 2, 64) })
+		}},
+	{Name: "seeding_time_limit", Header: "SEEDING TIME", Value: func(l torrentLimits) any { return l.SeedingTimeLimit },
+		Text: func(l torrentLimits) string { return formatTimeLimit(l.SeedingTimeLimit) }},
+	{Name: "inactive_seeding_time_limit", Header: "INACTIVE TIME", Value: func(l torrentLimits) any { return l.InactiveSeedingTimeLimit },
+		Text: func(l torrentLimits) string { return formatTimeLimit(l.InactiveSeedingTimeLimit) }},
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"qbit-cli/internal/api"
+	"qbit-cli/internal/qbittest"
+	"testing"
+)
+
+func TestTorrentLimitSet(t *testing.T) {
+	s := qbittest.NewServer(qbittest.Options{ApiVersion: "2.11.4"})
+	defer s.Close()
+	s.Configure(t)
+	s.AddTorrent(qbittest.Torrent{Hash: "aaa", Name: "a", RatioLimit: -2, SeedingTimeLimit: 60, InactiveSeedingTimeLimit: -2})
+	s.AddTorrent(qbittest.Torrent{Hash: "bbb", Name: "b", RatioLimit: -2, SeedingTimeLimit: -1, InactiveSeedingTimeLimit: -2})
+
+	cmd := TorrentLimitSet()
+	cmd.SetArgs([]string{"--all", "--download", "1MiB", "--ratio", "2", "--inactive-seeding-time", "2d", "--action", "stop"})
+	if err := cmd.ExecuteContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// seeding time limits are kept, so that each torrent is set by its own request
+	if n := len(s.RequestsTo("torrents/setShareLimits")); n != 2 {
+		t.Fatalf("got %d setShareLimits requests, want 2", n)
+	}
+	want := map[string]int64{"aaa": 60, "bbb": -1}
+	for _, torrent := range s.Torrents() {
+		if torrent.DlLimit != 1<<20 || torrent.UpLimit != 0 {
+			t.Errorf("%s limits are %d/%d", torrent.Hash, torrent.DlLimit, torrent.UpLimit)
+		}
+		if torrent.RatioLimit != 2 || torrent.SeedingTimeLimit != want[torrent.Hash] ||
+			torrent.InactiveSeedingTimeLimit != 2*24*60 || torrent.ShareLimitAction != "Stop" {
+			t.Errorf("%s share limits are %+v", torrent.Hash, torrent)
+		}
+	}
+
+	torrents, err := selectTorrents(context.Background(), []string{"aaa"}, false, &TorrentWhere{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	limits, err := listTorrentLimits(context.Background(), torrents)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(limits) != 1 || limits[0].DlLimit != 1<<20 || limits[0].RatioLimit != 2 {
+		t.Fatalf("got limits %+v", limits)
+	}
+
+	// torrent update sets the limit param, 0 removes the limit
+	update := TorrentUpdate()
+	update.SetArgs([]string{"aaa", "--download-limit", "0", "--upload-limit", "512K"})
+	if err := update.ExecuteContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if torrent, _ := s.Torrent("aaa"); torrent.DlLimit != 0 || torrent.UpLimit != 512<<10 {
+		t.Fatalf("limits are %d/%d after update", torrent.DlLimit, torrent.UpLimit)
+	}
+}
+
+func TestTorrentLimitSetUnsupported(t *testing.T) {
+	s := qbittest.NewServer(qbittest.Options{ApiVersion: "2.11.2"})
+	defer s.Close()
+	s.Configure(t)
+	s.AddTorrent(qbittest.Torrent{Hash: "aaa", Name: "a"})
+
+	cmd := TorrentLimitSet()
+	cmd.SetArgs([]string{"aaa", "--ratio", "1", "--action", "remove"})
+	if err := cmd.ExecuteContext(context.Background()); !errors.Is(err, api.ErrUnsupported) {
+		t.Fatalf("got %v, want unsupported", err)
+	}
+	if n := len(s.RequestsTo("torrents/setShareLimits")); n != 0 {
+		t.Fatalf("got %d setShareLimits requests, want 0", n)
+	}
+}
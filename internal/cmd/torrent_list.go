@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/spf13/cobra"
@@ -17,18 +19,24 @@ var TorrentState = []string{"all", "downloading", "seeding", "completed", "stopp
 
 func TorrentList() *cobra.Command {
 	listCmd := &cobra.Command{
-		Use:     "list [flags]",
-		Short:   "List torrents",
-		Example: `qbit torrent list --filter=downloading --category=abc`,
+		Use:   "list [flags]",
+		Short: "List torrents",
+		Example: `qbit torrent list --state=downloading --category=abc
+qbit torrent list --where 'ratio >= 2 && seeding_time > 14d && !tags.contains("keep")'
+qbit torrent list --sort category,-size --group-by tracker`,
 	}
 
 	var (
 		hashes, tag   string
 		limit, offset uint32
 		interactive   bool
+		where         TorrentWhere
+		sortKeys      []string
+		reverse       bool
 	)
 	category := FlagsProperty[string]{Flag: "category", Register: &TorrentCategoryFlagRegister{}}
 	state := FlagsProperty[string]{Flag: "state", Options: TorrentState}
+	groupBy := FlagsProperty[string]{Flag: "group-by", Options: TorrentGroupBy}
 
 	listCmd.Flags().StringVar(&state.Value, state.Flag, "", `state filter: `+strings.Join(TorrentState, ","))
 	listCmd.Flags().StringVar(&category.Value, category.Flag, "", "category filter")
@@ -37,21 +45,48 @@ func TorrentList() *cobra.Command {
 	listCmd.Flags().Uint32Var(&limit, "limit", 0, "results limit")
 	listCmd.Flags().Uint32Var(&offset, "offset", 0, "results offset")
 	listCmd.Flags().BoolVarP(&interactive, "interactive", "i", false, "interactive mode")
+	where.RegisterFlag(listCmd)
+	listCmd.Flags().StringSliceVar(&sortKeys, "sort", nil,
+		"sort by torrent fields separated by comma, prefix - means descending, e.g. category,-size. "+
+			"Computed fields age, idle and tracker_host are supported")
+	listCmd.Flags().BoolVar(&reverse, "reverse", false, "reverse the order")
+	listCmd.Flags().StringVar(&groupBy.Value, groupBy.Flag, "",
+		"group torrents with subtotals: "+strings.Join(TorrentGroupBy, ","))
 
 	// register flag completion
 	category.RegisterCompletion(listCmd)
 	state.RegisterCompletion(listCmd)
+	groupBy.RegisterCompletion(listCmd)
 
 	listCmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		if err := where.Compile(); err != nil {
+			return err
+		}
+		keys, err := parseTorrentSort(sortKeys)
+		if err != nil {
+			return err
+		}
+		if err := checkTorrentGroupBy(groupBy.Value); err != nil {
+			return err
+		}
 		d := torrentSearch{
+			ctx:      ctx,
 			state:    state.Value,
 			category: category.Value,
 			hashes:   hashes,
 			tag:      tag,
 			limit:    limit,
 			offset:   offset,
+			where:    &where,
+			sort:     keys,
+			reverse:  reverse,
 		}
 		if interactive {
+			if groupBy.Value != "" {
+				return errors.New("--group-by is not supported by interactive mode")
+			}
+			d.sync = api.NewMainDataSync()
 			headers := []string{"name", "hash", "CATE", "state", "PROG", "DOWN", "UP"}
 			model := utils.InteractiveTableModel{
 				Rows:         d.Rows(),
@@ -60,7 +95,7 @@ func TorrentList() *cobra.Command {
 				DataDelegate: &d,
 				Delegate:     &d,
 			}
-			if _, e := tea.NewProgram(&model, tea.WithAltScreen()).Run(); e != nil {
+			if _, e := tea.NewProgram(&model, tea.WithAltScreen(), tea.WithContext(ctx)).Run(); e != nil {
 				return e
 			}
 			return nil
@@ -71,26 +106,70 @@ func TorrentList() *cobra.Command {
 			return err
 		}
 
-		fmt.Printf("total size: %d\n", len(*torrentList))
-
-		headers := []string{"name", "hash", "CATE", "tags", "state", "size", "PROG", "AddOn"}
-		var data = make([][]string, len(*torrentList))
-		for i, t := range *torrentList {
-			addOn := time.Unix(t.AddOn, 0).Format("2006-01-02")
-			data[i] = []string{t.Name, t.Hash, t.Category, t.Tags, t.State, utils.FormatFileSizeAuto(uint64(t.Size), 1),
-				utils.FormatPercent(t.Progress), addOn}
+		if groupBy.Value != "" {
+			return printTorrentGroups(*torrentList, groupBy.Value)
+		}
+		if err := printList(torrentColumns, *torrentList); err != nil {
+			return err
+		}
+		if tableOutput() {
+			fmt.Printf("total %s\n", summarizeTorrents("", *torrentList))
 		}
-		utils.PrintListWithColWidth(headers, &data, map[int]int{0: 30, 6: 6}, false)
 		return nil
 	}
 
 	return listCmd
 }
 
+var torrentColumns = []utils.Column[api.Torrent]{
+	{Name: "name", Value: func(t api.Torrent) any { return t.Name }, Width: 30},
+	{Name: "hash", Value: func(t api.Torrent) any { return t.Hash }},
+	{Name: "category", Header: "CATE", Value: func(t api.Torrent) any { return t.Category }},
+	{Name: "tags", Value: func(t api.Torrent) any { return t.Tags }},
+	{Name: "state", Value: func(t api.Torrent) any { return t.State }},
+	{Name: "size", Value: func(t api.Torrent) any { return t.Size },
+		Text: func(t api.Torrent) string { return utils.FormatFileSizeAuto(uint64(t.Size), 1) }},
+	{Name: "progress", Header: "PROG", Value: func(t api.Torrent) any { return t.Progress },
+		Text: func(t api.Torrent) string { return utils.FormatPercent(t.Progress) }, Width: 6},
+	{Name: "added_on", Header: "AddOn", Value: func(t api.Torrent) any { return time.Unix(t.AddOn, 0) },
+		Text: func(t api.Torrent) string { return time.Unix(t.AddOn, 0).Format("2006-01-02") }},
+	{Name: "dlspeed", Value: func(t api.Torrent) any { return t.DLSpeed }, Hidden: true,
+		Text: func(t api.Torrent) string { return utils.FormatFileSizeAuto(uint64(t.DLSpeed), 1) + "/S" }},
+	{Name: "upspeed", Value: func(t api.Torrent) any { return t.UPSpeed }, Hidden: true,
+		Text: func(t api.Torrent) string { return utils.FormatFileSizeAuto(uint64(t.UPSpeed), 1) + "/S" }},
+	{Name: "ratio", Value: func(t api.Torrent) any { return t.Ratio }, Hidden: true,
+		Text: func(t api.Torrent) string { return strconv.FormatFloat(t.Ratio, 'f', 2, 64) }},
+	{Name: "eta", Value: func(t api.Torrent) any { return t.Eta }, Hidden: true,
+		Text: func(t api.Torrent) string { return formatSeconds(t.Eta) }},
+	{Name: "amount_left", Value: func(t api.Torrent) any { return t.AmountLeft }, Hidden: true,
+		Text: func(t api.Torrent) string { return utils.FormatFileSizeAuto(uint64(t.AmountLeft), 1) }},
+	{Name: "downloaded", Value: func(t api.Torrent) any { return t.Downloaded }, Hidden: true,
+		Text: func(t api.Torrent) string { return utils.FormatFileSizeAuto(uint64(t.Downloaded), 1) }},
+	{Name: "uploaded", Value: func(t api.Torrent) any { return t.Uploaded }, Hidden: true,
+		Text: func(t api.Torrent) string { return utils.FormatFileSizeAuto(uint64(t.Uploaded), 1) }},
+	{Name: "seeding_time", Value: func(t api.Torrent) any { return t.SeedingTime }, Hidden: true,
+		Text: func(t api.Torrent) string { return formatSeconds(t.SeedingTime) }},
+	{Name: "completion_on", Value: func(t api.Torrent) any { return t.CompletionOn }, Hidden: true,
+		Text: func(t api.Torrent) string { return formatUnixTime(t.CompletionOn) }},
+	{Name: "num_seeds", Value: func(t api.Torrent) any { return t.NumSeeds }, Hidden: true},
+	{Name: "num_leechs", Value: func(t api.Torrent) any { return t.NumLeechs }, Hidden: true},
+	{Name: "save_path", Value: func(t api.Torrent) any { return t.SavePath }, Hidden: true},
+	{Name: "content_path", Value: func(t api.Torrent) any { return t.ContentPath }, Hidden: true},
+	{Name: "tracker", Value: func(t api.Torrent) any { return t.Tracker }, Hidden: true},
+	{Name: "auto_tmm", Value: func(t api.Torrent) any { return t.AutoTMM }, Hidden: true},
+	{Name: "private", Value: func(t api.Torrent) any { return t.Private }, Hidden: true},
+}
+
 type torrentSearch struct {
+	ctx                          context.Context
 	state, category, tag, hashes string
 	limit, offset                uint32
+	where                        *TorrentWhere
+	sort                         []torrentSortKey
+	reverse                      bool
 	rows                         *[][]string
+	// interactive mode only transfers changes through /sync/maindata
+	sync *api.MainDataSync
 }
 
 func (t *torrentSearch) Frequency() time.Duration {
@@ -123,7 +202,7 @@ func (t *torrentSearch) notifyReturn(cursor int, operation string, params url.Va
 		params = url.Values{}
 	}
 	params.Set("hashes", hash)
-	if err := api.UpdateTorrent(operation, params); err != nil {
+	if err := api.UpdateTorrent(t.ctx, operation, params); err != nil {
 		notify = err.Error()
 	}
 	return &utils.KeyMsgDelegateModel{
@@ -152,31 +231,84 @@ func (t *torrentSearch) fetchData() (*[]api.Torrent, error) {
 	if t.hashes != "" {
 		params.Set("hashes", t.hashes)
 	}
-	if t.limit > 0 {
-		params.Set("limit", strconv.FormatUint(uint64(t.limit), 10))
+	// qBittorrent sorts by one field, the others are sorted on client side
+	clientSide := t.where.Enabled() || (len(t.sort) > 0 && !serverSortable(t.sort))
+	if serverSortable(t.sort) {
+		params.Set("sort", t.sort[0].field)
 	}
-	if t.offset > 0 {
-		params.Set("offset", strconv.FormatUint(uint64(t.offset), 10))
+	if !clientSide && (len(t.sort) > 0 && t.sort[0].desc) != t.reverse {
+		params.Set("reverse", "true")
+	}
+	// limit and offset are applied after --where and client side sorting
+	if !clientSide {
+		if t.limit > 0 {
+			params.Set("limit", strconv.FormatUint(uint64(t.limit), 10))
+		}
+		if t.offset > 0 {
+			params.Set("offset", strconv.FormatUint(uint64(t.offset), 10))
+		}
 	}
 
-	torrentList, err := api.TorrentList(params)
+	torrentList, err := api.TorrentList(t.ctx, params)
 	if err != nil {
 		return nil, err
 	}
+	if clientSide {
+		if torrentList, err = t.where.Filter(torrentList); err != nil {
+			return nil, err
+		}
+		sortTorrents(torrentList, t.sort, t.reverse)
+		torrentList = t.paginate(torrentList)
+	}
 	return &torrentList, nil
 }
 
+func (t *torrentSearch) paginate(torrentList []api.Torrent) []api.Torrent {
+	if t.offset > 0 {
+		if int(t.offset) >= len(torrentList) {
+			torrentList = nil
+		} else {
+			torrentList = torrentList[t.offset:]
+		}
+	}
+	if t.limit > 0 && int(t.limit) < len(torrentList) {
+		torrentList = torrentList[:t.limit]
+	}
+	return torrentList
+}
+
 func (t *torrentSearch) Headers() *[]string {
 	return nil
 }
 
 func (t *torrentSearch) Rows() *[][]string {
-	torrentList, err := t.fetchData()
-	if err != nil {
+	if _, err := t.sync.Update(t.ctx); err != nil {
 		return nil
 	}
-	var data = make([][]string, len(*torrentList))
-	for i, t := range *torrentList {
+	var hashes map[string]bool
+	if t.hashes != "" {
+		hashes = make(map[string]bool)
+		for _, h := range strings.Split(t.hashes, "|") {
+			hashes[h] = true
+		}
+	}
+	torrentList := t.sync.Torrents(func(torrent *api.Torrent) bool {
+		if t.category != "" && torrent.Category != t.category {
+			return false
+		}
+		if hashes != nil && !hashes[torrent.Hash] {
+			return false
+		}
+		if matched, err := t.where.Match(torrent); err != nil || !matched {
+			return false
+		}
+		return torrent.MatchTag(t.tag) && torrent.MatchStateFilter(t.state)
+	})
+	sortTorrents(torrentList, t.sort, t.reverse)
+	torrentList = t.paginate(torrentList)
+
+	var data = make([][]string, len(torrentList))
+	for i, t := range torrentList {
 		dl := utils.FormatFileSizeAuto(uint64(t.DLSpeed), 1) + "/S"
 		up := utils.FormatFileSizeAuto(uint64(t.UPSpeed), 1) + "/S"
 		data[i] = []string{t.Name, t.Hash, t.Category, t.State, utils.FormatPercent(t.Progress), dl, up}
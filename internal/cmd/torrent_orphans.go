@@ -0,0 +1,302 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"qbit-cli/internal/api"
+	"qbit-cli/pkg/utils"
+	"slices"
+	"strings"
+)
+
+// kinds of orphanEntry
+const (
+	orphanFile  = "orphan"
+	missingFile = "missing"
+)
+
+// incompleteSuffix is appended to incomplete files if "Append .!qB extension to incomplete files" is enabled
+const incompleteSuffix = ".!qB"
+
+// orphanEntry is a file on disk no torrent owns, or a torrent file missing on disk. Path is local.
+type orphanEntry struct {
+	Kind    string `json:"kind"`
+	Path    string `json:"path"`
+	Size    int64  `json:"size"`
+	Hash    string `json:"hash,omitempty"`
+	Torrent string `json:"torrent,omitempty"`
+	// Result is the trash path of moved orphans or the error
+	Result string `json:"result,omitempty"`
+}
+
+func TorrentOrphans() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "orphans",
+		Short: "Find files on disk no torrent owns and torrent files missing on disk",
+		Long: `Orphans walks the default save path and the save paths of all categories, then compares the files with the files
+of all torrents. Server paths are rewritten to local paths by --path-map server=local(the longest prefix wins),
+save paths which don't exist locally are skipped.
+Files which have been downloaded but are missing on disk are reported as missing.
+--trash moves the orphans into a directory keeping their paths relative to the save path, it should be on the same
+file system as the save paths.`,
+		Example: `qbit torrent orphans --path-map /downloads=/mnt/nas/downloads
+qbit torrent orphans --path-map /downloads=/mnt/nas/downloads --exclude @eaDir --exclude '*.part' --trash /mnt/nas/trash
+qbit torrent orphans -o json --columns kind,path,size`,
+	}
+
+	var (
+		pathMap, exclude []string
+		trash            string
+	)
+	cmd.Flags().StringArrayVar(&pathMap, "path-map", nil, "rewrite server path prefix to local path, old=new, repeatable")
+	cmd.Flags().StringArrayVar(&exclude, "exclude", nil, "skip files and directories whose name matches the glob, repeatable")
+	cmd.Flags().StringVar(&trash, "trash", "", "move orphans into the directory")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		mapping, err := parsePathMap(pathMap)
+		if err != nil {
+			return err
+		}
+		for _, pattern := range exclude {
+			if _, err := filepath.Match(pattern, ""); err != nil {
+				return fmt.Errorf("invalid --exclude %q: %w", pattern, err)
+			}
+		}
+		f := &orphanFinder{mapping: mapping, exclude: exclude, owned: make(map[string]bool)}
+		if trash != "" {
+			if f.trash, err = filepath.Abs(trash); err != nil {
+				return err
+			}
+		}
+		if err := f.findRoots(ctx); err != nil {
+			return err
+		}
+		if len(f.roots) == 0 {
+			return errors.New("none of the save paths exists locally, use --path-map server=local")
+		}
+		if err := f.collectOwned(ctx); err != nil {
+			return err
+		}
+		if err := f.walk(); err != nil {
+			return err
+		}
+		slices.SortFunc(f.entries, func(a, b orphanEntry) int {
+			return strings.Compare(a.Kind+a.Path, b.Kind+b.Path)
+		})
+
+		var moveErr error
+		if trash != "" {
+			moveErr = f.moveToTrash()
+		}
+		if err := printList(orphanColumns, f.entries); err != nil {
+			return err
+		}
+		if tableOutput() {
+			var orphans, missing int
+			var size int64
+			for _, e := range f.entries {
+				if e.Kind == orphanFile {
+					orphans++
+					size += e.Size
+				} else {
+					missing++
+				}
+			}
+			fmt.Printf("orphans: %d (%s), missing: %d\n", orphans, utils.FormatFileSizeAuto(uint64(size), 1), missing)
+		}
+		return moveErr
+	}
+
+	return cmd
+}
+
+type orphanFinder struct {
+	mapping []pathMapping
+	exclude []string
+	trash   string
+	// roots are local save paths to walk
+	roots []string
+	// owned are local paths of torrent files
+	owned   map[string]bool
+	entries []orphanEntry
+}
+
+func (f *orphanFinder) localPath(serverPath string) string {
+	return filepath.Clean(filepath.FromSlash(mapPath(f.mapping, serverPath)))
+}
+
+// findRoots resolves the default save path and the save paths of categories, empty or relative category paths are
+// under the default save path. Nested roots are walked by the outer one.
+func (f *orphanFinder) findRoots(ctx context.Context) error {
+	defaultPath, err := api.DefaultSavePath(ctx)
+	if err != nil {
+		return err
+	}
+	categories, err := api.CategoryList(ctx)
+	if err != nil {
+		return err
+	}
+	paths := []string{defaultPath}
+	for _, c := range *categories {
+		switch {
+		case c.SavePath == "":
+			paths = append(paths, path.Join(defaultPath, c.Name))
+		case !path.IsAbs(c.SavePath) && !filepath.IsAbs(c.SavePath):
+			paths = append(paths, path.Join(defaultPath, c.SavePath))
+		default:
+			paths = append(paths, c.SavePath)
+		}
+	}
+
+	var roots []string
+	for _, p := range paths {
+		root := f.localPath(p)
+		if info, err := os.Stat(root); err != nil || !info.IsDir() {
+			continue
+		}
+		roots = append(roots, root)
+	}
+	slices.Sort(roots)
+	for _, root := range roots {
+		if !f.inRoots(root) {
+			f.roots = append(f.roots, root)
+		}
+	}
+	return nil
+}
+
+// withinDir reports whether p is dir or under dir.
+func withinDir(dir, p string) bool {
+	rel, err := filepath.Rel(dir, p)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// collectOwned lists files of all torrents. Files of the save path and the download path of incomplete torrents are
+// owned, files which have data but exist at neither are missing.
+func (f *orphanFinder) collectOwned(ctx context.Context) error {
+	torrents, err := api.TorrentList(ctx, url.Values{})
+	if err != nil {
+		return err
+	}
+	for _, t := range torrents {
+		files, err := api.TorrentFiles(ctx, url.Values{"hash": {t.Hash}})
+		if err != nil {
+			return fmt.Errorf("%s: %w", t.Hash, err)
+		}
+		bases := []string{t.SavePath}
+		if t.DownloadPath != "" {
+			bases = append(bases, t.DownloadPath)
+		}
+		for _, file := range files {
+			var candidates []string
+			for _, base := range bases {
+				local := f.localPath(path.Join(base, file.Name))
+				candidates = append(candidates, local, local+incompleteSuffix)
+			}
+			for _, c := range candidates {
+				f.owned[c] = true
+			}
+			if file.Priority == 0 || file.Progress <= 0 || !f.inRoots(candidates[0]) {
+				continue
+			}
+			if !slices.ContainsFunc(candidates, utils.FileExists) {
+				f.entries = append(f.entries, orphanEntry{Kind: missingFile, Path: candidates[0], Size: file.Size, Hash: t.Hash, Torrent: t.Name})
+			}
+		}
+	}
+	return nil
+}
+
+func (f *orphanFinder) inRoots(p string) bool {
+	return slices.ContainsFunc(f.roots, func(root string) bool { return withinDir(root, p) })
+}
+
+func (f *orphanFinder) excluded(name string) bool {
+	return slices.ContainsFunc(f.exclude, func(pattern string) bool {
+		ok, _ := filepath.Match(pattern, name)
+		return ok
+	})
+}
+
+// walk reports regular files of roots which are not owned, the trash directory is skipped.
+func (f *orphanFinder) walk() error {
+	for _, root := range f.roots {
+		err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if p != root && f.excluded(d.Name()) || d.IsDir() && f.trash != "" && withinDir(f.trash, p) {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !d.Type().IsRegular() || f.owned[p] {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			f.entries = append(f.entries, orphanEntry{Kind: orphanFile, Path: p, Size: info.Size()})
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// moveToTrash moves orphans into the trash keeping their paths relative to the root, existing files aren't replaced.
+func (f *orphanFinder) moveToTrash() error {
+	perr := &api.PartialError{}
+	for i := range f.entries {
+		e := &f.entries[i]
+		if e.Kind != orphanFile {
+			continue
+		}
+		perr.Total++
+		dest, err := f.move(e.Path)
+		if e.Result = dest; err != nil {
+			e.Result = err.Error()
+		}
+		perr.Add(e.Path, err)
+	}
+	return perr.Err()
+}
+
+// move returns the trash path of p, orphans of a root are moved into the directory named by the root.
+func (f *orphanFinder) move(p string) (string, error) {
+	i := slices.IndexFunc(f.roots, func(root string) bool { return withinDir(root, p) })
+	rel, err := filepath.Rel(f.roots[i], p)
+	if err != nil {
+		return "", err
+	}
+	dest := filepath.Join(f.trash, filepath.Base(f.roots[i]), rel)
+	if utils.FileExists(dest) {
+		return "", fmt.Errorf("%s already exists", dest)
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return "", err
+	}
+	return dest, os.Rename(p, dest)
+}
+
+var orphanColumns = []utils.Column[orphanEntry]{
+	{Name: "kind", Value: func(e orphanEntry) any { return e.Kind }},
+	{Name: "path", Value: func(e orphanEntry) any { return e.Path }, Width: 70, Wrap: true},
+	{Name: "size", Value: func(e orphanEntry) any { return e.Size },
+		Text: func(e orphanEntry) string { return utils.FormatFileSizeAuto(uint64(e.Size), 1) }},
+	{Name: "hash", Value: func(e orphanEntry) any { return e.Hash }, Hidden: true},
+	{Name: "torrent", Value: func(e orphanEntry) any { return e.Torrent }, Width: 30},
+	{Name: "result", Value: func(e orphanEntry) any { return e.Result }, Width: 40, Wrap: true},
+}
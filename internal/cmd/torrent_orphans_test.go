@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"qbit-cli/internal/api"
+	"qbit-cli/internal/qbittest"
+	"qbit-cli/pkg/utils"
+	"slices"
+	"testing"
+)
+
+func TestTorrentOrphans(t *testing.T) {
+	s := qbittest.NewServer(qbittest.Options{})
+	defer s.Close()
+	s.Configure(t)
+
+	// server paths under /downloads are local paths under dir
+	dir := t.TempDir()
+	local := filepath.Join(dir, "downloads")
+	s.AddCategory("tv", "/downloads/tv")
+	s.AddCategory("movies", "")
+	s.AddTorrent(qbittest.Torrent{Hash: "aaa", Name: "Show", SavePath: "/downloads/tv", Files: []api.TorrentFile{
+		{Name: "Show/e1.mkv", Size: 3, Progress: 1, Priority: 1},
+		{Name: "Show/e2.mkv", Size: 5, Progress: 1, Priority: 1},
+		{Name: "Show/e3.mkv", Size: 7, Progress: 0, Priority: 1},
+	}})
+	s.AddTorrent(qbittest.Torrent{Hash: "bbb", Name: "Movie", SavePath: "/downloads/movies", Files: []api.TorrentFile{
+		{Name: "Movie.mkv", Size: 9, Progress: 0.5, Priority: 1},
+	}})
+	for name, content := range map[string]string{
+		"tv/Show/e1.mkv":           "abc",
+		"movies/Movie.mkv.!qB":     "12345",
+		"tv/Old/old.mkv":           "1234567890",
+		"stray.bin":                "xy",
+		"tv/@eaDir/Show/thumb.jpg": "thumb",
+	} {
+		p := filepath.Join(local, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	trash := filepath.Join(dir, "trash")
+	cmd := TorrentOrphans()
+	cmd.SetArgs([]string{"--path-map", "/downloads=" + local, "--exclude", "@eaDir", "--trash", trash})
+	if err := cmd.ExecuteContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"downloads/stray.bin", "downloads/tv/Old/old.mkv"} {
+		if !utils.FileExists(filepath.Join(trash, filepath.FromSlash(name))) {
+			t.Errorf("%s is not moved to trash", name)
+		}
+	}
+	for _, name := range []string{"tv/Show/e1.mkv", "movies/Movie.mkv.!qB", "tv/@eaDir/Show/thumb.jpg"} {
+		if !utils.FileExists(filepath.Join(local, filepath.FromSlash(name))) {
+			t.Errorf("%s is moved", name)
+		}
+	}
+
+	// e3 has no data yet, so that only e2 is missing
+	f := &orphanFinder{mapping: []pathMapping{{from: "/downloads", to: local}}, owned: make(map[string]bool)}
+	if err := f.findRoots(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(f.roots, []string{local}) {
+		t.Fatalf("got roots %v", f.roots)
+	}
+	if err := f.collectOwned(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.entries) != 1 || f.entries[0].Path != filepath.Join(local, "tv", "Show", "e2.mkv") || f.entries[0].Hash != "aaa" {
+		t.Fatalf("got missing files %+v", f.entries)
+	}
+}
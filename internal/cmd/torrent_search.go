@@ -1,7 +1,7 @@
 package cmd
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
 	"fmt"
 	"net/url"
@@ -67,13 +67,18 @@ make sure you plugin is valid and enabled`)
 	saveCategory.RegisterCompletion(searchCmd)
 
 	searchCmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
 
 		params := url.Values{
 			"pattern": {args[0]},
 		}
 
 		if plugins.Value == "" {
-			plugins.Value = config.GetConfig().Torrent.DefaultSearchPlugin
+			profile, err := config.GetProfile()
+			if err != nil {
+				return err
+			}
+			plugins.Value = profile.Torrent.DefaultSearchPlugin
 		}
 		if plugins.Value == "" {
 			plugins.Value = "enabled"
@@ -85,12 +90,12 @@ make sure you plugin is valid and enabled`)
 			params.Set("category", pluginCategory)
 		}
 
-		result, err := api.SearchStart(params)
+		result, err := api.SearchStart(ctx, params)
 		if err != nil {
 			return err
 		}
 
-		results, err := api.SearchDetails(1*time.Second, result.ID)
+		results, err := api.SearchDetails(ctx, 1*time.Second, result.ID)
 		if err != nil {
 			return nil
 		}
@@ -99,35 +104,34 @@ make sure you plugin is valid and enabled`)
 		if torrentRegex != "" {
 			r, err := regexp.Compile(torrentRegex)
 			if err != nil {
-				fmt.Printf("regex: %s compile failed\n", torrentRegex)
-			} else {
-				re = r
+				return fmt.Errorf("regex: %s compile failed: %w", torrentRegex, err)
 			}
+			re = r
 		}
 
 		//var urls []string
-		var printList = make([]*api.SearchDetail, 0, len(results))
+		var matched = make([]*api.SearchDetail, 0, len(results))
 		for _, r := range results {
 			if re == nil {
-				printList = append(printList, r)
+				matched = append(matched, r)
 			} else {
 				if re.MatchString(r.FileName) {
-					printList = append(printList, r)
+					matched = append(matched, r)
 					//urls = append(urls, r.FileURL)
 				}
 			}
 		}
 
-		sort.Slice(printList, func(i, j int) bool {
-			return printList[i].NBSeeders > printList[j].NBSeeders
+		sort.Slice(matched, func(i, j int) bool {
+			return matched[i].NBSeeders > matched[j].NBSeeders
 		})
 
 		if interactive {
-			interactive = interactive && len(printList) > 0
+			interactive = interactive && len(matched) > 0
 			if interactive {
 				header := []string{"name", "size", "S", "L", "plugin"}
-				data := make([][]string, 0, len(printList))
-				for _, r := range printList {
+				data := make([][]string, 0, len(matched))
+				for _, r := range matched {
 					data = append(data, []string{r.FileName, utils.FormatFileSizeAuto(uint64(r.FileSize), 1),
 						strconv.FormatInt(int64(r.NBSeeders), 10), strconv.FormatInt(int64(r.NBLeechers), 10), r.EngineName})
 				}
@@ -136,32 +140,35 @@ make sure you plugin is valid and enabled`)
 					Header:   &header,
 					WidthMap: map[int]int{0: 50, 1: 10, 2: 10, 3: 10, 4: 20},
 					Delegate: &torrentSearchMsgDelegate{
+						ctx,
 						autoDownload, autoMM,
 						savePath, saveCategory.Value, saveTags,
-						printList,
+						matched,
 					},
 				}
-				if _, e := tea.NewProgram(&model, tea.WithAltScreen()).Run(); e != nil {
+				if _, e := tea.NewProgram(&model, tea.WithAltScreen(), tea.WithContext(ctx)).Run(); e != nil {
 					return e
 				}
 			}
 		} else {
-			fmt.Printf("total search result size: %d\n", len(printList))
-			if len(printList) <= 0 {
+			if tableOutput() {
+				fmt.Printf("total search result size: %d\n", len(matched))
+			}
+			if len(matched) <= 0 {
 				return nil
 			}
-			str, err := json.MarshalIndent(printList, "", "  ")
-			if err != nil {
+			if err := printList(searchResultColumns, matched); err != nil {
 				return err
 			}
-			fmt.Println(string(str))
 
-			if autoDownload && len(printList) > 0 {
-				var downloadList = make([]string, 0, len(printList))
-				for _, r := range printList {
+			if autoDownload {
+				var downloadList = make([]string, 0, len(matched))
+				sizes := make(map[string]int64, len(matched))
+				for _, r := range matched {
 					downloadList = append(downloadList, r.FileURL)
+					sizes[r.FileURL] = r.FileSize
 				}
-				AutoDownload(downloadList, savePath, saveCategory.Value, saveTags, autoMM)
+				return AutoDownload(ctx, downloadList, sizes, savePath, saveCategory.Value, saveTags, autoMM)
 			}
 		}
 
@@ -171,21 +178,44 @@ make sure you plugin is valid and enabled`)
 	return searchCmd
 }
 
-func AutoDownload(urls []string, savePath, saveCategory, saveTags string, autoMM bool) {
+var searchResultColumns = []utils.Column[*api.SearchDetail]{
+	{Name: "name", Value: func(r *api.SearchDetail) any { return r.FileName }, Width: 50},
+	{Name: "size", Value: func(r *api.SearchDetail) any { return r.FileSize },
+		Text: func(r *api.SearchDetail) string { return utils.FormatFileSizeAuto(uint64(r.FileSize), 1) }},
+	{Name: "seeders", Header: "S", Value: func(r *api.SearchDetail) any { return r.NBSeeders }},
+	{Name: "leechers", Header: "L", Value: func(r *api.SearchDetail) any { return r.NBLeechers }},
+	{Name: "plugin", Value: func(r *api.SearchDetail) any { return r.EngineName }},
+	{Name: "url", Value: func(r *api.SearchDetail) any { return r.FileURL }, Hidden: true},
+	{Name: "desc_link", Value: func(r *api.SearchDetail) any { return r.DescLink }, Hidden: true},
+	{Name: "site", Value: func(r *api.SearchDetail) any { return r.SiteUrl }, Hidden: true},
+}
+
+// AutoDownload adds urls of search results, sizes of results are checked against the free space of the server.
+func AutoDownload(ctx context.Context, urls []string, sizes map[string]int64, savePath, saveCategory, saveTags string, autoMM bool) error {
 	addParams := url.Values{}
 	addParams.Set("category", saveCategory)
 	addParams.Set("tags", saveTags)
 	addParams.Set("auto-manage", strconv.FormatBool(autoMM))
 	addParams.Set("save-path", savePath)
-	LoadTorrentAddDefault(addParams)
-	if err := api.TorrentAdd(urls, addParams); err != nil {
-		fmt.Println("auto download failed:", err)
-	} else {
-		fmt.Printf("auto download %d torrent(s) success\n", len(urls))
+	if err := LoadTorrentAddDefault(addParams); err != nil {
+		return err
 	}
+	space, err := LoadSpaceGuard()
+	if err != nil {
+		return err
+	}
+	results, err := api.TorrentAddChecked(ctx, urls, addParams, api.AddOptions{OnDuplicate: api.DuplicateSkip, Sizes: sizes, Space: space})
+	if results == nil {
+		return err
+	}
+	if printErr := PrintAddResults(results); printErr != nil {
+		return printErr
+	}
+	return err
 }
 
 type torrentSearchMsgDelegate struct {
+	ctx                              context.Context
 	autoDownload, autoMM             bool
 	savePath, saveCategory, saveTags string
 	data                             []*api.SearchDetail
@@ -198,7 +228,8 @@ func (j *torrentSearchMsgDelegate) Operation(msg tea.KeyMsg, cursor int) *utils.
 			return nil
 		}
 		torrents := j.data[cursor].FileURL
-		str := InteractiveDownload([]string{torrents}, j.savePath, j.saveCategory, j.saveTags, j.autoMM)
+		sizes := map[string]int64{torrents: j.data[cursor].FileSize}
+		str := InteractiveDownload(j.ctx, []string{torrents}, sizes, j.savePath, j.saveCategory, j.saveTags, j.autoMM)
 		return &utils.KeyMsgDelegateModel{
 			RenderClicked: true,
 			NotifyMsg:     utils.NotifyMsg{Msg: str, Duration: time.Second},
@@ -211,16 +242,28 @@ func (j *torrentSearchMsgDelegate) Desc() string {
 	return "[enter] download"
 }
 
-func InteractiveDownload(urls []string, savePath, saveCategory, saveTags string, autoMM bool) string {
+func InteractiveDownload(ctx context.Context, urls []string, sizes map[string]int64, savePath, saveCategory, saveTags string, autoMM bool) string {
 	addParams := url.Values{}
 	addParams.Set("category", saveCategory)
 	addParams.Set("tags", saveTags)
 	addParams.Set("auto-manage", strconv.FormatBool(autoMM))
 	addParams.Set("save-path", savePath)
-	LoadTorrentAddDefault(addParams)
-	if err := api.TorrentAdd(urls, addParams); err != nil {
+	if err := LoadTorrentAddDefault(addParams); err != nil {
+		return fmt.Sprintf("download failed: %s", err)
+	}
+	space, err := LoadSpaceGuard()
+	if err != nil {
+		return fmt.Sprintf("download failed: %s", err)
+	}
+	results, err := api.TorrentAddChecked(ctx, urls, addParams, api.AddOptions{OnDuplicate: api.DuplicateSkip, Sizes: sizes, Space: space})
+	if err != nil {
 		return fmt.Sprintf("download failed: %s", err)
-	} else {
-		return "download success"
 	}
+	switch {
+	case results[0].Status == api.AddStatusPresent:
+		return "already present"
+	case results[0].Warning != "":
+		return "download success, " + results[0].Warning
+	}
+	return "download success"
 }
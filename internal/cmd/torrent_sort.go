@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"qbit-cli/internal/api"
+	"qbit-cli/pkg/utils"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// torrentSortKey is a key of --sort, keys prefixed by - are descending.
+type torrentSortKey struct {
+	field string
+	desc  bool
+}
+
+func parseTorrentSort(keys []string) ([]torrentSortKey, error) {
+	fields := api.TorrentFieldNames()
+	sortKeys := make([]torrentSortKey, 0, len(keys))
+	for _, key := range keys {
+		key = strings.TrimSpace(key)
+		field, desc := strings.CutPrefix(key, "-")
+		field = strings.TrimPrefix(field, "+")
+		if !slices.Contains(fields, field) {
+			sort.Strings(fields)
+			return nil, fmt.Errorf("sort: unknown field %q, available: %s", field, strings.Join(fields, ","))
+		}
+		sortKeys = append(sortKeys, torrentSortKey{field: field, desc: desc})
+	}
+	return sortKeys, nil
+}
+
+// serverSortable reports whether qBittorrent can sort torrents by keys, it only supports one field.
+func serverSortable(keys []torrentSortKey) bool {
+	return len(keys) == 1 && !api.IsComputedField(keys[0].field)
+}
+
+func sortTorrents(torrents []api.Torrent, keys []torrentSortKey, reverse bool) {
+	slices.SortStableFunc(torrents, func(a, b api.Torrent) int {
+		for _, key := range keys {
+			c := api.CompareTorrents(&a, &b, key.field)
+			if key.desc {
+				c = -c
+			}
+			if c != 0 {
+				return c
+			}
+		}
+		return 0
+	})
+	if reverse {
+		slices.Reverse(torrents)
+	}
+}
+
+var TorrentGroupBy = []string{"category", "tag", "state", "tracker"}
+
+// torrentGroup is a group of --group-by, a torrent with many tags is in all groups of its tags.
+type torrentGroup struct {
+	name     string
+	torrents []api.Torrent
+}
+
+// noneGroup is the group of torrents without category, tag or tracker
+const noneGroup = "(none)"
+
+func checkTorrentGroupBy(by string) error {
+	if by != "" && !slices.Contains(TorrentGroupBy, by) {
+		return fmt.Errorf("group-by: unsupported %q, use one of %s", by, strings.Join(TorrentGroupBy, ","))
+	}
+	return nil
+}
+
+func groupTorrents(torrents []api.Torrent, by string) []torrentGroup {
+	keys := func(t *api.Torrent) []string {
+		switch by {
+		case "category":
+			return []string{t.Category}
+		case "tag":
+			return t.TagList()
+		case "state":
+			return []string{t.State}
+		}
+		return []string{t.TrackerHost()}
+	}
+	index := make(map[string]int)
+	var groups []torrentGroup
+	for _, t := range torrents {
+		names := keys(&t)
+		if len(names) == 0 {
+			names = []string{""}
+		}
+		for _, name := range names {
+			if name == "" {
+				name = noneGroup
+			}
+			i, ok := index[name]
+			if !ok {
+				i = len(groups)
+				index[name] = i
+				groups = append(groups, torrentGroup{name: name})
+			}
+			groups[i].torrents = append(groups[i].torrents, t)
+		}
+	}
+	slices.SortStableFunc(groups, func(a, b torrentGroup) int { return strings.Compare(a.name, b.name) })
+	return groups
+}
+
+// torrentSummary is the aggregate of torrents, ratio is the average one.
+type torrentSummary struct {
+	Group   string
+	Count   int
+	Size    int64
+	DLSpeed int64
+	UPSpeed int64
+	Ratio   float64
+}
+
+func summarizeTorrents(group string, torrents []api.Torrent) torrentSummary {
+	s := torrentSummary{Group: group, Count: len(torrents)}
+	for _, t := range torrents {
+		s.Size += t.Size
+		s.DLSpeed += t.DLSpeed
+		s.UPSpeed += t.UPSpeed
+		s.Ratio += t.Ratio
+	}
+	if s.Count > 0 {
+		s.Ratio /= float64(s.Count)
+	}
+	return s
+}
+
+func (s torrentSummary) String() string {
+	return fmt.Sprintf("count: %d, size: %s, download: %s, upload: %s, avg ratio: %s", s.Count,
+		utils.FormatFileSizeAuto(uint64(s.Size), 1), formatSpeed(s.DLSpeed), formatSpeed(s.UPSpeed),
+		strconv.FormatFloat(s.Ratio, 'f', 2, 64))
+}
+
+func formatSpeed(speed int64) string {
+	return utils.FormatFileSizeAuto(uint64(speed), 1) + "/S"
+}
+
+var torrentSummaryColumns = []utils.Column[torrentSummary]{
+	{Name: "group", Value: func(s torrentSummary) any { return s.Group }},
+	{Name: "count", Value: func(s torrentSummary) any { return s.Count }},
+	{Name: "size", Value: func(s torrentSummary) any { return s.Size },
+		Text: func(s torrentSummary) string { return utils.FormatFileSizeAuto(uint64(s.Size), 1) }},
+	{Name: "dlspeed", Value: func(s torrentSummary) any { return s.DLSpeed },
+		Text: func(s torrentSummary) string { return formatSpeed(s.DLSpeed) }},
+	{Name: "upspeed", Value: func(s torrentSummary) any { return s.UPSpeed },
+		Text: func(s torrentSummary) string { return formatSpeed(s.UPSpeed) }},
+	{Name: "ratio", Value: func(s torrentSummary) any { return s.Ratio },
+		Text: func(s torrentSummary) string { return strconv.FormatFloat(s.Ratio, 'f', 2, 64) }},
+}
+
+// printTorrentGroups prints torrents of every group with subtotals as table, other outputs only have the subtotals.
+func printTorrentGroups(torrents []api.Torrent, by string) error {
+	groups := groupTorrents(torrents, by)
+	summaries := make([]torrentSummary, len(groups))
+	for i, g := range groups {
+		summaries[i] = summarizeTorrents(g.name, g.torrents)
+	}
+	if !tableOutput() {
+		return printList(torrentSummaryColumns, summaries)
+	}
+	for i, g := range groups {
+		fmt.Printf("%s: %s\n", by, g.name)
+		if err := printList(torrentColumns, g.torrents); err != nil {
+			return err
+		}
+		fmt.Printf("subtotal %s\n\n", summaries[i])
+	}
+	fmt.Printf("total %s\n", summarizeTorrents("", torrents))
+	// --columns selects columns of torrents
+	return utils.Render(os.Stdout, utils.Output{Format: utils.OutputTable}, torrentSummaryColumns, summaries)
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"context"
+	"qbit-cli/internal/api"
+	"qbit-cli/internal/qbittest"
+	"testing"
+)
+
+func hashesOf(torrents []api.Torrent) string {
+	var s string
+	for _, t := range torrents {
+		s += t.Hash
+	}
+	return s
+}
+
+func TestTorrentListSort(t *testing.T) {
+	s := qbittest.NewServer(qbittest.Options{})
+	defer s.Close()
+	s.Configure(t)
+	s.AddTorrent(qbittest.Torrent{Hash: "a", Name: "a", Category: "tv", Size: 30})
+	s.AddTorrent(qbittest.Torrent{Hash: "b", Name: "b", Category: "movie", Size: 10})
+	s.AddTorrent(qbittest.Torrent{Hash: "c", Name: "c", Category: "movie", Size: 20})
+	s.AddTorrent(qbittest.Torrent{Hash: "d", Name: "d", Size: 40})
+
+	tests := []struct {
+		sort    []string
+		where   string
+		reverse bool
+		limit   uint32
+		want    string
+		server  bool
+	}{
+		{[]string{"-size"}, "", false, 2, "da", true},
+		{[]string{"size"}, "", true, 0, "dacb", true},
+		{[]string{"category", "-size"}, "", false, 3, "dcb", false},
+		{[]string{"category", "name"}, "", true, 0, "acbd", false},
+		// --where sorts on client side, the server order is reversed
+		{nil, "size > 10", true, 0, "dca", false},
+	}
+	for _, tt := range tests {
+		keys, err := parseTorrentSort(tt.sort)
+		if err != nil {
+			t.Fatal(err)
+		}
+		before := len(s.RequestsTo("torrents/info"))
+		d := torrentSearch{ctx: context.Background(), sort: keys, reverse: tt.reverse, limit: tt.limit, where: &TorrentWhere{Value: tt.where}}
+		list, err := d.fetchData()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hashesOf(*list); got != tt.want {
+			t.Errorf("%v reverse %v: got %s, want %s", tt.sort, tt.reverse, got, tt.want)
+		}
+		request := s.RequestsTo("torrents/info")[before]
+		if server := request.Form.Get("sort") != ""; server != tt.server {
+			t.Errorf("%v: server sort %v, want %v", tt.sort, server, tt.server)
+		}
+	}
+
+	if _, err := parseTorrentSort([]string{"sizee"}); err == nil {
+		t.Error("unknown sort field should fail")
+	}
+}
+
+func TestGroupTorrents(t *testing.T) {
+	torrents := []api.Torrent{
+		{Hash: "a", Tags: "hd, new", Size: 10, Ratio: 1, UPSpeed: 5},
+		{Hash: "b", Tags: "hd", Size: 20, Ratio: 2},
+		{Hash: "c", Size: 30},
+	}
+	groups := groupTorrents(torrents, "tag")
+	if len(groups) != 3 || groups[0].name != noneGroup || groups[1].name != "hd" || groups[2].name != "new" {
+		t.Fatalf("unexpected groups %+v", groups)
+	}
+	summary := summarizeTorrents("hd", groups[1].torrents)
+	if summary.Count != 2 || summary.Size != 30 || summary.Ratio != 1.5 || summary.UPSpeed != 5 {
+		t.Fatalf("unexpected summary %+v", summary)
+	}
+}
@@ -25,19 +25,13 @@ func TagList() *cobra.Command {
 	}
 
 	tagListCmd.RunE = func(cmd *cobra.Command, args []string) error {
-		tags, err := api.TagList()
+		ctx := cmd.Context()
+		tags, err := api.TagList(ctx)
 		if err != nil {
 			return err
 		}
 
-		header := []string{"TAG"}
-		data := make([][]string, 0, len(tags))
-		for _, tag := range tags {
-			data = append(data, []string{tag})
-		}
-		utils.PrintList(header, &data)
-
-		return nil
+		return printList(tagColumns, tags)
 	}
 
 	return tagListCmd
@@ -53,8 +47,9 @@ func DeleteTag() *cobra.Command {
 	deleteTagCmd.Flags().BoolVar(&all, "all", false, "Delete all tags")
 
 	deleteTagCmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
 		if all {
-			tags, err := api.TagList()
+			tags, err := api.TagList(ctx)
 			if err != nil {
 				return err
 			}
@@ -64,7 +59,7 @@ func DeleteTag() *cobra.Command {
 				return errors.New("must provide at least one tag")
 			}
 		}
-		err := api.TagUpdate("deleteTags", args)
+		err := api.TagUpdate(ctx, "deleteTags", args)
 		if err != nil {
 			return err
 		}
@@ -86,7 +81,8 @@ func AddTag() *cobra.Command {
 	}
 
 	addTagCmd.RunE = func(cmd *cobra.Command, args []string) error {
-		err := api.TagUpdate("createTags", args)
+		ctx := cmd.Context()
+		err := api.TagUpdate(ctx, "createTags", args)
 		if err != nil {
 			return err
 		}
@@ -94,3 +90,7 @@ func AddTag() *cobra.Command {
 	}
 	return addTagCmd
 }
+
+var tagColumns = []utils.Column[string]{
+	{Name: "tag", Header: "TAG", Value: func(tag string) any { return tag }},
+}
@@ -0,0 +1,177 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"net/url"
+	"qbit-cli/internal/api"
+	"qbit-cli/pkg/utils"
+	"strings"
+)
+
+func TrackerAdd() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:     "add <hash> <url>...",
+		Short:   "Add trackers to a torrent",
+		Example: `qbit torrent tracker add <hash> https://tracker.example.com/announce`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) < 2 {
+				return errors.New("torrent hash and at least one tracker url are required")
+			}
+			return nil
+		},
+	}
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		return api.TorrentAddTrackers(cmd.Context(), args[0], args[1:])
+	}
+
+	return cmd
+}
+
+func TrackerEdit() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "edit <hash> <url> <new url>",
+		Short: "Edit a tracker url of a torrent",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 3 {
+				return errors.New("torrent hash, tracker url and new url are required")
+			}
+			return nil
+		},
+	}
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		return api.TorrentEditTracker(cmd.Context(), args[0], args[1], args[2])
+	}
+
+	return cmd
+}
+
+func TrackerRemove() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "remove <hash> <url>...",
+		Short: "Remove trackers of a torrent",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) < 2 {
+				return errors.New("torrent hash and at least one tracker url are required")
+			}
+			return nil
+		},
+	}
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		return api.TorrentRemoveTrackers(cmd.Context(), args[0], args[1:])
+	}
+
+	return cmd
+}
+
+// trackerChange is a tracker url rewritten by tracker replace.
+type trackerChange struct {
+	hash, name string
+	from, to   string
+	result     string
+}
+
+func TrackerReplace() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "replace <old> <new>",
+		Short: "Replace a url or passkey substring of trackers across torrents",
+		Long: `Replace rewrites every tracker url containing <old> of all torrents, or the ones selected by --hashes and --where.
+Use --dry-run to preview the changes.`,
+		Example: `qbit torrent tracker replace 0123oldpasskey 4567newpasskey --dry-run
+qbit torrent tracker replace http://tracker.example.com https://tracker.example.com --where 'private'`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 2 {
+				return errors.New("old and new substrings are required")
+			}
+			if args[0] == "" || args[0] == args[1] {
+				return errors.New("old substring must be non-empty and differ from the new one")
+			}
+			return nil
+		},
+	}
+
+	var (
+		hashes string
+		dryRun bool
+		where  TorrentWhere
+	)
+	cmd.Flags().StringVar(&hashes, "hashes", "", "hash filter separated by |")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "print the changes without editing trackers")
+	where.RegisterFlag(cmd)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		if err := where.Compile(); err != nil {
+			return err
+		}
+		params := url.Values{}
+		if hashes != "" {
+			params.Set("hashes", hashes)
+		}
+		torrents, err := api.TorrentList(ctx, params)
+		if err != nil {
+			return err
+		}
+		if torrents, err = where.Filter(torrents); err != nil {
+			return err
+		}
+
+		old, replacement := args[0], args[1]
+		perr := &api.PartialError{}
+		var changes []trackerChange
+		changed := make(map[string]bool)
+		for _, t := range torrents {
+			trackers, err := api.TorrentTrackers(ctx, t.Hash)
+			if err != nil {
+				perr.Total++
+				perr.Add(t.Hash, err)
+				continue
+			}
+			for _, tracker := range *trackers {
+				// DHT, PeX and LSD are listed as ** [DHT] ** and so on
+				if strings.HasPrefix(tracker.URL, "** [") || !strings.Contains(tracker.URL, old) {
+					continue
+				}
+				c := trackerChange{hash: t.Hash, name: t.Name, from: tracker.URL,
+					to: strings.ReplaceAll(tracker.URL, old, replacement), result: "dry-run"}
+				if !dryRun {
+					perr.Total++
+					c.result = "replaced"
+					if err := api.TorrentEditTracker(ctx, t.Hash, c.from, c.to); err != nil {
+						perr.Add(t.Hash+" "+c.from, err)
+						c.result = err.Error()
+					}
+				}
+				changed[t.Hash] = true
+				changes = append(changes, c)
+			}
+		}
+
+		if err := printList(trackerChangeColumns, changes); err != nil {
+			return err
+		}
+		if tableOutput() {
+			verb := "replaced"
+			if dryRun {
+				verb = "to replace"
+			}
+			fmt.Printf("torrents scanned: %d, trackers %s: %d of %d torrents, failed: %d\n",
+				len(torrents), verb, len(changes), len(changed), len(perr.Errs))
+		}
+		return perr.Err()
+	}
+
+	return cmd
+}
+
+var trackerChangeColumns = []utils.Column[trackerChange]{
+	{Name: "hash", Value: func(c trackerChange) any { return c.hash }},
+	{Name: "name", Value: func(c trackerChange) any { return c.name }, Width: 30},
+	{Name: "from", Value: func(c trackerChange) any { return c.from }, Width: 50, Wrap: true},
+	{Name: "to", Value: func(c trackerChange) any { return c.to }, Width: 50, Wrap: true},
+	{Name: "result", Value: func(c trackerChange) any { return c.result }, Width: 30, Wrap: true},
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"context"
+	"qbit-cli/internal/api"
+	"qbit-cli/internal/qbittest"
+	"testing"
+)
+
+func trackers(urls ...string) []api.TorrentTracker {
+	list := []api.TorrentTracker{{URL: "** [DHT] **"}}
+	for _, u := range urls {
+		list = append(list, api.TorrentTracker{URL: u, Status: 2})
+	}
+	return list
+}
+
+func TestTrackerReplace(t *testing.T) {
+	s := qbittest.NewServer(qbittest.Options{})
+	defer s.Close()
+	s.Configure(t)
+	s.AddTorrent(qbittest.Torrent{Hash: "aaa", Name: "a", Trackers: trackers("https://pt.example.com/announce?passkey=old")})
+	s.AddTorrent(qbittest.Torrent{Hash: "bbb", Name: "b", Trackers: trackers("https://pt.example.com/announce?passkey=old", "udp://open.example.com:80")})
+	s.AddTorrent(qbittest.Torrent{Hash: "ccc", Name: "c", Trackers: trackers("udp://open.example.com:80")})
+	ctx := context.Background()
+
+	cmd := TorrentTracker()
+	cmd.SetArgs([]string{"replace", "passkey=old", "passkey=new", "--dry-run"})
+	if err := cmd.ExecuteContext(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(s.RequestsTo("torrents/editTracker")); n != 0 {
+		t.Fatalf("dry run sent %d edits", n)
+	}
+
+	cmd = TorrentTracker()
+	cmd.SetArgs([]string{"replace", "passkey=old", "passkey=new"})
+	if err := cmd.ExecuteContext(ctx); err != nil {
+		t.Fatal(err)
+	}
+	for _, hash := range []string{"aaa", "bbb"} {
+		torrent, _ := s.Torrent(hash)
+		if got := torrent.Trackers[1].URL; got != "https://pt.example.com/announce?passkey=new" {
+			t.Errorf("%s tracker is %s", hash, got)
+		}
+	}
+	if n := len(s.RequestsTo("torrents/editTracker")); n != 2 {
+		t.Fatalf("got %d edits, want 2", n)
+	}
+
+	// the new url exists on bbb
+	if err := api.TorrentAddTrackers(ctx, "bbb", []string{"udp://open.example.com:81"}); err != nil {
+		t.Fatal(err)
+	}
+	cmd = TorrentTracker()
+	cmd.SetArgs([]string{"replace", ":80", ":81"})
+	if err := cmd.ExecuteContext(ctx); ExitCode(err) != ExitPartial {
+		t.Fatalf("got %v, want partial error", err)
+	}
+	if torrent, _ := s.Torrent("ccc"); torrent.Trackers[1].URL != "udp://open.example.com:81" {
+		t.Errorf("ccc tracker is %s", torrent.Trackers[1].URL)
+	}
+}
@@ -1,11 +1,12 @@
 package cmd
 
 import (
-	"errors"
+	"context"
 	"fmt"
 	"github.com/spf13/cobra"
 	"net/url"
 	"qbit-cli/internal/api"
+	"qbit-cli/pkg/utils"
 	"strconv"
 	"strings"
 )
@@ -13,8 +14,9 @@ import (
 func TorrentUpdate() *cobra.Command {
 
 	var cmd = &cobra.Command{
-		Use:   "update <hash>",
-		Short: "A bulk of torrent operations, support multiple or all torrents.",
+		Use:     "update <hash>",
+		Short:   "A bulk of torrent operations, support multiple or all torrents.",
+		Example: `qbit torrent update --where 'ratio >= 2 && seeding_time > 14d' --stop`,
 	}
 
 	var (
@@ -22,14 +24,16 @@ func TorrentUpdate() *cobra.Command {
 		stop, start, recheck, reannounce                         bool
 		increasePriority, decreasePriority                       bool
 		maximalPriority, minimalPriority                         bool
-		downloadLimit, uploadLimit                               int64
+		downloadLimit, uploadLimit                               string
 		category, tags, torrentLocation, removeTags              string
 		autoManage, forceStart, superSeeding, sequentialDownload bool
 		firstOrLastPieceFirst                                    bool
 		stopSeeding                                              bool
+		where                                                    TorrentWhere
 	)
 
 	cmd.Flags().BoolVar(&all, "all", false, "update all torrents")
+	where.RegisterFlag(cmd)
 
 	cmd.Flags().BoolVar(&stopSeeding, "stop-seeding", false, "stop all seeding torrents(can't work with other flags)")
 	cmd.Flags().BoolVar(&stop, "stop", false, "stop torrent")
@@ -43,8 +47,8 @@ func TorrentUpdate() *cobra.Command {
 	cmd.Flags().BoolVar(&maximalPriority, "maximal-priority", false, "maximal torrent priority")
 	cmd.Flags().BoolVar(&minimalPriority, "minimal-priority", false, "minimal torrent priority")
 
-	cmd.Flags().Int64Var(&downloadLimit, "download-limit", 0, "download limit(bytes)")
-	cmd.Flags().Int64Var(&uploadLimit, "upload-limit", 0, "upload limit(bytes)")
+	cmd.Flags().StringVar(&downloadLimit, "download-limit", "", "download limit per second like 512K or 4MiB, 0 means unlimited")
+	cmd.Flags().StringVar(&uploadLimit, "upload-limit", "", "upload limit per second like 512K or 4MiB, 0 means unlimited")
 
 	cmd.Flags().StringVar(&category, "category", "", "torrent category")
 	cmd.Flags().StringVar(&tags, "tags", "", "torrent tags, separated by comma")
@@ -59,22 +63,46 @@ func TorrentUpdate() *cobra.Command {
 	cmd.Flags().BoolVar(&firstOrLastPieceFirst, "first-last-first", false, "first or last piece first")
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
 		if stopSeeding {
-			stopSeedingTorrents()
-			return nil
+			return stopSeedingTorrents(ctx)
+		}
+		if err := where.Compile(); err != nil {
+			return err
 		}
 
-		hashes := strings.Join(args, "|")
-		if all {
-			hashes = "all"
-		} else {
-			if len(args) < 1 {
-				return errors.New("torrent update requires at least a hash")
+		var (
+			dlLimit, upLimit int64
+			err              error
+		)
+		if cmd.Flags().Changed("download-limit") {
+			if dlLimit, err = utils.ParseFileSize(downloadLimit); err != nil {
+				return err
 			}
 		}
+		if cmd.Flags().Changed("upload-limit") {
+			if upLimit, err = utils.ParseFileSize(uploadLimit); err != nil {
+				return err
+			}
+		}
+
+		hashes, err := selectTorrentHashes(ctx, args, all, &where)
+		if err != nil {
+			return err
+		}
+		if hashes == "" {
+			fmt.Println("no torrent matched.")
+			return nil
+		}
 
 		params := url.Values{}
 		params.Set("hashes", hashes)
+		// each operation is counted as an item
+		perr := &api.PartialError{}
+		update := func(operation string, params url.Values) {
+			perr.Total++
+			perr.Add(operation, updateTorrents(ctx, operation, params))
+		}
 
 		if stop {
 			update("stop", params)
@@ -103,10 +131,12 @@ func TorrentUpdate() *cobra.Command {
 			update("bottomPrio", params)
 		}
 
-		if downloadLimit > 0 {
+		if cmd.Flags().Changed("download-limit") {
+			params.Set("limit", strconv.FormatInt(dlLimit, 10))
 			update("setDownloadLimit", params)
 		}
-		if uploadLimit > 0 {
+		if cmd.Flags().Changed("upload-limit") {
+			params.Set("limit", strconv.FormatInt(upLimit, 10))
 			update("setUploadLimit", params)
 		}
 
@@ -144,32 +174,30 @@ func TorrentUpdate() *cobra.Command {
 			update("toggleSuperSeeding", params)
 		}
 
-		return nil
+		return perr.Err()
 	}
 
 	return cmd
 }
 
-func update(operation string, params url.Values) {
-	err := api.UpdateTorrent(operation, params)
-	if err != nil {
-		fmt.Printf("%s %s failed: %v\n", params.Get("hashes"), operation, err)
-	} else {
-		fmt.Println("done.")
+func updateTorrents(ctx context.Context, operation string, params url.Values) error {
+	if err := api.UpdateTorrent(ctx, operation, params); err != nil {
+		return err
 	}
+	fmt.Println("done.")
+	return nil
 }
 
-func stopSeedingTorrents() {
+func stopSeedingTorrents(ctx context.Context) error {
 	searchParams := url.Values{}
 	searchParams.Set("filter", "seeding")
-	torrents, err := api.TorrentList(searchParams)
+	torrents, err := api.TorrentList(ctx, searchParams)
 	if err != nil {
-		fmt.Printf("stop seeding error: %s\n", err)
-		return
+		return err
 	}
 	var hashes = make([]string, 0, len(torrents))
 	for _, torrent := range torrents {
 		hashes = append(hashes, torrent.Hash)
 	}
-	update("stop", url.Values{"hashes": []string{strings.Join(hashes, "|")}})
+	return updateTorrents(ctx, "stop", url.Values{"hashes": []string{strings.Join(hashes, "|")}})
 }
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"qbit-cli/internal/api"
+	"qbit-cli/internal/qbittest"
+	"testing"
+)
+
+func TestTorrentUpdate(t *testing.T) {
+	s := qbittest.NewServer(qbittest.Options{Username: "admin", Password: "adminadmin"})
+	defer s.Close()
+	s.Configure(t)
+	s.AddCategory("movies", "/data/movies")
+	s.AddTorrent(qbittest.Torrent{Hash: "aaa", Name: "a", Progress: 1})
+	s.AddTorrent(qbittest.Torrent{Hash: "bbb", Name: "b", Progress: 0.5})
+	s.AddTorrent(qbittest.Torrent{Hash: "ccc", Name: "c"})
+
+	cmd := TorrentUpdate()
+	cmd.SetArgs([]string{"aaa", "bbb", "--stop", "--category", "movies", "--tags", "hd,new"})
+	if err := cmd.ExecuteContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{"aaa": "stoppedUP", "bbb": "stoppedDL", "ccc": "stalledDL"}
+	for _, torrent := range s.Torrents() {
+		if torrent.State != want[torrent.Hash] {
+			t.Errorf("%s state is %s, want %s", torrent.Hash, torrent.State, want[torrent.Hash])
+		}
+		updated := torrent.Hash != "ccc"
+		if updated != (torrent.Category == "movies" && torrent.Tags == "hd, new") {
+			t.Errorf("%s category %q tags %q", torrent.Hash, torrent.Category, torrent.Tags)
+		}
+	}
+}
+
+func TestTorrentUpdatePartial(t *testing.T) {
+	s := qbittest.NewServer(qbittest.Options{})
+	defer s.Close()
+	s.Configure(t)
+	s.AddTorrent(qbittest.Torrent{Hash: "aaa", Name: "a"})
+
+	// category doesn't exist, setCategory is answered with 409 while stop is done
+	cmd := TorrentUpdate()
+	cmd.SetArgs([]string{"--all", "--stop", "--category", "missing"})
+	err := cmd.ExecuteContext(context.Background())
+	var perr *api.PartialError
+	if !errors.As(err, &perr) || !perr.Partial() {
+		t.Fatalf("got %v, want partial error", err)
+	}
+	if code := ExitCode(err); code != ExitPartial {
+		t.Fatalf("got exit code %d, want %d", code, ExitPartial)
+	}
+	if torrent, _ := s.Torrent("aaa"); torrent.State != "stoppedDL" {
+		t.Fatalf("torrent is %s, want stoppedDL", torrent.State)
+	}
+}
@@ -0,0 +1,310 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"os"
+	"path"
+	"qbit-cli/internal/api"
+	"qbit-cli/pkg/utils"
+	"slices"
+	"strings"
+	"time"
+)
+
+// conditions of torrent wait --until
+const (
+	untilMetadata   = "metadata"
+	untilDownloaded = "downloaded"
+	untilChecked    = "checked"
+	untilMoved      = "moved"
+)
+
+var WaitConditions = []string{untilMetadata, untilDownloaded, untilChecked, untilMoved}
+
+// statuses of waitStatus
+const (
+	waitWaiting   = "waiting"
+	waitCompleted = "completed"
+	waitErrored   = "errored"
+	waitRemoved   = "removed"
+	waitTimedOut  = "timeout"
+)
+
+// kinds of errors of torrent wait, they are mapped to exit codes
+var (
+	errWaitTimeout    = errors.New("timed out")
+	errTorrentErrored = errors.New("torrent errored")
+)
+
+var (
+	metadataStates = []string{"metaDL", "forcedMetaDL"}
+	checkingStates = []string{"checkingUP", "checkingDL", "checkingResumeData"}
+	erroredStates  = []string{"error", "missingFiles"}
+)
+
+// waitStatus is a torrent waited by torrent wait, it's emitted as a json line whenever it changes.
+type waitStatus struct {
+	Time     time.Time `json:"time"`
+	Hash     string    `json:"hash"`
+	Name     string    `json:"name"`
+	State    string    `json:"state"`
+	Progress float64   `json:"progress"`
+	Size     int64     `json:"size"`
+	Status   string    `json:"status"`
+	// seen is false until the torrent shows up, torrents just added may not be listed yet
+	seen bool
+	// started is true once the torrent was seen checking or moving
+	started bool
+	// idlePolls counts polls which saw the torrent neither checking, moving nor fetching metadata before it started,
+	// a fast check or move may finish between two polls
+	idlePolls int
+}
+
+func TorrentWait() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "wait [hash...]",
+		Short: "Wait until torrents have metadata, are downloaded, checked or moved",
+		Long: `Wait watches torrents until all of them reach the --until condition, use the global --timeout to give up.
+Torrents without metadata have to get it first. checked and moved complete once the torrent has been seen checking
+or moving and stopped doing it, or when it stays idle for one more --interval after it's first seen, because a fast
+check or move may finish between two polls. moved with --torrent-location completes once the torrent is saved there.
+Progress is drawn as a bar on stderr in a terminal, otherwise every change of a torrent is written to stderr
+as a json line, so that scripts can follow it. The result is printed to stdout when all torrents are done.
+The exit code tells how it ended:
+0 all completed, 4 a torrent was removed, 9 timed out, 10 a torrent errored(error or missingFiles state),
+6 some of the torrents didn't complete.`,
+		Example: `qbit torrent wait <hash> --until downloaded --timeout 6h
+qbit torrent wait --where 'category == "tv" && progress < 1' --no-progress
+qbit torrent update <hash> --torrent-location /data/done && qbit torrent wait <hash> --until moved --torrent-location /data/done
+qbit torrent wait <hash> -o json | jq -r '.[] | select(.status == "completed") | .hash'`,
+	}
+
+	var (
+		all        bool
+		where      TorrentWhere
+		interval   time.Duration
+		noProgress bool
+		location   string
+	)
+	until := FlagsProperty[string]{Flag: "until", Options: WaitConditions}
+	cmd.Flags().BoolVar(&all, "all", false, "wait for all torrents")
+	where.RegisterFlag(cmd)
+	cmd.Flags().StringVar(&until.Value, until.Flag, untilDownloaded, "condition to wait for: metadata, downloaded, checked(after checking) or moved(after moving)")
+	cmd.Flags().StringVar(&location, "torrent-location", "", "target save path of --until moved, the torrent is moved once it's saved there")
+	cmd.Flags().DurationVar(&interval, "interval", 2*time.Second, "interval of polling the server")
+	cmd.Flags().BoolVar(&noProgress, "no-progress", false, "only print the result, no progress bar or json lines")
+	until.RegisterCompletion(cmd)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		if !slices.Contains(WaitConditions, until.Value) {
+			return fmt.Errorf("unsupported --until %q, use one of %s", until.Value, strings.Join(WaitConditions, ","))
+		}
+		if location != "" && until.Value != untilMoved {
+			return errors.New("--torrent-location requires --until moved")
+		}
+		torrents, err := selectTorrents(ctx, args, all, &where)
+		if err != nil {
+			return err
+		}
+		if len(torrents) == 0 {
+			return fmt.Errorf("no torrent to wait for: %w", api.ErrNotFound)
+		}
+		hashes := make([]string, len(torrents))
+		for i, t := range torrents {
+			hashes[i] = t.Hash
+		}
+		w := newTorrentWaiter(hashes, until.Value, interval, noProgress)
+		w.location = location
+		return w.run(ctx)
+	}
+
+	return cmd
+}
+
+// torrentWaiter watches torrents through /sync/maindata until they reach the condition.
+type torrentWaiter struct {
+	until    string
+	interval time.Duration
+	// location is the target save path of moved, empty means any
+	location string
+	// bar is drawn to stderr of terminals, lines are json lines of changes written to stderr otherwise
+	bar, lines bool
+	statuses   []*waitStatus
+}
+
+func newTorrentWaiter(hashes []string, until string, interval time.Duration, noProgress bool) *torrentWaiter {
+	w := &torrentWaiter{until: until, interval: interval}
+	if !noProgress {
+		stat, err := os.Stderr.Stat()
+		w.bar = err == nil && stat.Mode()&os.ModeCharDevice != 0
+		w.lines = !w.bar
+	}
+	for _, hash := range hashes {
+		w.statuses = append(w.statuses, &waitStatus{Hash: hash, Status: waitWaiting})
+	}
+	return w
+}
+
+// run waits until no torrent is waiting or ctx is done, torrents still waiting at the deadline are timed out.
+// Every poll is applied even if nothing changed, because idle polls count for checked and moved.
+func (w *torrentWaiter) run(ctx context.Context) error {
+	sync := api.NewMainDataSync()
+	for ctx.Err() == nil {
+		if _, err := sync.Update(ctx); err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			return err
+		}
+		w.update(sync)
+		if !slices.ContainsFunc(w.statuses, func(s *waitStatus) bool { return s.Status == waitWaiting }) {
+			break
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(w.interval):
+		}
+	}
+	if err := ctx.Err(); err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	for _, s := range w.statuses {
+		if s.Status == waitWaiting {
+			w.setStatus(s, waitTimedOut)
+		}
+	}
+
+	if w.bar {
+		fmt.Fprintln(os.Stderr)
+	}
+	if err := printList(waitColumns, w.statuses); err != nil {
+		return err
+	}
+	return w.err()
+}
+
+// update applies the synced torrents to statuses, a torrent which disappears after it was seen is removed.
+func (w *torrentWaiter) update(sync *api.MainDataSync) {
+	for _, s := range w.statuses {
+		if s.Status != waitWaiting {
+			continue
+		}
+		t, ok := sync.Torrent(s.Hash)
+		if !ok {
+			if s.seen {
+				w.setStatus(s, waitRemoved)
+			}
+			continue
+		}
+		changed := !s.seen || s.State != t.State || s.Progress != t.Progress || s.Name != t.Name
+		s.seen, s.Name, s.State, s.Progress, s.Size = true, t.Name, t.State, t.Progress, t.Size
+		if slices.Contains(checkingStates, t.State) || t.State == "moving" {
+			s.started = true
+		} else if !s.started && !slices.Contains(metadataStates, t.State) {
+			s.idlePolls++
+		}
+		switch {
+		case slices.Contains(erroredStates, t.State):
+			w.setStatus(s, waitErrored)
+		case w.reached(s, &t):
+			w.setStatus(s, waitCompleted)
+		case changed:
+			w.emit(s)
+		}
+	}
+	if w.bar {
+		w.drawBar()
+	}
+}
+
+// reached reports whether the torrent meets the condition of --until. A torrent which is not checking or moving
+// may not have started yet, so that checked and moved require it to be seen doing it, or to stay idle for an interval.
+func (w *torrentWaiter) reached(s *waitStatus, t *api.Torrent) bool {
+	if !t.HasMetadata && (t.Size <= 0 || slices.Contains(metadataStates, t.State)) {
+		return false
+	}
+	switch w.until {
+	case untilMetadata:
+		return true
+	case untilDownloaded:
+		return t.Progress >= 1 && !slices.Contains(checkingStates, t.State)
+	case untilChecked:
+		return (s.started || s.idlePolls > 1) && !slices.Contains(checkingStates, t.State)
+	case untilMoved:
+		if w.location != "" {
+			return t.State != "moving" && path.Clean(t.SavePath) == path.Clean(w.location)
+		}
+		return (s.started || s.idlePolls > 1) && t.State != "moving"
+	}
+	return false
+}
+
+func (w *torrentWaiter) setStatus(s *waitStatus, status string) {
+	s.Status = status
+	w.emit(s)
+}
+
+// emit writes the status as a json line to stderr in lines mode.
+func (w *torrentWaiter) emit(s *waitStatus) {
+	s.Time = time.Now()
+	if !w.lines {
+		return
+	}
+	data, _ := json.Marshal(s)
+	fmt.Fprintln(os.Stderr, string(data))
+}
+
+// drawBar draws the bytes downloaded of torrents waiting for downloaded, or the count of finished torrents.
+func (w *torrentWaiter) drawBar() {
+	const width = 30
+	var finished int
+	var done, total float64
+	for _, s := range w.statuses {
+		if s.Status != waitWaiting {
+			finished++
+		}
+		if w.until == untilDownloaded {
+			done += s.Progress * float64(s.Size)
+			total += float64(s.Size)
+		}
+	}
+	if total == 0 {
+		done, total = float64(finished), float64(len(w.statuses))
+	}
+	ratio := done / total
+	filled := int(ratio * width)
+	fmt.Fprintf(os.Stderr, "\r[%s%s] %3.0f%% %d/%d %s", strings.Repeat("#", filled), strings.Repeat("-", width-filled),
+		ratio*100, finished, len(w.statuses), w.until)
+}
+
+// err returns a PartialError of torrents which didn't complete.
+func (w *torrentWaiter) err() error {
+	perr := &api.PartialError{Total: len(w.statuses)}
+	for _, s := range w.statuses {
+		switch s.Status {
+		case waitErrored:
+			perr.Add(s.Hash, fmt.Errorf("%w: %s", errTorrentErrored, s.State))
+		case waitRemoved:
+			perr.Add(s.Hash, fmt.Errorf("removed: %w", api.ErrNotFound))
+		case waitTimedOut:
+			perr.Add(s.Hash, fmt.Errorf("%w waiting for %s", errWaitTimeout, w.until))
+		}
+	}
+	return perr.Err()
+}
+
+var waitColumns = []utils.Column[*waitStatus]{
+	{Name: "status", Value: func(s *waitStatus) any { return s.Status }},
+	{Name: "hash", Value: func(s *waitStatus) any { return s.Hash }},
+	{Name: "name", Value: func(s *waitStatus) any { return s.Name }, Width: 40},
+	{Name: "state", Value: func(s *waitStatus) any { return s.State }},
+	{Name: "progress", Header: "PROG", Value: func(s *waitStatus) any { return s.Progress },
+		Text: func(s *waitStatus) string { return utils.FormatPercent(s.Progress) }, Width: 6},
+	{Name: "size", Value: func(s *waitStatus) any { return s.Size }, Hidden: true,
+		Text: func(s *waitStatus) string { return utils.FormatFileSizeAuto(uint64(s.Size), 1) }},
+}
@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"path/filepath"
+	"qbit-cli/internal/api"
+	"qbit-cli/internal/qbittest"
+	"qbit-cli/pkg/metainfo"
+	"testing"
+	"time"
+)
+
+// waitFor runs torrent wait with args, change is called once the torrents are synced.
+func waitFor(t *testing.T, s *qbittest.Server, timeout time.Duration, change func(), args ...string) error {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	done := make(chan error, 1)
+	synced := len(s.RequestsTo("sync/maindata"))
+	go func() {
+		cmd := TorrentWait()
+		cmd.SetArgs(append([]string{"--interval", "10ms"}, args...))
+		done <- cmd.ExecuteContext(ctx)
+	}()
+	for len(s.RequestsTo("sync/maindata")) == synced {
+		select {
+		case err := <-done:
+			return err
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+	change()
+	return <-done
+}
+
+func TestTorrentWait(t *testing.T) {
+	s := qbittest.NewServer(qbittest.Options{})
+	defer s.Close()
+	s.Configure(t)
+	s.AddTorrent(qbittest.Torrent{Hash: "aaa", Name: "a", Size: 100, Progress: 0.5})
+	s.AddTorrent(qbittest.Torrent{Hash: "bbb", Name: "b", Size: 300})
+	s.AddTorrent(qbittest.Torrent{Hash: "ccc", Name: "c", Size: 200})
+
+	// bbb is being checked after download, so that it's not done yet
+	err := waitFor(t, s, 5*time.Second, func() {
+		s.UpdateTorrent("aaa", func(t *qbittest.Torrent) { t.Progress, t.State = 1, "stalledUP" })
+		s.UpdateTorrent("bbb", func(t *qbittest.Torrent) { t.Progress, t.State = 1, "checkingUP" })
+		time.Sleep(50 * time.Millisecond)
+		s.UpdateTorrent("bbb", func(t *qbittest.Torrent) { t.State = "stalledUP" })
+	}, "aaa", "bbb")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = waitFor(t, s, 200*time.Millisecond, func() {}, "aaa", "ccc")
+	if ExitCode(err) != ExitPartial {
+		t.Fatalf("got %v, want partial error", err)
+	}
+	err = waitFor(t, s, 200*time.Millisecond, func() {}, "ccc")
+	if ExitCode(err) != ExitTimeout {
+		t.Fatalf("got %v, want timeout", err)
+	}
+
+	// the check of ccc finished before the first poll, it's checked after staying idle for an interval
+	start := time.Now()
+	err = waitFor(t, s, 5*time.Second, func() {}, "ccc", "--until", "checked", "--interval", "100ms")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Fatalf("checked after %s, want after an interval of grace", elapsed)
+	}
+	err = waitFor(t, s, 5*time.Second, func() {
+		s.UpdateTorrent("ccc", func(t *qbittest.Torrent) { t.State = "checkingDL" })
+		time.Sleep(50 * time.Millisecond)
+		s.UpdateTorrent("ccc", func(t *qbittest.Torrent) { t.State = "stalledDL" })
+	}, "ccc", "--until", "checked")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = waitFor(t, s, 5*time.Second, func() {
+		s.UpdateTorrent("ccc", func(t *qbittest.Torrent) { t.SavePath = "/data/done/" })
+	}, "ccc", "--until", "moved", "--torrent-location", "/data/done")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.UpdateTorrent("ccc", func(t *qbittest.Torrent) { t.State = "checkingDL" })
+	err = waitFor(t, s, 5*time.Second, func() {
+		s.UpdateTorrent("ccc", func(t *qbittest.Torrent) { t.State = "missingFiles" })
+	}, "ccc", "--until", "checked")
+	if ExitCode(err) != ExitTorrentError {
+		t.Fatalf("got %v, want torrent error", err)
+	}
+
+	s.UpdateTorrent("ccc", func(t *qbittest.Torrent) { t.State = "moving" })
+	err = waitFor(t, s, 5*time.Second, func() {
+		params := url.Values{"hashes": {"ccc"}, "deleteFiles": {"false"}}
+		if err := api.UpdateTorrent(context.Background(), "delete", params); err != nil {
+			t.Error(err)
+		}
+	}, "ccc", "--until", "moved")
+	if ExitCode(err) != ExitNotFound {
+		t.Fatalf("got %v, want not found", err)
+	}
+}
+
+func TestTorrentAddWait(t *testing.T) {
+	s := qbittest.NewServer(qbittest.Options{})
+	defer s.Close()
+	s.Configure(t)
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "file"), make([]byte, 1000), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	created, err := metainfo.Create(metainfo.CreateOptions{Path: filepath.Join(dir, "file")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	torrentFile := filepath.Join(dir, "file.torrent")
+	if err := os.WriteFile(torrentFile, created.Data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// a magnet never gets metadata from the fake server
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	add := TorrentAdd()
+	add.SetArgs([]string{"magnet:?xt=urn:btih:4444444444444444444444444444444444444444&dn=d", "--wait", "--until", "metadata"})
+	if err := add.ExecuteContext(ctx); ExitCode(err) != ExitTimeout {
+		t.Fatalf("got %v, want timeout", err)
+	}
+
+	add = TorrentAdd()
+	add.SetArgs([]string{torrentFile, "--wait", "--until", "metadata"})
+	if err := add.ExecuteContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
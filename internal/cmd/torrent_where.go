@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"net/url"
+	"qbit-cli/internal/api"
+	"qbit-cli/pkg/expr"
+	"strings"
+)
+
+// TorrentWhere is the --where flag shared by commands selecting torrents,
+// the expression is evaluated client side over all fields of /torrents/info.
+type TorrentWhere struct {
+	Value string
+	expr  *expr.Expr
+}
+
+func (w *TorrentWhere) RegisterFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&w.Value, "where", "",
+		`filter expression over torrent fields, e.g. 'ratio >= 2 && seeding_time > 14d && !tags.contains("keep")'`)
+}
+
+// Compile parses the expression, it should be called before any request so that typos fail fast.
+func (w *TorrentWhere) Compile() error {
+	if w.Value == "" || w.expr != nil {
+		return nil
+	}
+	e, err := expr.Compile(w.Value, api.TorrentFieldNames())
+	if err != nil {
+		return err
+	}
+	w.expr = e
+	return nil
+}
+
+func (w *TorrentWhere) Enabled() bool {
+	return w.Value != ""
+}
+
+func (w *TorrentWhere) Match(t *api.Torrent) (bool, error) {
+	if !w.Enabled() {
+		return true, nil
+	}
+	if err := w.Compile(); err != nil {
+		return false, err
+	}
+	return w.expr.Match(t.Field)
+}
+
+// Filter returns torrents matching the expression.
+func (w *TorrentWhere) Filter(torrents []api.Torrent) ([]api.Torrent, error) {
+	if !w.Enabled() {
+		return torrents, nil
+	}
+	matched := make([]api.Torrent, 0, len(torrents))
+	for i := range torrents {
+		ok, err := w.Match(&torrents[i])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", torrents[i].Hash, err)
+		}
+		if ok {
+			matched = append(matched, torrents[i])
+		}
+	}
+	return matched, nil
+}
+
+// selectTorrentHashes returns the hashes param of torrent actions by hash args, --all and --where,
+// where filters torrents of args or all torrents. Empty hashes means no torrent matched.
+func selectTorrentHashes(ctx context.Context, args []string, all bool, where *TorrentWhere) (string, error) {
+	if !where.Enabled() {
+		if all {
+			return "all", nil
+		}
+		if len(args) < 1 {
+			return "", errors.New("requires at least a hash, --all or --where")
+		}
+		return strings.Join(args, "|"), nil
+	}
+	torrents, err := selectTorrents(ctx, args, all, where)
+	if err != nil {
+		return "", err
+	}
+	hashes := make([]string, len(torrents))
+	for i, t := range torrents {
+		hashes[i] = t.Hash
+	}
+	return strings.Join(hashes, "|"), nil
+}
+
+// selectTorrents lists torrents of hash args, or all torrents if --all or --where is set, and filters them by where.
+func selectTorrents(ctx context.Context, args []string, all bool, where *TorrentWhere) ([]api.Torrent, error) {
+	if !all && !where.Enabled() && len(args) < 1 {
+		return nil, errors.New("requires at least a hash, --all or --where")
+	}
+	if err := where.Compile(); err != nil {
+		return nil, err
+	}
+	params := url.Values{}
+	if len(args) > 0 && !all {
+		params.Set("hashes", strings.Join(args, "|"))
+	}
+	torrents, err := api.TorrentList(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	return where.Filter(torrents)
+}
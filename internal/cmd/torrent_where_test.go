@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"context"
+	"qbit-cli/internal/qbittest"
+	"testing"
+)
+
+func TestDeleteWhere(t *testing.T) {
+	s := qbittest.NewServer(qbittest.Options{})
+	defer s.Close()
+	s.Configure(t)
+	s.AddTorrent(qbittest.Torrent{Hash: "aaa", Name: "a", Category: "movie", Ratio: 3, Progress: 1})
+	s.AddTorrent(qbittest.Torrent{Hash: "bbb", Name: "b", Category: "movie", Ratio: 3, Progress: 1, Tags: "keep"})
+	s.AddTorrent(qbittest.Torrent{Hash: "ccc", Name: "c", Category: "tv", Ratio: 3, Progress: 1})
+
+	cmd := DeleteTorrents()
+	cmd.SetArgs([]string{"--where", `ratio >= 2 && category == "movie" && !tags.contains("keep")`})
+	if err := cmd.ExecuteContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.Torrent("aaa"); ok {
+		t.Error("aaa should be deleted")
+	}
+	if n := len(s.Torrents()); n != 2 {
+		t.Errorf("got %d torrents, want 2", n)
+	}
+	requests := s.RequestsTo("torrents/delete")
+	if len(requests) != 1 || requests[0].Form.Get("hashes") != "aaa" || requests[0].Form.Get("deleteFiles") != "false" {
+		t.Fatalf("unexpected delete requests %+v", requests)
+	}
+
+	// nothing matched, no request is sent
+	cmd = DeleteTorrents()
+	cmd.SetArgs([]string{"--where", `ratio > 5`})
+	if err := cmd.ExecuteContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(s.RequestsTo("torrents/delete")); n != 1 {
+		t.Fatalf("got %d delete requests, want 1", n)
+	}
+}
@@ -1,12 +1,19 @@
 package cmd
 
 import (
+	"cmp"
 	"errors"
+	"fmt"
 	"github.com/spf13/cobra"
 	"net/url"
+	"os"
 	"qbit-cli/internal/api"
 	"qbit-cli/internal/config"
+	"qbit-cli/pkg/utils"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 )
 
 func TorrentAdd() *cobra.Command {
@@ -18,6 +25,13 @@ Attention: auto management is enabled by default, so make sure your qBittorrent
 This method can add torrents from server local file or from URLs.
 http://, https://, magnet: and bc://bt/ links are supported.
 You can add torrent like: add /t/xx.torrent "magnet:xxx"
+Info hashes of magnet links and local .torrent files are compared with existing torrents first,
+torrents which are already present are skipped, or their trackers are merged by --on-duplicate merge.
+Torrents of known size(magnet links with xl, local .torrent files) which would leave less free space on the server
+disk than --free-space-reserve are refused, or added with a warning by --on-low-space warn|pause.
+--wait waits for the added torrents like torrent wait and prints the wait result instead of the add results,
+sources which failed are reported after waiting. Torrents added by http urls can't be waited for because their
+hashes are unknown.
 `,
 		Args: func(cmd *cobra.Command, args []string) error {
 			if len(args) < 1 {
@@ -31,18 +45,49 @@ You can add torrent like: add /t/xx.torrent "magnet:xxx"
 		tags     string
 		autoTMM  bool
 		savePath string
+		reserve  string
+		wait     bool
 	)
 	category := FlagsProperty[string]{Flag: "category", Register: &TorrentCategoryFlagRegister{}}
+	onDuplicate := FlagsProperty[string]{Flag: "on-duplicate", Options: api.DuplicateActions}
+	onLowSpace := FlagsProperty[string]{Flag: "on-low-space", Options: api.LowSpaceActions}
+	until := FlagsProperty[string]{Flag: "until", Options: WaitConditions}
 
 	addCmd.Flags().StringVar(&category.Value, category.Flag, "", "torrent category")
 	addCmd.Flags().StringVar(&tags, "tags", "", "torrent tags split by ','")
 	addCmd.Flags().BoolVar(&autoTMM, "auto-manage", true, "Whether Automatic Torrent Management should be used, default is true")
 	addCmd.Flags().StringVar(&savePath, "save-path", "", "torrent save path")
+	addCmd.Flags().StringVar(&onDuplicate.Value, onDuplicate.Flag, string(api.DuplicateSkip), "what to do with torrents which already exist: skip or merge(add new trackers)")
+	addCmd.Flags().StringVar(&reserve, "free-space-reserve", "", "free space to leave on server disk, e.g. 50GB, default is free-space-reserve of config")
+	addCmd.Flags().StringVar(&onLowSpace.Value, onLowSpace.Flag, "", "what to do when free space is below the reserve: refuse, warn, pause(add stopped) or ignore, default is low-space-action of config")
+
+	addCmd.Flags().BoolVar(&wait, "wait", false, "wait until the added torrents reach the --until condition")
+	addCmd.Flags().StringVar(&until.Value, until.Flag, untilDownloaded, "condition of --wait: metadata, downloaded, checked or moved")
 
 	// register completion
 	category.RegisterCompletion(addCmd)
+	onDuplicate.RegisterCompletion(addCmd)
+	onLowSpace.RegisterCompletion(addCmd)
+	until.RegisterCompletion(addCmd)
 
 	addCmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		if !slices.Contains(api.DuplicateActions, onDuplicate.Value) {
+			return fmt.Errorf("unsupported --on-duplicate %q, use one of %s", onDuplicate.Value, strings.Join(api.DuplicateActions, ","))
+		}
+		if wait && !slices.Contains(WaitConditions, until.Value) {
+			return fmt.Errorf("unsupported --until %q, use one of %s", until.Value, strings.Join(WaitConditions, ","))
+		}
+		if onLowSpace.Value != "" && !slices.Contains(api.LowSpaceActions, onLowSpace.Value) {
+			return fmt.Errorf("unsupported --on-low-space %q, use one of %s", onLowSpace.Value, strings.Join(api.LowSpaceActions, ","))
+		}
+		var reserveSize int64
+		if reserve != "" {
+			var err error
+			if reserveSize, err = utils.ParseFileSize(reserve); err != nil {
+				return err
+			}
+		}
 		params := url.Values{
 			"autoTMM": {strconv.FormatBool(autoTMM)},
 		}
@@ -55,20 +100,59 @@ You can add torrent like: add /t/xx.torrent "magnet:xxx"
 		if savePath != "" && !autoTMM {
 			params.Add("savepath", savePath)
 		}
-		LoadTorrentAddDefault(params)
-
-		if err := api.TorrentAdd(args, params); err != nil {
+		if err := LoadTorrentAddDefault(params); err != nil {
 			return err
 		}
+		space, err := LoadSpaceGuard()
+		if err != nil {
+			return err
+		}
+		if reserve != "" {
+			space.Reserve = reserveSize
+		}
+		if onLowSpace.Value != "" {
+			space.Action = api.LowSpaceAction(onLowSpace.Value)
+		}
 
-		return nil
+		opts := api.AddOptions{OnDuplicate: api.DuplicateAction(onDuplicate.Value), Space: space}
+		results, err := api.TorrentAddChecked(ctx, args, params, opts)
+		if results == nil {
+			return err
+		}
+		if !wait || !slices.ContainsFunc(results, waitable) {
+			if printErr := PrintAddResults(results); printErr != nil {
+				return printErr
+			}
+			return err
+		}
+		var hashes []string
+		for _, r := range results {
+			if waitable(r) {
+				hashes = append(hashes, r.Hash)
+			} else if r.Hash == "" && r.Status != api.AddStatusFailed && r.Status != api.AddStatusRefused {
+				fmt.Fprintf(os.Stderr, "can't wait for %s, its hash is unknown\n", r.Source)
+			}
+		}
+		// failures of adding are reported after waiting for the others
+		if waitErr := newTorrentWaiter(hashes, until.Value, time.Second, false).run(ctx); waitErr != nil {
+			return waitErr
+		}
+		return err
 	}
 
 	return addCmd
 }
 
-func LoadTorrentAddDefault(params url.Values) {
-	cfg := config.GetConfig()
+// waitable reports whether add --wait can wait for the result, failed sources and http urls are not.
+func waitable(r api.AddResult) bool {
+	return r.Hash != "" && r.Status != api.AddStatusFailed && r.Status != api.AddStatusRefused
+}
+
+func LoadTorrentAddDefault(params url.Values) error {
+	cfg, err := config.GetProfile()
+	if err != nil {
+		return err
+	}
 	// load defaults from config file
 	if params.Get("category") == "" {
 		params.Set("category", cfg.Torrent.DefaultSaveCategory)
@@ -76,7 +160,68 @@ func LoadTorrentAddDefault(params url.Values) {
 	if params.Get("tags") == "" {
 		params.Set("tags", cfg.Torrent.DefaultSaveTags)
 	}
-	if params.Get("savepath") == "" {
+	// save path is ignored by automatic torrent management, the category decides it
+	if params.Get("savepath") == "" && params.Get("autoTMM") != "true" {
 		params.Set("savepath", cfg.Torrent.DefaultSavePath)
 	}
+	return nil
+}
+
+// LoadSpaceGuard builds the free space check of adding torrents from config, torrents are refused by default if they
+// don't fit on the server disk.
+func LoadSpaceGuard() (*api.SpaceGuard, error) {
+	cfg, err := config.GetProfile()
+	if err != nil {
+		return nil, err
+	}
+	space := &api.SpaceGuard{Action: api.LowSpaceAction(cmp.Or(cfg.Torrent.LowSpaceAction, string(api.LowSpaceRefuse)))}
+	if !slices.Contains(api.LowSpaceActions, string(space.Action)) {
+		return nil, config.NewConfigError(fmt.Sprintf("unsupported torrent.low-space-action %q, use one of %s",
+			space.Action, strings.Join(api.LowSpaceActions, ",")))
+	}
+	if cfg.Torrent.FreeSpaceReserve != "" {
+		if space.Reserve, err = utils.ParseFileSize(cfg.Torrent.FreeSpaceReserve); err != nil {
+			return nil, config.NewConfigError(fmt.Sprintf("invalid torrent.free-space-reserve: %s", err))
+		}
+	}
+	return space, nil
+}
+
+var addResultColumns = []utils.Column[api.AddResult]{
+	{Name: "status", Value: func(r api.AddResult) any { return r.Status }},
+	{Name: "hash", Value: func(r api.AddResult) any { return r.Hash }},
+	{Name: "name", Value: func(r api.AddResult) any { return r.Name }, Width: 40,
+		Text: func(r api.AddResult) string { return cmp.Or(r.Name, r.Source) }},
+	{Name: "source", Value: func(r api.AddResult) any { return r.Source }, Hidden: true},
+	{Name: "trackers", Value: func(r api.AddResult) any { return r.Trackers }, Hidden: true},
+	{Name: "size", Value: func(r api.AddResult) any { return r.Size }, Hidden: true,
+		Text: func(r api.AddResult) string { return utils.FormatFileSizeAuto(uint64(r.Size), 1) }},
+	// warnings of torrents added despite low free space are shown in the error column of table
+	{Name: "error", Value: func(r api.AddResult) any { return r.Error }, Width: 50, Wrap: true,
+		Text: func(r api.AddResult) string { return cmp.Or(r.Error, r.Warning) }},
+	{Name: "warning", Value: func(r api.AddResult) any { return r.Warning }, Hidden: true},
+}
+
+// PrintAddResults prints the results of api.TorrentAddChecked, counts of statuses are printed with table output.
+func PrintAddResults(results []api.AddResult) error {
+	if err := printList(addResultColumns, results); err != nil {
+		return err
+	}
+	if tableOutput() {
+		fmt.Println(addSummary(results))
+	}
+	return nil
+}
+
+func addSummary(results []api.AddResult) string {
+	counts := make(map[string]int)
+	for _, r := range results {
+		counts[r.Status]++
+	}
+	summary := fmt.Sprintf("added: %d, present: %d, merged: %d, failed: %d", counts[api.AddStatusAdded],
+		counts[api.AddStatusPresent], counts[api.AddStatusMerged], counts[api.AddStatusFailed])
+	if n := counts[api.AddStatusRefused]; n > 0 {
+		summary += fmt.Sprintf(", refused: %d", n)
+	}
+	return summary
 }
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"qbit-cli/internal/api"
+	"qbit-cli/internal/qbittest"
+	"qbit-cli/pkg/metainfo"
+	"testing"
+)
+
+func TestAddDuplicates(t *testing.T) {
+	s := qbittest.NewServer(qbittest.Options{})
+	defer s.Close()
+	s.Configure(t)
+	const existing = "c12fe1c06bba254a9dc9f519b335aa7c1367a88a"
+	s.AddTorrent(qbittest.Torrent{Hash: existing, Name: "ubuntu", Trackers: trackers("udp://a:80")})
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "file"), make([]byte, 1000), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	created, err := metainfo.Create(metainfo.CreateOptions{Path: filepath.Join(dir, "file")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	torrentFile := filepath.Join(dir, "file.torrent")
+	if err := os.WriteFile(torrentFile, created.Data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	invalidFile := filepath.Join(dir, "invalid.torrent")
+	if err := os.WriteFile(invalidFile, []byte("not a torrent"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	newMagnet := "magnet:?xt=urn:btih:d2474e86c95b19b8bcfdb92bc12c9d44667cfa36&dn=debian"
+	urls := []string{
+		"magnet:?xt=urn:btih:" + existing + "&tr=udp%3A%2F%2Fa%3A80&tr=udp%3A%2F%2Fb%3A80",
+		newMagnet, newMagnet, torrentFile, invalidFile,
+	}
+	results, err := api.TorrentAddChecked(context.Background(), urls, nil, api.AddOptions{OnDuplicate: api.DuplicateMerge})
+	if ExitCode(err) != ExitPartial {
+		t.Fatalf("got %v, want partial error", err)
+	}
+	want := []string{api.AddStatusMerged, api.AddStatusAdded, api.AddStatusPresent, api.AddStatusAdded, api.AddStatusFailed}
+	for i, r := range results {
+		if r.Status != want[i] {
+			t.Errorf("%s: got %s, want %s", r.Source, r.Status, want[i])
+		}
+	}
+	if results[3].Hash != created.InfoHash || results[3].Name != "file" {
+		t.Errorf("got %+v", results[3])
+	}
+	if torrent, _ := s.Torrent(existing); len(torrent.Trackers) != 3 || torrent.Trackers[2].URL != "udp://b:80" {
+		t.Errorf("trackers are not merged: %v", torrent.Trackers)
+	}
+	if n := len(s.RequestsTo("torrents/add")); n != 2 {
+		t.Errorf("got %d adds, want 2", n)
+	}
+
+	// the file is skipped on the second run
+	cmd := TorrentAdd()
+	cmd.SetArgs([]string{torrentFile})
+	if err := cmd.ExecuteContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(s.RequestsTo("torrents/add")); n != 2 {
+		t.Errorf("got %d adds, want 2", n)
+	}
+}
+
+func TestAddFreeSpace(t *testing.T) {
+	s := qbittest.NewServer(qbittest.Options{})
+	defer s.Close()
+	s.Configure(t)
+	s.SetServerState("free_space_on_disk", int64(10<<30))
+	const (
+		big   = "magnet:?xt=urn:btih:1111111111111111111111111111111111111111&dn=big&xl=6442450944"
+		large = "magnet:?xt=urn:btih:2222222222222222222222222222222222222222&dn=large&xl=3221225472"
+		// unknown size is not checked
+		unknown = "magnet:?xt=urn:btih:3333333333333333333333333333333333333333&dn=unknown"
+	)
+
+	// free space is reduced by the first one, so that large leaves less than 2GiB
+	cmd := TorrentAdd()
+	cmd.SetArgs([]string{big, large, unknown, "--free-space-reserve", "2GiB"})
+	if err := cmd.ExecuteContext(context.Background()); ExitCode(err) != ExitPartial {
+		t.Fatalf("got %v, want partial error", err)
+	}
+	for hash, want := range map[string]bool{"1111111111111111111111111111111111111111": true,
+		"2222222222222222222222222222222222222222": false, "3333333333333333333333333333333333333333": true} {
+		if _, ok := s.Torrent(hash); ok != want {
+			t.Errorf("%s added: %v, want %v", hash, ok, want)
+		}
+	}
+
+	cmd = TorrentAdd()
+	cmd.SetArgs([]string{large, "--free-space-reserve", "8GiB"})
+	if err := cmd.ExecuteContext(context.Background()); ExitCode(err) != ExitNoSpace {
+		t.Fatalf("got %v, want no space error", err)
+	}
+
+	results, err := api.TorrentAddChecked(context.Background(), []string{large}, nil,
+		api.AddOptions{Space: &api.SpaceGuard{Reserve: 8 << 30, Action: api.LowSpacePause}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Status != api.AddStatusAdded || results[0].Warning == "" {
+		t.Fatalf("got %+v", results[0])
+	}
+	if torrent, _ := s.Torrent("2222222222222222222222222222222222222222"); torrent.State != "stoppedDL" {
+		t.Errorf("large is %s, want stoppedDL", torrent.State)
+	}
+}
@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"github.com/spf13/cobra"
+	"net"
+	"qbit-cli/internal/api"
+	"qbit-cli/pkg/utils"
+)
+
+func TransferCmd() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "transfer",
+		Short: "Global transfer info, speed limits, alternative speed mode and peer bans",
+	}
+
+	cmd.AddCommand(TransferInfo())
+	cmd.AddCommand(TransferLimit())
+	cmd.AddCommand(TransferAltSpeed())
+	cmd.AddCommand(TransferBanPeers())
+
+	return cmd
+}
+
+// transferStatus is transfer info with the alternative speed mode.
+type transferStatus struct {
+	api.TransferInfo
+	AltSpeed bool `json:"alt_speed"`
+}
+
+func TransferInfo() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "info",
+		Short: "Show global speeds, session totals, DHT nodes and connection status",
+	}
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		info, err := api.GetTransferInfo(ctx)
+		if err != nil {
+			return err
+		}
+		alt, err := api.AltSpeedMode(ctx)
+		if err != nil {
+			return err
+		}
+		status := transferStatus{TransferInfo: *info, AltSpeed: alt}
+		return printDetail(status, func() {
+			data := [][]string{
+				{"connection status", status.ConnectionStatus},
+				{"dht nodes", fmt.Sprint(status.DHTNodes)},
+				{"download speed", fmt.Sprintf("%s (limit %s)", formatSpeed(status.DLInfoSpeed), formatSpeedLimit(status.DLRateLimit))},
+				{"upload speed", fmt.Sprintf("%s (limit %s)", formatSpeed(status.UPInfoSpeed), formatSpeedLimit(status.UPRateLimit))},
+				{"downloaded", utils.FormatFileSizeAuto(uint64(status.DLInfoData), 1)},
+				{"uploaded", utils.FormatFileSizeAuto(uint64(status.UPInfoData), 1)},
+				{"alternative speed", formatOnOff(status.AltSpeed)},
+			}
+			utils.PrintListWithColWidth([]string{"property", "value"}, &data, map[int]int{1: 80}, true)
+		})
+	}
+
+	return cmd
+}
+
+// transferLimits are the active global limits in bytes per second, 0 means no limit.
+type transferLimits struct {
+	DownloadLimit int64 `json:"download_limit"`
+	UploadLimit   int64 `json:"upload_limit"`
+	AltSpeed      bool  `json:"alt_speed"`
+}
+
+func TransferLimit() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:   "limit",
+		Short: "Show or set global download and upload limits",
+		Long: `Without flags the global limits are shown, --download and --upload set them.
+Speeds are sizes per second like 512K or 4MiB, 0 means unlimited.
+qBittorrent applies the limits to the alternative speed limits when alternative speed mode is on.`,
+		Example: `qbit transfer limit
+qbit transfer limit --download 10MiB --upload 2MiB
+qbit transfer limit --upload 0`,
+	}
+
+	var download, upload string
+	cmd.Flags().StringVar(&download, "download", "", "global download limit per second, 0 means unlimited")
+	cmd.Flags().StringVar(&upload, "upload", "", "global upload limit per second, 0 means unlimited")
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		if cmd.Flags().Changed("download") {
+			limit, err := utils.ParseFileSize(download)
+			if err != nil {
+				return err
+			}
+			if err := api.SetTransferDownloadLimit(ctx, limit); err != nil {
+				return err
+			}
+		}
+		if cmd.Flags().Changed("upload") {
+			limit, err := utils.ParseFileSize(upload)
+			if err != nil {
+				return err
+			}
+			if err := api.SetTransferUploadLimit(ctx, limit); err != nil {
+				return err
+			}
+		}
+
+		var (
+			limits transferLimits
+			err    error
+		)
+		if limits.DownloadLimit, err = api.TransferDownloadLimit(ctx); err != nil {
+			return err
+		}
+		if limits.UploadLimit, err = api.TransferUploadLimit(ctx); err != nil {
+			return err
+		}
+		if limits.AltSpeed, err = api.AltSpeedMode(ctx); err != nil {
+			return err
+		}
+		return printDetail(limits, func() {
+			data := [][]string{
+				{"download limit", formatSpeedLimit(limits.DownloadLimit)},
+				{"upload limit", formatSpeedLimit(limits.UploadLimit)},
+				{"alternative speed", formatOnOff(limits.AltSpeed)},
+			}
+			utils.PrintListWithColWidth([]string{"property", "value"}, &data, map[int]int{1: 80}, true)
+		})
+	}
+
+	return cmd
+}
+
+func TransferAltSpeed() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:       "alt-speed [on|off|toggle]",
+		Short:     "Show, switch or toggle alternative speed mode",
+		Example:   `qbit transfer alt-speed on`,
+		ValidArgs: []string{"on", "off", "toggle"},
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 1 {
+				return errors.New("requires at most one of on, off or toggle")
+			}
+			return cobra.OnlyValidArgs(cmd, args)
+		},
+	}
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		var err error
+		if len(args) == 1 {
+			switch args[0] {
+			case "on":
+				err = api.SetAltSpeedMode(ctx, true)
+			case "off":
+				err = api.SetAltSpeedMode(ctx, false)
+			case "toggle":
+				err = api.ToggleAltSpeedMode(ctx)
+			}
+		}
+		if err != nil {
+			return err
+		}
+		alt, err := api.AltSpeedMode(ctx)
+		if err != nil {
+			return err
+		}
+		return printDetail(map[string]bool{"alt_speed": alt}, func() {
+			fmt.Printf("alternative speed: %s\n", formatOnOff(alt))
+		})
+	}
+
+	return cmd
+}
+
+func TransferBanPeers() *cobra.Command {
+	var cmd = &cobra.Command{
+		Use:     "ban-peers <host:port>...",
+		Short:   "Ban peers permanently",
+		Example: `qbit transfer ban-peers 203.0.113.7:6881 [2001:db8::1]:51413`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) < 1 {
+				return errors.New("requires at least one peer of host:port")
+			}
+			for _, peer := range args {
+				if _, _, err := net.SplitHostPort(peer); err != nil {
+					return fmt.Errorf("invalid peer %q, use host:port", peer)
+				}
+			}
+			return nil
+		},
+	}
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		return api.BanPeers(cmd.Context(), args)
+	}
+
+	return cmd
+}
+
+func formatOnOff(on bool) string {
+	if on {
+		return "on"
+	}
+	return "off"
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"context"
+	"qbit-cli/internal/api"
+	"qbit-cli/internal/qbittest"
+	"slices"
+	"testing"
+)
+
+func TestTransfer(t *testing.T) {
+	s := qbittest.NewServer(qbittest.Options{})
+	defer s.Close()
+	s.Configure(t)
+	ctx := context.Background()
+
+	limit := TransferLimit()
+	limit.SetArgs([]string{"--download", "10MiB", "--upload", "2MiB"})
+	if err := limit.ExecuteContext(ctx); err != nil {
+		t.Fatal(err)
+	}
+	info, err := api.GetTransferInfo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.DLRateLimit != 10<<20 || info.UPRateLimit != 2<<20 || info.ConnectionStatus != "connected" {
+		t.Fatalf("got transfer info %+v", info)
+	}
+
+	// on is idempotent, the limits are the alternative ones while it's on
+	for range 2 {
+		alt := TransferAltSpeed()
+		alt.SetArgs([]string{"on"})
+		if err := alt.ExecuteContext(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := len(s.RequestsTo("transfer/toggleSpeedLimitsMode")); n != 1 {
+		t.Fatalf("got %d toggles, want 1", n)
+	}
+	if dl, err := api.TransferDownloadLimit(ctx); err != nil || dl != 10240 {
+		t.Fatalf("got alternative download limit %d, %v", dl, err)
+	}
+	alt := TransferAltSpeed()
+	alt.SetArgs([]string{"toggle"})
+	if err := alt.ExecuteContext(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if on, err := api.AltSpeedMode(ctx); err != nil || on {
+		t.Fatalf("alternative speed is %v, %v after toggle", on, err)
+	}
+
+	ban := TransferBanPeers()
+	ban.SetArgs([]string{"203.0.113.7:6881", "[2001:db8::1]:51413"})
+	if err := ban.ExecuteContext(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if banned := s.BannedPeers(); !slices.Equal(banned, []string{"203.0.113.7", "2001:db8::1"}) {
+		t.Fatalf("got banned peers %v", banned)
+	}
+	ban = TransferBanPeers()
+	ban.SetArgs([]string{"203.0.113.7"})
+	if err := ban.ExecuteContext(ctx); err == nil {
+		t.Fatal("peer without port is accepted")
+	}
+}
@@ -1,8 +1,12 @@
 package config
 
 import (
+	"bytes"
+	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -10,35 +14,164 @@ import (
 var (
 	CfgPath string
 	config  *Config
+	loadErr error
 	Debug   bool
+	// ProfileName is set by --profile flag, it overrides the profile selected in config file
+	ProfileName string
+	// RecordDir and ReplayDir are set by --record and --replay flags
+	RecordDir, ReplayDir string
 )
 
-type Config struct {
-	Server struct {
-		Host     string `yaml:"host" validate:"required"`
-		Username string `yaml:"username"`
-		Password string `yaml:"password"`
-		Token    string `yaml:"token"`
-	} `yaml:"server"`
-
-	Torrent struct {
-		DefaultSaveCategory string `yaml:"default-save-category"`
-		DefaultSaveTags     string `yaml:"default-save-tags"`
-		DefaultSavePath     string `yaml:"default-save-path"`
-		DefaultSearchPlugin string `yaml:"default-search-plugin"`
-	} `yaml:"torrent"`
-
-	Jackett struct {
-		Host   string `yaml:"host" validate:"required"`
-		ApiKey string `yaml:"api-key"`
-		Cookie string `yaml:"cookie"`
-	} `yaml:"jackett"`
-
-	Emby struct {
-		Host   string `yaml:"host"`
-		ApiKey string `yaml:"api-key"`
-		User   string `yaml:"user"`
+// ConfigError is returned when config file can't be loaded or a required value is missing.
+type ConfigError struct {
+	Path    string
+	message string
+	err     error
+}
+
+func NewConfigError(message string) *ConfigError {
+	return &ConfigError{Path: CfgPath, message: message}
+}
+
+func (e *ConfigError) Error() string {
+	msg := "config error"
+	if e.Path != "" {
+		msg += " " + e.Path
+	}
+	if e.message != "" {
+		msg += ": " + e.message
+	}
+	if e.err != nil {
+		msg += ": " + e.err.Error()
+	}
+	return msg
+}
+
+func (e *ConfigError) Unwrap() error {
+	return e.err
+}
+
+// DefaultProfile is the name of profile defined by top level server, torrent, jackett and emby blocks
+const DefaultProfile = "default"
+
+// TransportConfig is the TLS and proxy settings of a service.
+type TransportConfig struct {
+	// CAFile is a PEM bundle trusted in addition to system roots
+	CAFile             string `yaml:"ca-file"`
+	InsecureSkipVerify bool   `yaml:"insecure-skip-verify"`
+	// ClientCert and ClientKey are PEM files for mutual TLS
+	ClientCert string `yaml:"client-cert"`
+	ClientKey  string `yaml:"client-key"`
+	// Proxy is http://, https:// or socks5:// url, environment proxy is used if empty
+	Proxy string `yaml:"proxy"`
+}
+
+type ServerConfig struct {
+	Host            string `yaml:"host" validate:"required"`
+	Username        string `yaml:"username"`
+	Password        string `yaml:"password"`
+	Token           string `yaml:"token"`
+	TransportConfig `yaml:",inline"`
+}
+
+type TorrentConfig struct {
+	DefaultSaveCategory string `yaml:"default-save-category"`
+	DefaultSaveTags     string `yaml:"default-save-tags"`
+	DefaultSavePath     string `yaml:"default-save-path"`
+	DefaultSearchPlugin string `yaml:"default-search-plugin"`
+	// FreeSpaceReserve is the free space which must be left on the server disk after adding a torrent, e.g. 50GB
+	FreeSpaceReserve string `yaml:"free-space-reserve"`
+	// LowSpaceAction is refuse(default), warn, pause or ignore
+	LowSpaceAction string `yaml:"low-space-action"`
+}
+
+type JackettConfig struct {
+	Host            string `yaml:"host" validate:"required"`
+	ApiKey          string `yaml:"api-key"`
+	Cookie          string `yaml:"cookie"`
+	TransportConfig `yaml:",inline"`
+}
+
+type EmbyConfig struct {
+	Host            string `yaml:"host"`
+	ApiKey          string `yaml:"api-key"`
+	User            string `yaml:"user"`
+	TransportConfig `yaml:",inline"`
+}
+
+// HttpConfig configures retry and rate limit of all HTTP clients.
+type HttpConfig struct {
+	// MaxRetry defaults to 3, set to -1 to disable retry
+	MaxRetry     int           `yaml:"max-retry"`
+	RetryWait    time.Duration `yaml:"retry-wait"`
+	MaxRetryWait time.Duration `yaml:"max-retry-wait"`
+	// RateLimit is max requests per second per host, 0 means no limit
+	RateLimit float64 `yaml:"rate-limit"`
+	// Timeout of a request to qBittorrent and Emby including retries, defaults to 10s, -1 means no timeout
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// Profile is a group of qBittorrent, Jackett and Emby servers.
+type Profile struct {
+	Server  ServerConfig  `yaml:"server"`
+	Torrent TorrentConfig `yaml:"torrent"`
+	Jackett JackettConfig `yaml:"jackett"`
+	Emby    EmbyConfig    `yaml:"emby"`
+}
+
+// PolicyConfig is the seeding policy of policy plan and apply, rules are evaluated in order.
+type PolicyConfig struct {
+	// AuditLog is the file actions of policy apply are appended to, policy-audit.log next to config file if empty
+	AuditLog string       `yaml:"audit-log"`
+	Rules    []PolicyRule `yaml:"rules"`
+}
+
+// PolicyRule applies Action to torrents selected by Match once When is true, empty expressions match all torrents.
+type PolicyRule struct {
+	Name   string `yaml:"name"`
+	Match  string `yaml:"match"`
+	When   string `yaml:"when"`
+	Action string `yaml:"action"`
+}
+
+// LoadPolicy reads the policy block of config file, or a separate policy file if path is set.
+func LoadPolicy(path string) (*PolicyConfig, error) {
+	if path == "" {
+		cfg, err := Load()
+		if err != nil {
+			return nil, err
+		}
+		policy := cfg.Policy
+		if policy.AuditLog == "" && CfgPath != "" {
+			policy.AuditLog = filepath.Join(filepath.Dir(CfgPath), "policy-audit.log")
+		}
+		return &policy, nil
+	}
+	file, err := os.ReadFile(path)
+	if err != nil {
+		return nil, &ConfigError{Path: path, err: err}
 	}
+	var policy PolicyConfig
+	if err := yaml.Unmarshal(file, &policy); err != nil {
+		return nil, &ConfigError{Path: path, err: err}
+	}
+	if policy.AuditLog == "" {
+		policy.AuditLog = filepath.Join(filepath.Dir(path), "policy-audit.log")
+	}
+	return &policy, nil
+}
+
+type Config struct {
+	// top level blocks are the default profile
+	Profile `yaml:",inline"`
+
+	// CurrentProfile is the profile used when --profile flag is not set
+	CurrentProfile string              `yaml:"profile"`
+	Profiles       map[string]*Profile `yaml:"profiles"`
+
+	Http HttpConfig `yaml:"http"`
+
+	Policy PolicyConfig `yaml:"policy"`
 
 	NeteaseMusicCookie string `yaml:"netease_music_cookie"`
 	QQMusicCookie      string `yaml:"qq_music_cookie"`
@@ -70,27 +203,153 @@ func (c *Config) ConfigPath() string {
 	return CfgPath
 }
 
+// ActiveProfileName returns profile name selected by --profile flag, then profile in config file.
+func (c *Config) ActiveProfileName() string {
+	if ProfileName != "" {
+		return ProfileName
+	}
+	if c.CurrentProfile != "" {
+		return c.CurrentProfile
+	}
+	return DefaultProfile
+}
+
+// ProfileNames returns all profile names sorted, default profile first.
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles)+1)
+	for name := range c.Profiles {
+		if name != DefaultProfile {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return append([]string{DefaultProfile}, names...)
+}
+
+// GetProfile returns profile by name.
+// Torrent, Jackett and Emby blocks omitted by a named profile are inherited from the default profile.
+func (c *Config) GetProfile(name string) (*Profile, error) {
+	if name == DefaultProfile {
+		if p := c.Profiles[DefaultProfile]; p != nil {
+			return p, nil
+		}
+		return &c.Profile, nil
+	}
+	p := c.Profiles[name]
+	if p == nil {
+		return nil, NewConfigError(fmt.Sprintf("profile %s not found", name))
+	}
+	profile := *p
+	if profile.Torrent == (TorrentConfig{}) {
+		profile.Torrent = c.Torrent
+	}
+	if profile.Jackett == (JackettConfig{}) {
+		profile.Jackett = c.Jackett
+	}
+	if profile.Emby == (EmbyConfig{}) {
+		profile.Emby = c.Emby
+	}
+	return &profile, nil
+}
+
+// GetProfile returns the active profile.
+func GetProfile() (*Profile, error) {
+	cfg, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	return cfg.GetProfile(cfg.ActiveProfileName())
+}
+
+// UseProfile saves the profile name to config file as the current profile.
+// Config file is edited as yaml node to keep comments and orders.
+func (c *Config) UseProfile(name string) error {
+	if _, err := c.GetProfile(name); err != nil {
+		return err
+	}
+	file, err := os.ReadFile(CfgPath)
+	if err != nil {
+		return err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(file, &doc); err != nil {
+		return err
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return NewConfigError("not a valid config file")
+	}
+	root := doc.Content[0]
+	found := false
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "profile" {
+			root.Content[i+1].SetString(name)
+			found = true
+			break
+		}
+	}
+	if !found {
+		key := &yaml.Node{}
+		key.SetString("profile")
+		value := &yaml.Node{}
+		value.SetString(name)
+		root.Content = append([]*yaml.Node{key, value}, root.Content...)
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return err
+	}
+	if err := os.WriteFile(CfgPath, buf.Bytes(), 0600); err != nil {
+		return err
+	}
+	c.CurrentProfile = name
+	return nil
+}
+
+// GetConfig returns the loaded config, an empty config is returned if load failed.
+// Use Load if the error matters.
 func GetConfig() *Config {
-	if config != nil {
-		return config
+	cfg, err := Load()
+	if err != nil {
+		return &Config{}
+	}
+	return cfg
+}
+
+// Set replaces the loaded config, nil resets it so that the next Load reads the file again. It is used by tests.
+func Set(cfg *Config) {
+	config = cfg
+	loadErr = nil
+}
+
+// Load loads config file once, following calls return the same result.
+func Load() (*Config, error) {
+	if config != nil || loadErr != nil {
+		return config, loadErr
 	}
 	var file []byte
 	if CfgPath == "" {
 		file = loadDefaultConfig()
 		if file == nil {
-			panic("default config file load failed")
+			loadErr = &ConfigError{message: "default config file load failed, use --config to specify one"}
+			return nil, loadErr
 		}
 	} else {
 		f, err := os.ReadFile(CfgPath)
 		if err != nil {
-			panic(err.Error())
+			loadErr = &ConfigError{Path: CfgPath, err: err}
+			return nil, loadErr
 		}
 		file = f
 	}
 
 	cfg := Config{}
 	if err := yaml.Unmarshal(file, &cfg); err != nil {
-		panic(err.Error())
+		loadErr = &ConfigError{Path: CfgPath, err: err}
+		return nil, loadErr
 	}
-	return &cfg
+	config = &cfg
+	return config, nil
 }
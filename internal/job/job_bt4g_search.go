@@ -2,6 +2,7 @@ package job
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -38,11 +39,12 @@ func (r *BT4G) Tags() []string {
 }
 
 func init() {
-	api.RegisterJob(&BT4G{
-		client: &http.Client{
-			Timeout: time.Second * 180,
-		},
-	})
+	api.RegisterJob(&BT4G{})
+}
+
+// flaresolverr may take minutes to solve the challenge
+func newBT4GHttpClient() *http.Client {
+	return api.NewHttpClient(time.Second * 180)
 }
 
 var categories = strings.Split("all,movie,audio,doc,app,other", ",")
@@ -72,10 +74,11 @@ func (r *BT4G) RunCommand() *cobra.Command {
 	runCmd.Flags().StringVar(&saveTags, "save-tags", "", "torrent save tags, valid only when auto download enabled")
 
 	runCmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		r.client = newBT4GHttpClient()
 		result, err := r.sendRequest(fmt.Sprintf("%s/search?q=%s&category=%s&orderby=%s", bt4gUrl, args[0], category.Value, sort.Value))
 		if err != nil {
-			fmt.Println(err.Error())
-			return nil
+			return err
 		}
 
 		printList := parseList(result)
@@ -90,6 +93,7 @@ func (r *BT4G) RunCommand() *cobra.Command {
 			data = append(data, []string{item.Title, item.CreateTime, item.Size, item.Leecher, item.Seeder})
 		}
 		bt4gC := &bt4gIConfig{
+			ctx:         ctx,
 			data:        printList,
 			currentPage: 1, bt4g: r, pages: printList[0].Pages,
 			keyword: args[0], category: category.Value, orderBy: sort.Value,
@@ -103,7 +107,7 @@ func (r *BT4G) RunCommand() *cobra.Command {
 			Delegate:     bt4gC,
 			DataDelegate: bt4gC,
 		}
-		if _, e := tea.NewProgram(&model, tea.WithAltScreen()).Run(); e != nil {
+		if _, e := tea.NewProgram(&model, tea.WithAltScreen(), tea.WithContext(ctx)).Run(); e != nil {
 			return e
 		}
 
@@ -114,6 +118,8 @@ func (r *BT4G) RunCommand() *cobra.Command {
 }
 
 type bt4gIConfig struct {
+	ctx context.Context
+
 	autoMM                           bool
 	savePath, saveCategory, saveTags string
 
@@ -159,13 +165,13 @@ func (b *bt4gIConfig) Operation(msg tea.KeyMsg, cursor int) *utils.KeyMsgDelegat
 		if b.data == nil || cursor >= len(b.data) {
 			return nil
 		}
-		magnet := b.bt4g.download(b.data[cursor].Url)
+		magnet, err := b.bt4g.download(b.data[cursor].Url)
 
 		str := ""
-		if magnet != "" {
-			str = cmd.InteractiveDownload([]string{magnet}, b.savePath, b.saveCategory, b.saveTags, b.autoMM)
+		if err == nil {
+			str = cmd.InteractiveDownload(b.ctx, []string{magnet}, nil, b.savePath, b.saveCategory, b.saveTags, b.autoMM)
 		} else {
-			str = "download failed from bt4g"
+			str = "download failed from bt4g: " + err.Error()
 		}
 
 		return &utils.KeyMsgDelegateModel{
@@ -194,25 +200,25 @@ func (b *bt4gIConfig) Operation(msg tea.KeyMsg, cursor int) *utils.KeyMsgDelegat
 	return nil
 }
 
-func (r *BT4G) download(hash string) string {
+// download returns the magnet of the torrent page.
+func (r *BT4G) download(hash string) (string, error) {
 	result, err := r.sendRequest(fmt.Sprintf("%s%s", bt4gUrl, hash))
 	if err != nil {
-		fmt.Println(err.Error())
-		return ""
+		return "", err
 	}
 
 	doc, err := goquery.NewDocumentFromReader(bytes.NewBufferString(result))
 	if err != nil {
-		return ""
+		return "", err
 	}
 	url, _ := doc.Find("a.btn.btn-info.me-2").Attr("href")
 
 	matches := magnetRegex.FindStringSubmatch(url)
 	if len(matches) > 1 {
 		magnet := strings.Replace(matches[1], "%3F", "?", 1)
-		return magnet + "&tr=" + strings.Join(bt4gTrackers, "&tr=")
+		return magnet + "&tr=" + strings.Join(bt4gTrackers, "&tr="), nil
 	}
-	return ""
+	return "", fmt.Errorf("no magnet found in %s%s", bt4gUrl, hash)
 }
 
 var magnetRegex = regexp.MustCompile(`/(magnet:%3Fxt=.*)`)
@@ -2,6 +2,7 @@ package job
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
@@ -26,6 +27,7 @@ import (
 )
 
 type Bunkr struct {
+	ctx                       context.Context
 	maxWorker, albumMaxWorker int
 	savePath                  string
 	client                    *http.Client
@@ -63,10 +65,11 @@ func (r *Bunkr) RunCommand() *cobra.Command {
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
 		path := args[0]
+		bunkr.ctx = cmd.Context()
 		if strings.Contains(path, "/f/") {
 			// file share
 			bunkr.url = path
-			bunkr.client = &http.Client{Timeout: time.Minute * 60}
+			bunkr.client = api.NewHttpClient(time.Minute * 60)
 			err := bunkr.download()
 			if err != nil {
 				return err
@@ -74,8 +77,8 @@ func (r *Bunkr) RunCommand() *cobra.Command {
 		} else if strings.Contains(path, "/a/") {
 			// album share
 			bunkr.url = path
-			bunkr.client = &http.Client{Timeout: time.Minute * 60}
-			bunkr.albumDownload()
+			bunkr.client = api.NewHttpClient(time.Minute * 60)
+			return bunkr.albumDownload()
 		} else {
 			return errors.New("unknown url")
 		}
@@ -86,21 +89,21 @@ func (r *Bunkr) RunCommand() *cobra.Command {
 	return cmd
 }
 
-func (r *Bunkr) albumDownload() {
+// albumDownload downloads all files of the album, files which fail are returned as a PartialError.
+func (r *Bunkr) albumDownload() error {
 	parsedAlbum, err := url.Parse(r.url)
 	if err != nil {
-		return
+		return err
 	}
 
-	req, _ := http.NewRequest(http.MethodGet, r.url, nil)
+	req, _ := http.NewRequestWithContext(r.ctx, http.MethodGet, r.url, nil)
 	resp, err := r.client.Do(req)
 	if err != nil {
-		log.Println(err)
+		return err
 	}
 	defer utils.SafeClose(resp.Body)
 	if resp.StatusCode != http.StatusOK {
-		log.Printf("%s: %s", r.url, resp.Status)
-		return
+		return fmt.Errorf("%s: %s", r.url, resp.Status)
 	}
 
 	urls := findHtmlHrefNode(resp.Body)
@@ -125,17 +128,17 @@ func (r *Bunkr) albumDownload() {
 			}
 
 			if page >= len(pages) {
-				panic("max page exceeded")
+				return fmt.Errorf("page %d of %s exceeds max page %d", page, r.url, len(pages)-1)
 			}
 			if pages[page] {
 				continue
 			}
 
 			// parse page
-			req, _ := http.NewRequest(http.MethodGet, host+a, nil)
+			req, _ := http.NewRequestWithContext(r.ctx, http.MethodGet, host+a, nil)
 			resp, err := r.client.Do(req)
 			if err != nil {
-				log.Println(err)
+				return err
 			}
 			if resp.StatusCode != http.StatusOK {
 				log.Printf("%s: %s", r.url, resp.Status)
@@ -159,6 +162,8 @@ func (r *Bunkr) albumDownload() {
 	fmt.Println("total files: ", len(files))
 
 	var wg sync.WaitGroup
+	var mu sync.Mutex
+	perr := &api.PartialError{Total: len(files)}
 	limit := make(chan struct{}, r.albumMaxWorker)
 	for i := range files {
 		wg.Add(1)
@@ -168,15 +173,16 @@ func (r *Bunkr) albumDownload() {
 
 			b := *r
 			b.url = files[i]
-			err = b.download()
-			if err != nil {
-				log.Println(err)
-			}
+			err := b.download()
+			mu.Lock()
+			perr.Add(files[i], err)
+			mu.Unlock()
 
 			<-limit
 		}(i)
 	}
 	wg.Wait()
+	return perr.Err()
 }
 
 func findHtmlHrefNode(body io.Reader) []string {
@@ -230,6 +236,8 @@ func (r *Bunkr) download() error {
 	downloader := utils.HttpFileDownloader{
 		SavePath:  r.savePath,
 		MaxWorker: r.maxWorker,
+		Client:    r.client,
+		Context:   r.ctx,
 		Headers: map[string]string{
 			"Referer": downloadHost,
 		},
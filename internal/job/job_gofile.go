@@ -108,7 +108,7 @@ func GofileTrafficCmd() *cobra.Command {
 }
 
 func buildGofileHttpClient() *http.Client {
-	return &http.Client{Timeout: time.Minute * 1}
+	return api.NewHttpClient(time.Minute * 1)
 }
 
 func (r *Gofile) getTraffic() (*GofileTrafficResp, error) {
@@ -200,6 +200,7 @@ If you're using a shared IP address, this may include additional traffic beyond
 			SavePath:  gofile.savePath,
 			MaxWorker: gofile.maxWorker,
 			Client:    gofile.client,
+			Context:   cmd.Context(),
 			Headers: map[string]string{
 				"Cookie": "accountToken=" + gofile.token,
 			},
@@ -1,9 +1,10 @@
 package job
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
-	"net/http"
 	"net/url"
 	"qbit-cli/internal/api"
 	"qbit-cli/internal/api/emby"
@@ -84,6 +85,7 @@ this option will find all jp video's 4k version which is filter by extra jp code
 	plugins.RegisterCompletion(cmd)
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
 		searchParams := url.Values{}
 		searchParams.Add("Recursive", "true")
 		// video definition filter
@@ -99,8 +101,8 @@ this option will find all jp video's 4k version which is filter by extra jp code
 			searchParams.Add("ParentId", strconv.Itoa(parentId))
 		}
 
-		items := fourKItems(searchParams)
-		items = append(items, searchExtraCodes(extraCodes)...)
+		items := fourKItems(ctx, searchParams)
+		items = append(items, searchExtraCodes(ctx, extraCodes)...)
 		if len(items) <= 0 {
 			fmt.Println("no 4k items found")
 			return nil
@@ -109,14 +111,18 @@ this option will find all jp video's 4k version which is filter by extra jp code
 			maxParallelism = 1
 		}
 		bt4gClient := &BT4G{
-			client: &http.Client{
-				Timeout: time.Second * 180,
-			},
+			client: newBT4GHttpClient(),
 		}
 
 		var wg sync.WaitGroup
 		var mu sync.Mutex
 		data := make([]*api.SearchDetail, 0, len(items)*2)
+		perr := &api.PartialError{Total: len(items)}
+		fail := func(jpCode string, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			perr.Add(jpCode, err)
+		}
 		limit := make(chan struct{}, maxParallelism)
 		for i := range items {
 			wg.Add(1)
@@ -137,17 +143,18 @@ this option will find all jp video's 4k version which is filter by extra jp code
 				if bt4gMode {
 					result, err := bt4gClient.sendRequest(fmt.Sprintf("%s/search?q=%s&orderby=size", bt4gUrl, jpCode))
 					if err != nil {
-						fmt.Println(err.Error())
+						fail(jpCode, err)
 						return
 					}
 
+					var errs []error
 					for _, r := range parseList(result) {
 						if !JP4KRegex.MatchString(r.Title) {
 							continue
 						}
-						magnet := bt4gClient.download(r.Url)
-						if magnet == "" {
-							fmt.Println(fmt.Sprintf("bt4g [%s] get failed:", r.Url))
+						magnet, err := bt4gClient.download(r.Url)
+						if err != nil {
+							errs = append(errs, fmt.Errorf("bt4g [%s] get failed: %w", r.Url, err))
 							continue
 						}
 						data = append(data, &api.SearchDetail{
@@ -155,6 +162,7 @@ this option will find all jp video's 4k version which is filter by extra jp code
 							FileURL:  magnet,
 						})
 					}
+					fail(jpCode, errors.Join(errs...))
 					return
 				}
 
@@ -165,14 +173,14 @@ this option will find all jp video's 4k version which is filter by extra jp code
 				if plugins.Value != "" {
 					params.Set("plugins", plugins.Value)
 				}
-				result, err := api.SearchStart(params)
+				result, err := api.SearchStart(ctx, params)
 				if err != nil {
-					fmt.Printf("search start error: %s\n", err)
+					fail(jpCode, fmt.Errorf("search start: %w", err))
 					return
 				}
-				results, err := api.SearchDetails(1*time.Second, result.ID)
+				results, err := api.SearchDetails(ctx, 1*time.Second, result.ID)
 				if err != nil {
-					fmt.Printf("search details error: %s\n", err)
+					fail(jpCode, fmt.Errorf("search details: %w", err))
 					return
 				}
 
@@ -189,6 +197,7 @@ this option will find all jp video's 4k version which is filter by extra jp code
 
 		if len(data) > 0 {
 			urls := make([]string, 0, len(data))
+			sizes := make(map[string]int64, len(data))
 			fmt.Println("founded 4k items:")
 			for _, item := range data {
 				skip := false
@@ -206,21 +215,23 @@ this option will find all jp video's 4k version which is filter by extra jp code
 				}
 
 				urls = append(urls, item.FileURL)
+				sizes[item.FileURL] = item.FileSize
 				fmt.Println(item.FileName)
 			}
-			err := addTorrents(urls, autoMM, saveCategory.Value, saveTags, savePath)
+			err := addTorrents(ctx, urls, sizes, autoMM, saveCategory.Value, saveTags, savePath)
 			if err != nil {
 				return err
 			}
 		}
 
-		return nil
+		// torrents found by other items are added before failed searches are reported
+		return perr.Err()
 	}
 
 	return cmd
 }
 
-func searchExtraCodes(codes []string) []*api.EmbyItem {
+func searchExtraCodes(ctx context.Context, codes []string) []*api.EmbyItem {
 	// search extra codes which has no 4K tag
 	searchParams := url.Values{
 		"SearchTerm": codes,
@@ -229,7 +240,7 @@ func searchExtraCodes(codes []string) []*api.EmbyItem {
 		// 4k video filter
 		"MaxWidth": {strconv.Itoa(3000)},
 	}
-	items, err := emby.Items(searchParams)
+	items, err := emby.Items(ctx, searchParams)
 	if err != nil {
 		return nil
 	}
@@ -246,7 +257,7 @@ func searchExtraCodes(codes []string) []*api.EmbyItem {
 	return results
 }
 
-func fourKItems(searchParams url.Values) []*api.EmbyItem {
+func fourKItems(ctx context.Context, searchParams url.Values) []*api.EmbyItem {
 	// search 4k tag first
 	if searchParams.Get("ParentId") == "" {
 		tagParams := url.Values{
@@ -254,7 +265,7 @@ func fourKItems(searchParams url.Values) []*api.EmbyItem {
 			"IncludeItemTypes": {"genre"},
 			"Recursive":        {"true"},
 		}
-		tags, err := emby.Items(tagParams)
+		tags, err := emby.Items(ctx, tagParams)
 		if err != nil {
 			return nil
 		}
@@ -265,7 +276,7 @@ func fourKItems(searchParams url.Values) []*api.EmbyItem {
 		searchParams.Add("GenreIds", tag)
 	}
 
-	items, err := emby.Items(searchParams)
+	items, err := emby.Items(ctx, searchParams)
 	if err != nil {
 		return nil
 	}
@@ -276,12 +287,15 @@ func fourKItems(searchParams url.Values) []*api.EmbyItem {
 	return results
 }
 
-func addTorrents(urls []string, autoTMM bool, category, tags, savePath string) error {
+func addTorrents(ctx context.Context, urls []string, sizes map[string]int64, autoTMM bool, category, tags, savePath string) error {
 	params := url.Values{
 		"autoTMM": {strconv.FormatBool(autoTMM)},
 	}
 
-	cfg := config.GetConfig()
+	cfg, err := config.GetProfile()
+	if err != nil {
+		return err
+	}
 	// load defaults from config file
 	if category == "" {
 		category = cfg.Torrent.DefaultSaveCategory
@@ -296,8 +310,16 @@ func addTorrents(urls []string, autoTMM bool, category, tags, savePath string) e
 	params.Add("savepath", savePath)
 	params.Add("category", category)
 
-	if err := api.TorrentAdd(urls, params); err != nil {
+	space, err := c.LoadSpaceGuard()
+	if err != nil {
+		return err
+	}
+	results, err := api.TorrentAddChecked(ctx, urls, params, api.AddOptions{OnDuplicate: api.DuplicateSkip, Sizes: sizes, Space: space})
+	if results == nil {
 		return err
 	}
-	return nil
+	if printErr := c.PrintAddResults(results); printErr != nil {
+		return printErr
+	}
+	return err
 }
@@ -77,9 +77,11 @@ type musicParserHandler interface {
 	availableQuality()
 }
 
-var httpClient = &http.Client{
-	Timeout: time.Second * 10,
-}
+// httpClient is built when command runs, after config file is loaded
+var httpClient *http.Client
+
+// downloadClient downloads audio files and covers, which takes longer than api requests
+var downloadClient *http.Client
 
 type musicBatchParser interface {
 	parseSongs(id ...string) error
@@ -123,6 +125,8 @@ Parser will automatically parse the maximum bitrate downloadable audio, ensure y
 	parserDefine.RegisterCompletion(cmd)
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		httpClient = api.NewHttpClient(time.Second * 10)
+		downloadClient = api.NewHttpClient(time.Minute * 10)
 		var p musicParserHandler
 		switch parserConfig.parserType {
 		default:
@@ -138,32 +142,30 @@ Parser will automatically parse the maximum bitrate downloadable audio, ensure y
 			return nil
 		}
 
+		// songs of a batch parser are one item, so that a failed batch isn't counted as partial
+		perr := &api.PartialError{Total: len(playlistIds) + len(albumIds)}
 		if batch, ok := p.(musicBatchParser); ok {
-			if err := batch.parseSongs(songIds...); err != nil {
-				fmt.Println(err)
+			if len(songIds) > 0 {
+				perr.Total++
+				perr.Add("songs "+strings.Join(songIds, ","), batch.parseSongs(songIds...))
 			}
 		} else {
 			for _, songId := range songIds {
-				if err := p.parseSong(songId); err != nil {
-					fmt.Println(err)
-				}
+				perr.Total++
+				perr.Add("song "+songId, p.parseSong(songId))
 			}
 		}
 
 		for _, id := range playlistIds {
-			if err := p.parsePlaylist(id); err != nil {
-				fmt.Println(err)
-			}
+			perr.Add("playlist "+id, p.parsePlaylist(id))
 		}
 
 		// parse albums
 		for _, id := range albumIds {
-			if err := p.parseAlbum(id); err != nil {
-				fmt.Println(err)
-			}
+			perr.Add("album "+id, p.parseAlbum(id))
 		}
 
-		return nil
+		return perr.Err()
 
 	}
 	return cmd
@@ -1,6 +1,8 @@
 package job
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/url"
@@ -49,6 +51,7 @@ func (r *RenameJP) RunCommand() *cobra.Command {
 	var (
 		category, hashes, tag string
 		renameTorrent         bool
+		where                 cmd2.TorrentWhere
 	)
 
 	state := cmd2.FlagsProperty[string]{Flag: "state", Options: cmd2.TorrentState}
@@ -58,10 +61,15 @@ func (r *RenameJP) RunCommand() *cobra.Command {
 	jp.Flags().StringVar(&tag, "tag", "", "tag filter")
 	jp.Flags().StringVar(&hashes, "hashes", "", "hash filter separated by |'")
 	jp.Flags().BoolVar(&renameTorrent, "rename-torrent", false, "whether to rename torrent files")
+	where.RegisterFlag(jp)
 
 	state.RegisterCompletion(jp)
 
 	jp.RunE = func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		if err := where.Compile(); err != nil {
+			return err
+		}
 		params := url.Values{}
 		if state.Value != "" {
 			params.Set("filter", state.Value)
@@ -76,19 +84,25 @@ func (r *RenameJP) RunCommand() *cobra.Command {
 			params.Set("category", category)
 		}
 
-		torrentList, err := api.TorrentList(params)
+		torrentList, err := api.TorrentList(ctx, params)
 		if err != nil {
 			return err
 		}
+		if torrentList, err = where.Filter(torrentList); err != nil {
+			return err
+		}
 
 		fmt.Printf("total size: %d\n", len(torrentList))
+		perr := &api.PartialError{Total: len(torrentList)}
 		for _, t := range torrentList {
 			// get torrent files
-			fileList, err := api.TorrentFiles(url.Values{"hash": {t.Hash}})
-			if fileList == nil {
-				fmt.Println(err.Error())
+			fileList, err := api.TorrentFiles(ctx, url.Values{"hash": {t.Hash}})
+			if err != nil {
+				perr.Add(t.Hash, err)
 				continue
 			}
+			// failures of renaming are reported once per torrent
+			var errs []error
 
 			for _, file := range fileList {
 				// priority = 0 means file is not selected to download
@@ -103,25 +117,25 @@ func (r *RenameJP) RunCommand() *cobra.Command {
 					if jpCode == "" {
 						continue
 					}
-					rename(renameTorrent, t, jpCode)
+					errs = append(errs, rename(ctx, renameTorrent, t, jpCode))
 					if newPath := jpCode + filepath.Ext(files[0]); newPath != files[0] {
-						if err := api.TorrentRenameFile(t.Hash, file.Name, newPath); err != nil {
-							fmt.Printf("hash:[%s] new path: %s rename file failed: %v\n", t.Hash, newPath, err)
+						if err := api.TorrentRenameFile(ctx, t.Hash, file.Name, newPath); err != nil {
+							errs = append(errs, fmt.Errorf("rename file to %s: %w", newPath, err))
 						}
-						rename(renameTorrent, t, jpCode)
+						errs = append(errs, rename(ctx, renameTorrent, t, jpCode))
 					}
 				} else if l == 2 {
 					newFolder := parseJPCode(files[1], files[0])
 					if newFolder == "" {
 						continue
 					}
-					rename(renameTorrent, t, newFolder)
+					errs = append(errs, rename(ctx, renameTorrent, t, newFolder))
 					// rename only when name changed
 					sleep := false
 					if newFolder != files[0] {
 						sleep = true
-						if err := api.TorrentRenameFolder(t.Hash, files[0], newFolder); err != nil {
-							fmt.Printf("[%s] %s -> %s renameFolder failed\n", t.Hash, files[0], newFolder)
+						if err := api.TorrentRenameFolder(ctx, t.Hash, files[0], newFolder); err != nil {
+							errs = append(errs, fmt.Errorf("rename folder %s -> %s: %w", files[0], newFolder, err))
 						}
 					}
 					newPath := newFolder + "/" + parseJPName(files[1], files[0]) + filepath.Ext(files[1])
@@ -130,28 +144,29 @@ func (r *RenameJP) RunCommand() *cobra.Command {
 						if sleep {
 							time.Sleep(500 * time.Millisecond)
 						}
-						if err := api.TorrentRenameFile(t.Hash, oldPath, newPath); err != nil {
-							fmt.Printf("[%s] %s -> %s renameFile failed: %s\n", t.Hash, oldPath, newPath, err)
+						if err := api.TorrentRenameFile(ctx, t.Hash, oldPath, newPath); err != nil {
+							errs = append(errs, fmt.Errorf("rename file %s -> %s: %w", oldPath, newPath, err))
 						}
 					}
 				}
 			}
+			perr.Add(t.Hash, errors.Join(errs...))
 		}
 
-		return nil
+		return perr.Err()
 	}
 
 	return jp
 }
 
-func rename(rename bool, t api.Torrent, name string) {
+func rename(ctx context.Context, rename bool, t api.Torrent, name string) error {
 	if !rename || t.Name == name {
-		return
+		return nil
 	}
-	err := api.RenameTorrent(t.Hash, name)
-	if err != nil {
-		fmt.Printf("%s rename failed: %v\n", t.Hash, err)
+	if err := api.RenameTorrent(ctx, t.Hash, name); err != nil {
+		return fmt.Errorf("rename torrent to %s: %w", name, err)
 	}
+	return nil
 }
 
 func parseJPCode(fileName string, folder string) string {
@@ -0,0 +1,43 @@
+package job
+
+import (
+	"context"
+	"qbit-cli/internal/api"
+	"qbit-cli/internal/qbittest"
+	"testing"
+)
+
+func TestRenameJP(t *testing.T) {
+	s := qbittest.NewServer(qbittest.Options{})
+	defer s.Close()
+	s.Configure(t)
+	s.AddTorrent(qbittest.Torrent{Hash: "aaa", Name: "single", Files: []api.TorrentFile{
+		{Name: "[site] ABP-123.mp4", Priority: 1},
+	}})
+	s.AddTorrent(qbittest.Torrent{Hash: "bbb", Name: "folder", Files: []api.TorrentFile{
+		{Name: "random folder/SSIS-001 hd.mp4", Priority: 1},
+		{Name: "random folder/ad.txt", Priority: 0},
+	}})
+
+	cmd := (&RenameJP{}).RunCommand()
+	cmd.SetArgs([]string{"--rename-torrent"})
+	if err := cmd.ExecuteContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string][]string{
+		"aaa": {"ABP-123.mp4"},
+		"bbb": {"SSIS-001/SSIS-001.mp4", "SSIS-001/ad.txt"},
+	}
+	names := map[string]string{"aaa": "ABP-123", "bbb": "SSIS-001"}
+	for _, torrent := range s.Torrents() {
+		if torrent.Name != names[torrent.Hash] {
+			t.Errorf("%s name is %s, want %s", torrent.Hash, torrent.Name, names[torrent.Hash])
+		}
+		for i, f := range torrent.Files {
+			if f.Name != want[torrent.Hash][i] {
+				t.Errorf("%s file %d is %s, want %s", torrent.Hash, i, f.Name, want[torrent.Hash][i])
+			}
+		}
+	}
+}
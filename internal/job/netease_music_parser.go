@@ -10,6 +10,7 @@ import (
 	"net/http"
 	"net/url"
 	"path/filepath"
+	"qbit-cli/internal/api"
 	"qbit-cli/internal/config"
 	"qbit-cli/pkg/utils"
 	"strconv"
@@ -67,12 +68,13 @@ func (parser *NeteaseMusicParser) parsePlaylist(id string) error {
 		return err
 	}
 
+	perr := &api.PartialError{Total: len(infos.Songs)}
 	for _, s := range infos.Songs {
 		parser.songInfo = &s
 		parser.songPrivilege = &s.Privilege
-		_ = parser.parseSong(strconv.FormatInt(s.ID, 10))
+		perr.Add(s.Name, parser.parseSong(strconv.FormatInt(s.ID, 10)))
 	}
-	return nil
+	return perr.Err()
 }
 
 func (parser *NeteaseMusicParser) parseAlbum(id string) error {
@@ -97,12 +99,13 @@ func (parser *NeteaseMusicParser) parseAlbum(id string) error {
 	if err != nil {
 		return err
 	}
+	perr := &api.PartialError{Total: len(data)}
 	for _, s := range data {
 		parser.songInfo = &s
 		parser.songPrivilege = &s.Privilege
-		_ = parser.parseSong(strconv.FormatInt(s.ID, 10))
+		perr.Add(s.Name, parser.parseSong(strconv.FormatInt(s.ID, 10)))
 	}
-	return nil
+	return perr.Err()
 }
 
 func (parser *NeteaseMusicParser) parseSong(id string) error {
@@ -145,13 +148,10 @@ func (parser *NeteaseMusicParser) parseSong(id string) error {
 	log.Printf("song quality: %s\n", parserConfig.quality)
 	song, err := getNeteaseSong(songId, parserConfig.quality)
 	if err != nil {
-		fmt.Println(err)
 		return err
 	}
 	if song.Code != 200 {
-		errStr := fmt.Sprintf("%d:%s parse failed: %d", songId, parser.songInfo.Name, song.Code)
-		fmt.Println(errStr)
-		return errors.New(errStr)
+		return fmt.Errorf("%d:%s parse failed: %d", songId, parser.songInfo.Name, song.Code)
 	}
 
 	track := ""
@@ -161,14 +161,14 @@ func (parser *NeteaseMusicParser) parseSong(id string) error {
 	filename = filepath.Join(parserConfig.savePath, track+filename+"."+song.EncodeType)
 
 	// download audio file
-	err = utils.DownloadUrlToFile(filename, song.Url)
+	err = utils.DownloadUrlToFile(downloadClient, filename, song.Url)
 	if err != nil {
 		return err
 	}
 	// download cover
 	cover := parserConfig.coverPathFromAudio(filename, albumPic)
 	if albumPic != "" {
-		_ = utils.DownloadUrlToFile(cover, albumPic)
+		_ = utils.DownloadUrlToFile(downloadClient, cover, albumPic)
 		defer utils.SafeRemoveFile(cover)
 	}
 	// get lyrics
@@ -197,22 +197,28 @@ func (parser *NeteaseMusicParser) parseSongs(id ...string) error {
 	if len(id) == 0 {
 		return nil
 	}
+	perr := &api.PartialError{Total: len(id)}
 	var ids = make([]int64, 0, len(id))
 	for _, v := range id {
-		if songId, err := strconv.ParseInt(v, 10, 64); err == nil {
-			ids = append(ids, songId)
+		songId, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			perr.Add(v, errors.New("invalid song id"))
+			continue
 		}
+		ids = append(ids, songId)
 	}
-	infos, err := getNeteaseSongInfo(ids...)
-	if err != nil {
-		return err
-	}
-	for _, song := range infos.Songs {
-		parser.songInfo = &song
-		parser.songPrivilege = &song.Privilege
-		_ = parser.parseSong("")
+	if len(ids) > 0 {
+		infos, err := getNeteaseSongInfo(ids...)
+		if err != nil {
+			return err
+		}
+		for _, song := range infos.Songs {
+			parser.songInfo = &song
+			parser.songPrivilege = &song.Privilege
+			perr.Add(song.Name, parser.parseSong(""))
+		}
 	}
-	return nil
+	return perr.Err()
 }
 
 func albumDetail(albumId int64) (*[]NeteaseSongInfo, error) {
@@ -231,7 +237,7 @@ func albumDetail(albumId int64) (*[]NeteaseSongInfo, error) {
 	defer utils.SafeClose(resp.Body)
 	var album NeteaseAlbumResult
 	if err := json.NewDecoder(resp.Body).Decode(&album); err != nil {
-		fmt.Println(err)
+		return nil, err
 	}
 	return &album.Songs, nil
 }
@@ -253,7 +259,6 @@ func playlistDetail(playlistId int64) (*NeteasePlaylistResult, error) {
 	defer utils.SafeClose(resp.Body)
 	var result NeteasePlaylistResult
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		fmt.Println(err)
 		return nil, err
 	}
 	return &result, nil
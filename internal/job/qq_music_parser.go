@@ -10,6 +10,7 @@ import (
 	"net/http"
 	"net/url"
 	"path/filepath"
+	"qbit-cli/internal/api"
 	"qbit-cli/internal/config"
 	"qbit-cli/pkg/utils"
 	"strconv"
@@ -108,14 +109,12 @@ func (parser *QQMusicParser) parseAlbum(id string) error {
 		return err
 	}
 
+	perr := &api.PartialError{Total: len(*tracks)}
 	for _, t := range *tracks {
 		parser.songInfo = &t
-		err = parser.parseSong(t.Mid)
-		if err != nil {
-			fmt.Println(err)
-		}
+		perr.Add(t.Name, parser.parseSong(t.Mid))
 	}
-	return nil
+	return perr.Err()
 }
 
 func (parser *QQMusicParser) parsePlaylist(id string) error {
@@ -130,14 +129,12 @@ func (parser *QQMusicParser) parsePlaylist(id string) error {
 		return err
 	}
 
+	perr := &api.PartialError{Total: len(*tracks)}
 	for _, t := range *tracks {
 		parser.songInfo = &t
-		err = parser.parseSong(t.Mid)
-		if err != nil {
-			fmt.Println(err)
-		}
+		perr.Add(t.Name, parser.parseSong(t.Mid))
 	}
-	return nil
+	return perr.Err()
 }
 
 func (parser *QQMusicParser) parseSong(id string) error {
@@ -224,7 +221,7 @@ func (parser *QQMusicParser) parseSong(id string) error {
 	audioFilePath := filepath.Join(parserConfig.savePath, track+parser.songInfo.Name+q.Ext)
 	log.Println(audioFilePath)
 	audioUrl := result.Req1.Data.Sip[0] + result.Req1.Data.MidUrlInfo[0].PUrl
-	err = utils.DownloadUrlToFile(audioFilePath, audioUrl)
+	err = utils.DownloadUrlToFile(downloadClient, audioFilePath, audioUrl)
 	if err != nil {
 		return err
 	}
@@ -234,7 +231,7 @@ func (parser *QQMusicParser) parseSong(id string) error {
 	if parser.songInfo.AlbumMid != "" {
 		albumPicUrl := fmt.Sprintf(qqSongAlbumCoverFormat, parser.songInfo.AlbumMid)
 		albumPicPath = parserConfig.coverPathFromAudio(audioFilePath, albumPicUrl)
-		err = utils.DownloadUrlToFile(albumPicPath, albumPicUrl)
+		err = utils.DownloadUrlToFile(downloadClient, albumPicPath, albumPicUrl)
 		if err != nil {
 			return err
 		}
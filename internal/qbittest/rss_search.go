@@ -0,0 +1,263 @@
+package qbittest
+
+import (
+	"encoding/json"
+	"net/http"
+	"path"
+	"qbit-cli/internal/api"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// AddRssFeed adds or replaces a feed at path.
+func (s *Server) AddRssFeed(path, feedUrl string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rssItems[path] = api.RssSub{UID: newSID(), URL: feedUrl}
+}
+
+// RssFeeds returns feeds keyed by path.
+func (s *Server) RssFeeds() map[string]api.RssSub {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	feeds := make(map[string]api.RssSub, len(s.rssItems))
+	for k, v := range s.rssItems {
+		feeds[k] = v
+	}
+	return feeds
+}
+
+// RssRule returns the rule set by rss/setRule.
+func (s *Server) RssRule(name string) (api.RssRule, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	raw, ok := s.rssRules[name]
+	if !ok {
+		return api.RssRule{}, false
+	}
+	var rule api.RssRule
+	_ = json.Unmarshal(raw, &rule)
+	return rule, true
+}
+
+func (s *Server) rssAddFeed(w http.ResponseWriter, r *http.Request) {
+	if !requireParams(w, r, "url") {
+		return
+	}
+	feedUrl, feedPath := r.Form.Get("url"), r.Form.Get("path")
+	if feedPath == "" {
+		feedPath = feedUrl
+	}
+	if _, exists := s.rssItems[feedPath]; exists {
+		http.Error(w, "RSS item already exists", http.StatusConflict)
+		return
+	}
+	for _, item := range s.rssItems {
+		if item.URL == feedUrl {
+			http.Error(w, "RSS feed with given URL already exists", http.StatusConflict)
+			return
+		}
+	}
+	s.rssItems[feedPath] = api.RssSub{UID: newSID(), URL: feedUrl}
+}
+
+func (s *Server) rssRemoveItem(w http.ResponseWriter, r *http.Request) {
+	if !requireParams(w, r, "path") {
+		return
+	}
+	if _, exists := s.rssItems[r.Form.Get("path")]; !exists {
+		http.Error(w, "RSS item doesn't exist", http.StatusConflict)
+		return
+	}
+	delete(s.rssItems, r.Form.Get("path"))
+}
+
+func (s *Server) rssGetItems(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, s.rssItems)
+}
+
+func (s *Server) rssGetRules(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, s.rssRules)
+}
+
+func (s *Server) rssSetRule(w http.ResponseWriter, r *http.Request) {
+	if !requireParams(w, r, "ruleName", "ruleDef") {
+		return
+	}
+	def := json.RawMessage(r.Form.Get("ruleDef"))
+	var rule map[string]any
+	if err := json.Unmarshal(def, &rule); err != nil {
+		http.Error(w, "Invalid rule definition", http.StatusBadRequest)
+		return
+	}
+	s.rssRules[r.Form.Get("ruleName")] = def
+}
+
+func (s *Server) rssRemoveRule(w http.ResponseWriter, r *http.Request) {
+	if !requireParams(w, r, "ruleName") {
+		return
+	}
+	delete(s.rssRules, r.Form.Get("ruleName"))
+}
+
+// maxRunningSearches is the limit of concurrent search jobs of qBittorrent
+const maxRunningSearches = 5
+
+type searchJob struct {
+	id      uint32
+	pattern string
+	status  string
+	results []api.SearchDetail
+}
+
+func defaultPlugins() []api.SearchPlugin {
+	return []api.SearchPlugin{{
+		Enabled:  true,
+		FullName: "Fake",
+		Name:     "fake",
+		Url:      "https://fake.example.com",
+		Version:  "1.0",
+	}}
+}
+
+// SetSearchResults sets results of searches of pattern, searches finish immediately.
+func (s *Server) SetSearchResults(pattern string, results []api.SearchDetail) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.searchResults[pattern] = results
+}
+
+// Plugins returns installed search plugins.
+func (s *Server) Plugins() []api.SearchPlugin {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.plugins)
+}
+
+func (s *Server) searchJob(w http.ResponseWriter, r *http.Request) (*searchJob, bool) {
+	if !requireParams(w, r, "id") {
+		return nil, false
+	}
+	id, _ := strconv.ParseUint(r.Form.Get("id"), 10, 32)
+	job, ok := s.searchJobs[uint32(id)]
+	if !ok {
+		http.Error(w, "Search job was not found", http.StatusNotFound)
+	}
+	return job, ok
+}
+
+func (s *Server) searchStart(w http.ResponseWriter, r *http.Request) {
+	if !requireParams(w, r, "pattern", "plugins", "category") {
+		return
+	}
+	running := 0
+	for _, job := range s.searchJobs {
+		if job.status == "Running" {
+			running++
+		}
+	}
+	if running >= maxRunningSearches {
+		http.Error(w, "Unable to create more than 5 concurrent searches.", http.StatusConflict)
+		return
+	}
+	s.nextSearchID++
+	pattern := r.Form.Get("pattern")
+	job := &searchJob{
+		id:      s.nextSearchID,
+		pattern: pattern,
+		status:  "Stopped",
+		results: slices.Clone(s.searchResults[pattern]),
+	}
+	s.searchJobs[job.id] = job
+	writeJSON(w, api.SearchResult{ID: job.id})
+}
+
+func (s *Server) searchStop(w http.ResponseWriter, r *http.Request) {
+	if job, ok := s.searchJob(w, r); ok {
+		job.status = "Stopped"
+	}
+}
+
+func (s *Server) searchDelete(w http.ResponseWriter, r *http.Request) {
+	if job, ok := s.searchJob(w, r); ok {
+		delete(s.searchJobs, job.id)
+	}
+}
+
+func (s *Server) searchStatus(w http.ResponseWriter, r *http.Request) {
+	type status struct {
+		ID     uint32 `json:"id"`
+		Status string `json:"status"`
+		Total  int    `json:"total"`
+	}
+	var list []status
+	if r.Form.Get("id") != "" {
+		job, ok := s.searchJob(w, r)
+		if !ok {
+			return
+		}
+		list = append(list, status{job.id, job.status, len(job.results)})
+	} else {
+		for _, job := range s.searchJobs {
+			list = append(list, status{job.id, job.status, len(job.results)})
+		}
+		slices.SortFunc(list, func(a, b status) int { return int(a.ID) - int(b.ID) })
+	}
+	writeJSON(w, list)
+}
+
+func (s *Server) searchGetResults(w http.ResponseWriter, r *http.Request) {
+	job, ok := s.searchJob(w, r)
+	if !ok {
+		return
+	}
+	results := job.results
+	if offset, err := strconv.Atoi(r.Form.Get("offset")); err == nil && offset > 0 {
+		results = results[min(offset, len(results)):]
+	}
+	if limit, err := strconv.Atoi(r.Form.Get("limit")); err == nil && limit > 0 && limit < len(results) {
+		results = results[:limit]
+	}
+	if results == nil {
+		results = []api.SearchDetail{}
+	}
+	writeJSON(w, api.SearchResults{Results: results, Status: job.status, Total: uint32(len(job.results))})
+}
+
+func (s *Server) searchPlugins(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, s.plugins)
+}
+
+func (s *Server) searchInstallPlugin(w http.ResponseWriter, r *http.Request) {
+	if !requireParams(w, r, "sources") {
+		return
+	}
+	for _, source := range strings.Split(r.Form.Get("sources"), "|") {
+		name := strings.TrimSuffix(path.Base(source), ".py")
+		if slices.ContainsFunc(s.plugins, func(p api.SearchPlugin) bool { return p.Name == name }) {
+			continue
+		}
+		s.plugins = append(s.plugins, api.SearchPlugin{Enabled: true, FullName: name, Name: name, Url: source, Version: "1.0"})
+	}
+}
+
+func (s *Server) searchUninstallPlugin(w http.ResponseWriter, r *http.Request) {
+	if !requireParams(w, r, "names") {
+		return
+	}
+	names := strings.Split(r.Form.Get("names"), "|")
+	s.plugins = slices.DeleteFunc(s.plugins, func(p api.SearchPlugin) bool { return slices.Contains(names, p.Name) })
+}
+
+func (s *Server) searchEnablePlugin(w http.ResponseWriter, r *http.Request) {
+	if !requireParams(w, r, "names", "enable") {
+		return
+	}
+	names := strings.Split(r.Form.Get("names"), "|")
+	for i := range s.plugins {
+		if slices.Contains(names, s.plugins[i].Name) {
+			s.plugins[i].Enabled = r.Form.Get("enable") == "true"
+		}
+	}
+}
@@ -0,0 +1,447 @@
+// Package qbittest provides an in-process fake of qBittorrent WebUI api v2 for end-to-end tests.
+//
+// The fake keeps torrents, categories, tags, rss feeds and rules, search jobs and preferences in memory,
+// so commands can be run against it and the state checked afterwards:
+//
+//	s := qbittest.NewServer(qbittest.Options{Username: "admin", Password: "adminadmin"})
+//	defer s.Close()
+//	s.Configure(t)
+//	s.AddTorrent(qbittest.Torrent{Hash: "abc", Name: "ubuntu"})
+package qbittest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"qbit-cli/internal/api"
+	"qbit-cli/internal/config"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const (
+	DefaultApiVersion = "2.11.2"
+	DefaultAppVersion = "v5.0.0"
+)
+
+type Options struct {
+	// Username and Password enable cookie auth, all endpoints except auth/login answer 403 without a valid SID
+	Username string
+	Password string
+	// Token enables "Authorization: Bearer" auth
+	Token string
+	// MaxFailedLogins bans the client after that many failed logins, 0 means never
+	MaxFailedLogins int
+	// ApiVersion is returned by app/webapiVersion, servers older than 2.11.0 use pause/resume
+	ApiVersion string
+	AppVersion string
+}
+
+// Request is a request received by the server, Form includes query and body params.
+type Request struct {
+	Method string
+	Path   string
+	Form   url.Values
+}
+
+// Server is a stateful fake qBittorrent server, it is safe for concurrent use.
+type Server struct {
+	*httptest.Server
+	Options
+
+	apiVersion api.ApiVersion
+	routes     map[string]route
+
+	mu           sync.Mutex
+	sessions     map[string]struct{}
+	failedLogins int
+	requests     []Request
+
+	torrents    map[string]*Torrent
+	categories  map[string]api.TorrentCategory
+	tags        map[string]struct{}
+	serverState map[string]any
+	preferences map[string]any
+	sync        *syncSnapshot
+
+	rssItems map[string]api.RssSub
+	rssRules map[string]json.RawMessage
+
+	plugins       []api.SearchPlugin
+	searchResults map[string][]api.SearchDetail
+	searchJobs    map[uint32]*searchJob
+	nextSearchID  uint32
+}
+
+// route is an endpoint, read only endpoints accept GET as well as POST.
+type route struct {
+	get     bool
+	handler func(w http.ResponseWriter, r *http.Request)
+}
+
+// NewServer starts a fake server, it should be closed by Close.
+func NewServer(opts Options) *Server {
+	if opts.ApiVersion == "" {
+		opts.ApiVersion = DefaultApiVersion
+	}
+	if opts.AppVersion == "" {
+		opts.AppVersion = DefaultAppVersion
+	}
+	version, err := api.ParseApiVersion(opts.ApiVersion)
+	if err != nil {
+		panic(err)
+	}
+	s := &Server{
+		Options:       opts,
+		apiVersion:    version,
+		sessions:      make(map[string]struct{}),
+		torrents:      make(map[string]*Torrent),
+		categories:    make(map[string]api.TorrentCategory),
+		tags:          make(map[string]struct{}),
+		serverState:   defaultServerState(),
+		preferences:   defaultPreferences(),
+		rssItems:      make(map[string]api.RssSub),
+		rssRules:      make(map[string]json.RawMessage),
+		plugins:       defaultPlugins(),
+		searchResults: make(map[string][]api.SearchDetail),
+		searchJobs:    make(map[uint32]*searchJob),
+	}
+	s.routes = s.newRoutes()
+	s.Server = httptest.NewServer(s)
+	return s
+}
+
+// Configure points config and api clients of this process to the server until the test ends.
+// Session file is written to a temp dir, retry is disabled.
+func (s *Server) Configure(tb testing.TB) {
+	tb.Helper()
+	cfg := &config.Config{}
+	cfg.Server = config.ServerConfig{
+		Host:     s.URL,
+		Username: s.Username,
+		Password: s.Password,
+		Token:    s.Token,
+	}
+	cfg.Http.MaxRetry = -1
+	config.CfgPath = filepath.Join(tb.TempDir(), "config.yaml")
+	config.Set(cfg)
+	api.ResetClients()
+	tb.Cleanup(func() {
+		config.CfgPath = ""
+		config.Set(nil)
+		api.ResetClients()
+	})
+}
+
+func (s *Server) newRoutes() map[string]route {
+	routes := map[string]route{
+		"auth/login":  {handler: s.login},
+		"auth/logout": {handler: s.logout},
+
+		"app/version":         {get: true, handler: s.appVersion},
+		"app/webapiVersion":   {get: true, handler: s.webapiVersion},
+		"app/buildInfo":       {get: true, handler: s.buildInfo},
+		"app/preferences":     {get: true, handler: s.getPreferences},
+		"app/defaultSavePath": {get: true, handler: s.defaultSavePath},
+		"app/setPreferences":  {handler: s.setPreferences},
+
+		"torrents/info":                     {get: true, handler: s.torrentInfo},
+		"torrents/files":                    {get: true, handler: s.torrentFiles},
+		"torrents/trackers":                 {get: true, handler: s.torrentTrackers},
+		"torrents/properties":               {get: true, handler: s.torrentProperties},
+		"torrents/export":                   {get: true, handler: s.torrentExport},
+		"torrents/addTrackers":              {handler: s.torrentAddTrackers},
+		"torrents/editTracker":              {handler: s.torrentEditTracker},
+		"torrents/removeTrackers":           {handler: s.torrentRemoveTrackers},
+		"torrents/tags":                     {get: true, handler: s.tagList},
+		"torrents/categories":               {get: true, handler: s.categoryList},
+		"torrents/add":                      {handler: s.torrentAdd},
+		"torrents/delete":                   {handler: s.torrentDelete},
+		"torrents/rename":                   {handler: s.torrentRename},
+		"torrents/renameFile":               {handler: s.torrentRenameFile},
+		"torrents/renameFolder":             {handler: s.torrentRenameFolder},
+		"torrents/filePrio":                 {handler: s.torrentFilePrio},
+		"torrents/recheck":                  {handler: s.updateTorrents(nil)},
+		"torrents/reannounce":               {handler: s.updateTorrents(nil)},
+		"torrents/increasePrio":             {handler: s.updateTorrents(func(t *Torrent, _ url.Values) { t.Priority = max(t.Priority-1, 1) })},
+		"torrents/decreasePrio":             {handler: s.updateTorrents(func(t *Torrent, _ url.Values) { t.Priority++ })},
+		"torrents/topPrio":                  {handler: s.updateTorrents(func(t *Torrent, _ url.Values) { t.Priority = 1 })},
+		"torrents/bottomPrio":               {handler: s.updateTorrents(func(t *Torrent, _ url.Values) { t.Priority = len(s.torrents) })},
+		"torrents/downloadLimit":            {handler: s.torrentSpeedLimits(func(t *Torrent) int64 { return t.DlLimit })},
+		"torrents/uploadLimit":              {handler: s.torrentSpeedLimits(func(t *Torrent) int64 { return t.UpLimit })},
+		"torrents/setDownloadLimit":         {handler: s.updateTorrents(setDownloadLimit, "limit")},
+		"torrents/setUploadLimit":           {handler: s.updateTorrents(setUploadLimit, "limit")},
+		"torrents/setShareLimits":           {handler: s.updateTorrents(setShareLimits, "ratioLimit", "seedingTimeLimit")},
+		"torrents/setCategory":              {handler: s.torrentSetCategory},
+		"torrents/addTags":                  {handler: s.torrentAddTags},
+		"torrents/removeTags":               {handler: s.updateTorrents(removeTags)},
+		"torrents/setLocation":              {handler: s.torrentSetLocation},
+		"torrents/setAutoManagement":        {handler: s.updateTorrents(func(t *Torrent, form url.Values) { t.AutoTMM = form.Get("enable") == "true" }, "enable")},
+		"torrents/toggleSequentialDownload": {handler: s.updateTorrents(func(t *Torrent, _ url.Values) { t.SeqDl = !t.SeqDl })},
+		"torrents/toggleFirstLastPiecePrio": {handler: s.updateTorrents(func(t *Torrent, _ url.Values) { t.FLPiecePrio = !t.FLPiecePrio })},
+		"torrents/setForceStart":            {handler: s.updateTorrents(func(t *Torrent, form url.Values) { t.ForceStart = form.Get("value") == "true" }, "value")},
+		"torrents/setSuperSeeding":          {handler: s.updateTorrents(func(t *Torrent, form url.Values) { t.SuperSeeding = form.Get("value") == "true" }, "value")},
+		"torrents/createTags":               {handler: s.createTags},
+		"torrents/deleteTags":               {handler: s.deleteTags},
+		"torrents/createCategory":           {handler: s.createCategory},
+		"torrents/editCategory":             {handler: s.editCategory},
+		"torrents/removeCategories":         {handler: s.removeCategories},
+
+		"transfer/info":                  {get: true, handler: s.transferInfo},
+		"transfer/downloadLimit":         {get: true, handler: s.transferLimit("dl")},
+		"transfer/uploadLimit":           {get: true, handler: s.transferLimit("up")},
+		"transfer/setDownloadLimit":      {handler: s.transferSetLimit("dl")},
+		"transfer/setUploadLimit":        {handler: s.transferSetLimit("up")},
+		"transfer/speedLimitsMode":       {get: true, handler: s.speedLimitsMode},
+		"transfer/toggleSpeedLimitsMode": {handler: s.toggleSpeedLimitsMode},
+		"transfer/banPeers":              {handler: s.banPeers},
+
+		"sync/maindata":     {get: true, handler: s.syncMainData},
+		"sync/torrentPeers": {get: true, handler: s.syncTorrentPeers},
+
+		"rss/addFeed":    {handler: s.rssAddFeed},
+		"rss/removeItem": {handler: s.rssRemoveItem},
+		"rss/items":      {get: true, handler: s.rssGetItems},
+		"rss/rules":      {get: true, handler: s.rssGetRules},
+		"rss/setRule":    {handler: s.rssSetRule},
+		"rss/removeRule": {handler: s.rssRemoveRule},
+
+		"search/start":           {handler: s.searchStart},
+		"search/stop":            {handler: s.searchStop},
+		"search/status":          {get: true, handler: s.searchStatus},
+		"search/results":         {get: true, handler: s.searchGetResults},
+		"search/delete":          {handler: s.searchDelete},
+		"search/plugins":         {get: true, handler: s.searchPlugins},
+		"search/installPlugin":   {handler: s.searchInstallPlugin},
+		"search/uninstallPlugin": {handler: s.searchUninstallPlugin},
+		"search/enablePlugin":    {handler: s.searchEnablePlugin},
+		"search/updatePlugins":   {handler: ok},
+	}
+	// torrents/stop and torrents/start replace pause and resume since 2.11.0
+	stopped := s.stoppedState
+	stop := route{handler: s.updateTorrents(func(t *Torrent, _ url.Values) { t.State = stopped(t) })}
+	start := route{handler: s.updateTorrents(func(t *Torrent, _ url.Values) { t.State = runningState(t) })}
+	if s.legacy() {
+		routes["torrents/pause"], routes["torrents/resume"] = stop, start
+	} else {
+		routes["torrents/stop"], routes["torrents/start"] = stop, start
+	}
+	return routes
+}
+
+// legacy reports whether the server is older than qBittorrent 5.0
+func (s *Server) legacy() bool {
+	return s.apiVersion.Less(api.ApiVersion{Major: 2, Minor: 11})
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name, found := strings.CutPrefix(r.URL.Path, "/api/v2/")
+	rt, exists := s.routes[name]
+	if !found || !exists {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost && (r.Method != http.MethodGet || !rt.get) {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := parseForm(r); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, Request{Method: r.Method, Path: r.URL.Path, Form: r.Form})
+	if name != "auth/login" && !s.authorized(r) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+	rt.handler(w, r)
+}
+
+func parseForm(r *http.Request) error {
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		if err := r.ParseMultipartForm(32 << 20); err != nil {
+			return err
+		}
+		// multipart values are not merged into r.Form by ParseMultipartForm
+		for k, v := range r.MultipartForm.Value {
+			r.Form[k] = append(r.Form[k], v...)
+		}
+		return nil
+	}
+	return r.ParseForm()
+}
+
+func (s *Server) authEnabled() bool {
+	return s.Username != "" || s.Token != ""
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	if !s.authEnabled() {
+		return true
+	}
+	if s.Token != "" && r.Header.Get("Authorization") == "Bearer "+s.Token {
+		return true
+	}
+	cookie, err := r.Cookie("SID")
+	if err != nil {
+		return false
+	}
+	_, ok := s.sessions[cookie.Value]
+	return ok
+}
+
+func (s *Server) login(w http.ResponseWriter, r *http.Request) {
+	if s.MaxFailedLogins > 0 && s.failedLogins >= s.MaxFailedLogins {
+		http.Error(w, "Your IP address has been banned after too many failed authentication attempts.", http.StatusForbidden)
+		return
+	}
+	if s.Username != "" && (r.Form.Get("username") != s.Username || r.Form.Get("password") != s.Password) {
+		s.failedLogins++
+		_, _ = w.Write([]byte("Fails."))
+		return
+	}
+	s.failedLogins = 0
+	sid := newSID()
+	s.sessions[sid] = struct{}{}
+	http.SetCookie(w, &http.Cookie{Name: "SID", Value: sid, Path: "/", HttpOnly: true, SameSite: http.SameSiteStrictMode})
+	_, _ = w.Write([]byte("Ok."))
+}
+
+func (s *Server) logout(w http.ResponseWriter, r *http.Request) {
+	if cookie, err := r.Cookie("SID"); err == nil {
+		delete(s.sessions, cookie.Value)
+	}
+	ok(w, r)
+}
+
+func newSID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// ExpireSessions invalidates all SID cookies, following requests answer 403 until login again.
+func (s *Server) ExpireSessions() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions = make(map[string]struct{})
+}
+
+// Requests returns all requests received so far.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// RequestsTo returns requests of an endpoint, e.g. "torrents/stop".
+func (s *Server) RequestsTo(endpoint string) []Request {
+	var list []Request
+	for _, r := range s.Requests() {
+		if r.Path == "/api/v2/"+endpoint {
+			list = append(list, r)
+		}
+	}
+	return list
+}
+
+// requireParams answers 400 if any param is missing, like qBittorrent does.
+func requireParams(w http.ResponseWriter, r *http.Request, names ...string) bool {
+	for _, name := range names {
+		if !r.Form.Has(name) {
+			http.Error(w, fmt.Sprintf("Missing required parameter: '%s'", name), http.StatusBadRequest)
+			return false
+		}
+	}
+	return true
+}
+
+func ok(w http.ResponseWriter, _ *http.Request) {
+	w.WriteHeader(http.StatusOK)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func (s *Server) appVersion(w http.ResponseWriter, _ *http.Request) {
+	_, _ = w.Write([]byte(s.AppVersion))
+}
+
+func (s *Server) webapiVersion(w http.ResponseWriter, _ *http.Request) {
+	_, _ = w.Write([]byte(s.ApiVersion))
+}
+
+func (s *Server) buildInfo(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, map[string]any{
+		"qt":         "6.7.3",
+		"libtorrent": "2.0.10.0",
+		"boost":      "1.86.0",
+		"openssl":    "3.3.2",
+		"zlib":       "1.3.1",
+		"bitness":    64,
+		"platform":   "linux",
+	})
+}
+
+func defaultPreferences() map[string]any {
+	return map[string]any{
+		"save_path":              "/downloads",
+		"temp_path_enabled":      false,
+		"dl_limit":               0,
+		"up_limit":               0,
+		"alt_dl_limit":           10240,
+		"alt_up_limit":           10240,
+		"max_ratio_enabled":      false,
+		"max_ratio":              -1,
+		"max_seeding_time":       -1,
+		"queueing_enabled":       false,
+		"web_ui_username":        "admin",
+		"rss_processing_enabled": true,
+	}
+}
+
+func (s *Server) defaultSavePath(w http.ResponseWriter, _ *http.Request) {
+	_, _ = fmt.Fprint(w, s.preferences["save_path"])
+}
+
+func (s *Server) getPreferences(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, s.preferences)
+}
+
+func (s *Server) setPreferences(w http.ResponseWriter, r *http.Request) {
+	if !requireParams(w, r, "json") {
+		return
+	}
+	var prefs map[string]any
+	if err := json.Unmarshal([]byte(r.Form.Get("json")), &prefs); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	for k, v := range prefs {
+		s.preferences[k] = v
+	}
+}
+
+// Preference returns a preference value, numbers set by clients are float64.
+func (s *Server) Preference(key string) any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.preferences[key]
+}
+
+func (s *Server) SetPreference(key string, value any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.preferences[key] = value
+}
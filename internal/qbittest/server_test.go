@@ -0,0 +1,124 @@
+package qbittest_test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"qbit-cli/internal/api"
+	"qbit-cli/internal/config"
+	"qbit-cli/internal/qbittest"
+	"testing"
+)
+
+func TestLoginAndSessionExpiry(t *testing.T) {
+	s := qbittest.NewServer(qbittest.Options{Username: "admin", Password: "adminadmin"})
+	defer s.Close()
+	s.Configure(t)
+	s.AddTorrent(qbittest.Torrent{Hash: "aaa", Name: "ubuntu", Progress: 1})
+	ctx := context.Background()
+
+	list, err := api.TorrentList(ctx, url.Values{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].Name != "ubuntu" || list[0].State != "stalledUP" {
+		t.Fatalf("unexpected torrents %+v", list)
+	}
+
+	// expired SID is answered with 403, client logs in again and retries
+	s.ExpireSessions()
+	if _, err := api.TorrentList(ctx, url.Values{}); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(s.RequestsTo("auth/login")); n != 2 {
+		t.Fatalf("got %d logins, want 2", n)
+	}
+}
+
+func TestLoginFailed(t *testing.T) {
+	s := qbittest.NewServer(qbittest.Options{Username: "admin", Password: "secret"})
+	defer s.Close()
+	s.Configure(t)
+	config.GetConfig().Server.Password = "wrong"
+
+	_, err := api.TorrentList(context.Background(), url.Values{})
+	if !errors.Is(err, api.ErrAuth) {
+		t.Fatalf("got %v, want auth error", err)
+	}
+}
+
+func TestLegacyServer(t *testing.T) {
+	s := qbittest.NewServer(qbittest.Options{ApiVersion: "2.9.3"})
+	defer s.Close()
+	s.Configure(t)
+	s.AddTorrent(qbittest.Torrent{Hash: "aaa", Name: "a", Progress: 1})
+	s.AddTorrent(qbittest.Torrent{Hash: "bbb", Name: "b", Progress: 0.5})
+	ctx := context.Background()
+
+	if err := api.UpdateTorrent(ctx, "stop", url.Values{"hashes": {"aaa"}}); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(s.RequestsTo("torrents/pause")); n != 1 {
+		t.Fatalf("got %d torrents/pause requests, want 1", n)
+	}
+	list, err := api.TorrentList(ctx, url.Values{"filter": {"stopped"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].Hash != "aaa" || list[0].State != "pausedUP" {
+		t.Fatalf("unexpected stopped torrents %+v", list)
+	}
+}
+
+func TestCategoryConflict(t *testing.T) {
+	s := qbittest.NewServer(qbittest.Options{})
+	defer s.Close()
+	s.Configure(t)
+	ctx := context.Background()
+
+	if err := api.CategoryAdd(ctx, "movies", "/data/movies"); err != nil {
+		t.Fatal(err)
+	}
+	if err := api.CategoryAdd(ctx, "movies", "/data/movies"); !errors.Is(err, api.ErrConflict) {
+		t.Fatalf("got %v, want conflict", err)
+	}
+	if err := api.CategoryUpdate(ctx, "tv", "/data/tv"); !errors.Is(err, api.ErrNotFound) {
+		t.Fatalf("got %v, want not found", err)
+	}
+}
+
+func TestMainDataSync(t *testing.T) {
+	s := qbittest.NewServer(qbittest.Options{})
+	defer s.Close()
+	s.Configure(t)
+	s.AddTorrent(qbittest.Torrent{Hash: "aaa", Name: "a", Category: "movies"})
+	s.AddTorrent(qbittest.Torrent{Hash: "bbb", Name: "b"})
+	ctx := context.Background()
+
+	sync := api.NewMainDataSync()
+	event, err := sync.Update(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !event.FullUpdate || len(event.Added) != 2 {
+		t.Fatalf("unexpected first event %+v", event)
+	}
+
+	if err := api.UpdateTorrent(ctx, "delete", url.Values{"hashes": {"bbb"}, "deleteFiles": {"false"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := api.UpdateTorrent(ctx, "addTags", url.Values{"hashes": {"aaa"}, "tags": {"hd"}}); err != nil {
+		t.Fatal(err)
+	}
+	event, err = sync.Update(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.FullUpdate || len(event.Updated) != 1 || len(event.Removed) != 1 || !event.TagsChanged {
+		t.Fatalf("unexpected partial event %+v", event)
+	}
+	torrent, _ := sync.Torrent("aaa")
+	if torrent.Tags != "hd" || torrent.Category != "movies" {
+		t.Fatalf("partial update not merged: %+v", torrent)
+	}
+}
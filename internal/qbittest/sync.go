@@ -0,0 +1,205 @@
+package qbittest
+
+import (
+	"encoding/json"
+	"net/http"
+	"qbit-cli/internal/api"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// syncSnapshot is the state sent with the last rid, the next request with that rid only gets changes.
+type syncSnapshot struct {
+	rid         int64
+	torrents    map[string]map[string]any
+	categories  map[string]map[string]any
+	tags        map[string]struct{}
+	serverState map[string]any
+}
+
+func defaultServerState() map[string]any {
+	return map[string]any{
+		"connection_status":      "connected",
+		"dht_nodes":              300,
+		"dl_info_speed":          0,
+		"up_info_speed":          0,
+		"dl_rate_limit":          0,
+		"up_rate_limit":          0,
+		"free_space_on_disk":     int64(1) << 40,
+		"global_ratio":           "1.00",
+		"queueing":               false,
+		"use_alt_speed_limits":   false,
+		"refresh_interval":       1500,
+		"total_peer_connections": 0,
+	}
+}
+
+// SetServerState sets a field of server_state in /sync/maindata, e.g. free_space_on_disk.
+func (s *Server) SetServerState(key string, value any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.serverState[key] = value
+}
+
+// toMap converts v to its json object so that fields can be compared.
+func toMap(v any) map[string]any {
+	data, _ := json.Marshal(v)
+	m := make(map[string]any)
+	_ = json.Unmarshal(data, &m)
+	return m
+}
+
+func (s *Server) snapshot() *syncSnapshot {
+	snap := &syncSnapshot{
+		torrents:    make(map[string]map[string]any, len(s.torrents)),
+		categories:  make(map[string]map[string]any, len(s.categories)),
+		tags:        make(map[string]struct{}, len(s.tags)),
+		serverState: toMap(s.serverState),
+	}
+	for hash, t := range s.torrents {
+		m := toMap(t)
+		// hash is the key of torrents map
+		delete(m, "hash")
+		snap.torrents[hash] = m
+	}
+	for name, c := range s.categories {
+		snap.categories[name] = toMap(c)
+	}
+	for tag := range s.tags {
+		snap.tags[tag] = struct{}{}
+	}
+	return snap
+}
+
+// diffFields returns fields of cur which differ from prev.
+func diffFields(prev, cur map[string]any) map[string]any {
+	changed := make(map[string]any)
+	for k, v := range cur {
+		if old, ok := prev[k]; !ok || !reflect.DeepEqual(old, v) {
+			changed[k] = v
+		}
+	}
+	return changed
+}
+
+// diffObjects returns changed objects with changed fields only, and keys of removed objects.
+func diffObjects(prev, cur map[string]map[string]any) (map[string]map[string]any, []string) {
+	changed := make(map[string]map[string]any)
+	for key, obj := range cur {
+		old, ok := prev[key]
+		if !ok {
+			changed[key] = obj
+			continue
+		}
+		if fields := diffFields(old, obj); len(fields) > 0 {
+			changed[key] = fields
+		}
+	}
+	var removed []string
+	for key := range prev {
+		if _, ok := cur[key]; !ok {
+			removed = append(removed, key)
+		}
+	}
+	sort.Strings(removed)
+	return changed, removed
+}
+
+func setKeys(set map[string]struct{}) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// syncMainData answers full update for rid 0 or an unknown rid, otherwise changes since the rid.
+func (s *Server) syncMainData(w http.ResponseWriter, r *http.Request) {
+	rid, _ := strconv.ParseInt(r.Form.Get("rid"), 10, 64)
+	cur := s.snapshot()
+	prev := s.sync
+
+	data := make(map[string]any)
+	if prev == nil || rid == 0 || rid != prev.rid {
+		data["full_update"] = true
+		data["torrents"] = cur.torrents
+		data["categories"] = cur.categories
+		data["tags"] = setKeys(cur.tags)
+		data["server_state"] = cur.serverState
+	} else {
+		torrents, torrentsRemoved := diffObjects(prev.torrents, cur.torrents)
+		categories, categoriesRemoved := diffObjects(prev.categories, cur.categories)
+		if len(torrents) > 0 {
+			data["torrents"] = torrents
+		}
+		if len(torrentsRemoved) > 0 {
+			data["torrents_removed"] = torrentsRemoved
+		}
+		if len(categories) > 0 {
+			data["categories"] = categories
+		}
+		if len(categoriesRemoved) > 0 {
+			data["categories_removed"] = categoriesRemoved
+		}
+		var tags, tagsRemoved []string
+		for tag := range cur.tags {
+			if _, ok := prev.tags[tag]; !ok {
+				tags = append(tags, tag)
+			}
+		}
+		for tag := range prev.tags {
+			if _, ok := cur.tags[tag]; !ok {
+				tagsRemoved = append(tagsRemoved, tag)
+			}
+		}
+		if len(tags) > 0 {
+			sort.Strings(tags)
+			data["tags"] = tags
+		}
+		if len(tagsRemoved) > 0 {
+			sort.Strings(tagsRemoved)
+			data["tags_removed"] = tagsRemoved
+		}
+		if state := diffFields(prev.serverState, cur.serverState); len(state) > 0 {
+			data["server_state"] = state
+		}
+	}
+
+	cur.rid = 1
+	if prev != nil {
+		cur.rid = prev.rid + 1
+	}
+	s.sync = cur
+	data["rid"] = cur.rid
+	writeJSON(w, data)
+}
+
+// SetPeers replaces peers of a torrent, peers are keyed by ip:port.
+func (s *Server) SetPeers(hash string, peers map[string]api.TorrentPeer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if t, ok := s.torrents[hash]; ok {
+		t.Peers = peers
+	}
+}
+
+// syncTorrentPeers always answers full update.
+func (s *Server) syncTorrentPeers(w http.ResponseWriter, r *http.Request) {
+	t, ok := s.findTorrent(w, r)
+	if !ok {
+		return
+	}
+	rid, _ := strconv.ParseInt(r.Form.Get("rid"), 10, 64)
+	peers := t.Peers
+	if peers == nil {
+		peers = map[string]api.TorrentPeer{}
+	}
+	writeJSON(w, map[string]any{
+		"full_update": true,
+		"rid":         rid + 1,
+		"show_flags":  true,
+		"peers":       peers,
+	})
+}
@@ -0,0 +1,870 @@
+package qbittest
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"qbit-cli/internal/api"
+	"qbit-cli/pkg/metainfo"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Torrent is the state of a torrent kept by the fake server, json fields are the ones of /torrents/info.
+type Torrent struct {
+	Hash         string  `json:"hash"`
+	Name         string  `json:"name"`
+	Category     string  `json:"category"`
+	Tags         string  `json:"tags"`
+	State        string  `json:"state"`
+	SavePath     string  `json:"save_path"`
+	ContentPath  string  `json:"content_path"`
+	Comment      string  `json:"comment"`
+	Private      bool    `json:"private"`
+	Tracker      string  `json:"tracker"`
+	Progress     float64 `json:"progress"`
+	Size         int64   `json:"size"`
+	AddedOn      int64   `json:"added_on"`
+	DLSpeed      int64   `json:"dlspeed"`
+	UPSpeed      int64   `json:"upspeed"`
+	DlLimit      int64   `json:"dl_limit"`
+	UpLimit      int64   `json:"up_limit"`
+	Ratio        float64 `json:"ratio"`
+	SeedingTime  int64   `json:"seeding_time"`
+	DownloadPath string  `json:"download_path"`
+	// share limits, -2 means the global limit
+	RatioLimit               float64 `json:"ratio_limit"`
+	SeedingTimeLimit         int64   `json:"seeding_time_limit"`
+	InactiveSeedingTimeLimit int64   `json:"inactive_seeding_time_limit"`
+	Priority                 int     `json:"priority"`
+	AutoTMM                  bool    `json:"auto_tmm"`
+	ForceStart               bool    `json:"force_start"`
+	SeqDl                    bool    `json:"seq_dl"`
+	FLPiecePrio              bool    `json:"f_l_piece_prio"`
+	SuperSeeding             bool    `json:"super_seeding"`
+
+	// ShareLimitAction is set by torrents/setShareLimits, empty means Default
+	ShareLimitAction string `json:"-"`
+	// Metainfo is returned by torrents/export, torrents added by file keep the content
+	Metainfo []byte               `json:"-"`
+	Files    []api.TorrentFile    `json:"-"`
+	Trackers []api.TorrentTracker `json:"-"`
+	// Peers are keyed by ip:port
+	Peers map[string]api.TorrentPeer `json:"-"`
+}
+
+// baseAddedOn is used for torrents added without added_on so that the order of torrents is stable.
+const baseAddedOn = 1700000000
+
+func (t *Torrent) tagList() []string {
+	var tags []string
+	for _, tag := range strings.Split(t.Tags, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+func (t *Torrent) setTagList(tags []string) {
+	sort.Strings(tags)
+	t.Tags = strings.Join(tags, ", ")
+}
+
+func (t *Torrent) clone() Torrent {
+	c := *t
+	c.Metainfo = slices.Clone(t.Metainfo)
+	c.Files = slices.Clone(t.Files)
+	c.Trackers = slices.Clone(t.Trackers)
+	if t.Peers != nil {
+		c.Peers = make(map[string]api.TorrentPeer, len(t.Peers))
+		for k, v := range t.Peers {
+			c.Peers[k] = v
+		}
+	}
+	return c
+}
+
+func (t *Torrent) toApi() api.Torrent {
+	var torrent api.Torrent
+	data, _ := json.Marshal(t)
+	_ = json.Unmarshal(data, &torrent)
+	return torrent
+}
+
+// stoppedState is stoppedXX, or pausedXX before qBittorrent 5.0
+func (s *Server) stoppedState(t *Torrent) string {
+	prefix := "stopped"
+	if s.legacy() {
+		prefix = "paused"
+	}
+	if t.Progress >= 1 {
+		return prefix + "UP"
+	}
+	return prefix + "DL"
+}
+
+func runningState(t *Torrent) string {
+	if t.Progress >= 1 {
+		return "stalledUP"
+	}
+	return "stalledDL"
+}
+
+// AddTorrent adds or replaces a torrent, categories and tags of it are created if not exist.
+// Empty state, added_on, priority and files are filled with defaults.
+func (s *Server) AddTorrent(t Torrent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addTorrent(&t)
+}
+
+func (s *Server) addTorrent(t *Torrent) {
+	if t.State == "" {
+		t.State = runningState(t)
+	}
+	if t.AddedOn == 0 {
+		t.AddedOn = baseAddedOn + int64(len(s.torrents))
+	}
+	if t.Priority == 0 {
+		t.Priority = len(s.torrents) + 1
+	}
+	if t.SavePath == "" {
+		t.SavePath, _ = s.preferences["save_path"].(string)
+	}
+	if t.ContentPath == "" {
+		t.ContentPath = path.Join(t.SavePath, t.Name)
+	}
+	if t.Files == nil {
+		t.Files = []api.TorrentFile{{Name: t.Name, Priority: 1, Size: t.Size, Progress: t.Progress}}
+	}
+	for i := range t.Files {
+		t.Files[i].Index = int32(i)
+	}
+	if t.Category != "" {
+		if _, ok := s.categories[t.Category]; !ok {
+			s.categories[t.Category] = api.TorrentCategory{Name: t.Category}
+		}
+	}
+	tags := t.tagList()
+	for _, tag := range tags {
+		s.tags[tag] = struct{}{}
+	}
+	t.setTagList(tags)
+	s.torrents[t.Hash] = t
+}
+
+// Torrent returns a copy of the torrent.
+func (s *Server) Torrent(hash string) (Torrent, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.torrents[hash]
+	if !ok {
+		return Torrent{}, false
+	}
+	return t.clone(), true
+}
+
+// UpdateTorrent changes a torrent by fn, e.g. to make progress while a command is watching it.
+func (s *Server) UpdateTorrent(hash string, fn func(t *Torrent)) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.torrents[hash]
+	if ok {
+		fn(t)
+	}
+	return ok
+}
+
+// Torrents returns copies of all torrents sorted by added_on.
+func (s *Server) Torrents() []Torrent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := make([]Torrent, 0, len(s.torrents))
+	for _, t := range s.sortedTorrents() {
+		list = append(list, t.clone())
+	}
+	return list
+}
+
+func (s *Server) sortedTorrents() []*Torrent {
+	list := make([]*Torrent, 0, len(s.torrents))
+	for _, t := range s.torrents {
+		list = append(list, t)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].AddedOn == list[j].AddedOn {
+			return list[i].Hash < list[j].Hash
+		}
+		return list[i].AddedOn < list[j].AddedOn
+	})
+	return list
+}
+
+// selectTorrents returns torrents of hashes param, "all" selects all torrents and unknown hashes are ignored.
+func (s *Server) selectTorrents(hashes string) []*Torrent {
+	if hashes == "all" {
+		return s.sortedTorrents()
+	}
+	var list []*Torrent
+	for _, hash := range strings.Split(hashes, "|") {
+		if t, ok := s.torrents[strings.ToLower(hash)]; ok {
+			list = append(list, t)
+		}
+	}
+	return list
+}
+
+// findTorrent returns torrent of hash param, 404 is answered if not found.
+func (s *Server) findTorrent(w http.ResponseWriter, r *http.Request) (*Torrent, bool) {
+	if !requireParams(w, r, "hash") {
+		return nil, false
+	}
+	t, ok := s.torrents[strings.ToLower(r.Form.Get("hash"))]
+	if !ok {
+		http.Error(w, "Torrent hash was not found", http.StatusNotFound)
+	}
+	return t, ok
+}
+
+// matchFilter is the filter param of /torrents/info, filters of the other server version match all like unknown ones.
+func (s *Server) matchFilter(t *Torrent, filter string) bool {
+	switch filter {
+	case "paused", "resumed":
+		if !s.legacy() {
+			return true
+		}
+	case "stopped", "running":
+		if s.legacy() {
+			return true
+		}
+	}
+	torrent := t.toApi()
+	return torrent.MatchStateFilter(filter)
+}
+
+func (s *Server) torrentInfo(w http.ResponseWriter, r *http.Request) {
+	form := r.Form
+	var hashes []string
+	if form.Get("hashes") != "" {
+		hashes = strings.Split(strings.ToLower(form.Get("hashes")), "|")
+	}
+	list := make([]*Torrent, 0, len(s.torrents))
+	for _, t := range s.sortedTorrents() {
+		if !s.matchFilter(t, form.Get("filter")) {
+			continue
+		}
+		// empty category or tag param selects torrents without category or tag
+		if form.Has("category") && t.Category != form.Get("category") {
+			continue
+		}
+		if form.Has("tag") {
+			tag := form.Get("tag")
+			if (tag == "" && t.Tags != "") || (tag != "" && !slices.Contains(t.tagList(), tag)) {
+				continue
+			}
+		}
+		if hashes != nil && !slices.Contains(hashes, t.Hash) {
+			continue
+		}
+		list = append(list, t)
+	}
+	if field := form.Get("sort"); field != "" {
+		slices.SortStableFunc(list, func(a, b *Torrent) int {
+			x, y := a.toApi(), b.toApi()
+			return api.CompareTorrents(&x, &y, field)
+		})
+	}
+	if form.Get("reverse") == "true" {
+		slices.Reverse(list)
+	}
+	if offset, err := strconv.Atoi(form.Get("offset")); err == nil && offset > 0 {
+		list = list[min(offset, len(list)):]
+	}
+	if limit, err := strconv.Atoi(form.Get("limit")); err == nil && limit > 0 && limit < len(list) {
+		list = list[:limit]
+	}
+	writeJSON(w, list)
+}
+
+func (s *Server) torrentFiles(w http.ResponseWriter, r *http.Request) {
+	t, ok := s.findTorrent(w, r)
+	if !ok {
+		return
+	}
+	// index field is added in 2.8.2
+	if s.apiVersion.Less(api.ApiVersion{Major: 2, Minor: 8, Patch: 2}) {
+		files := make([]map[string]any, 0, len(t.Files))
+		for _, f := range t.Files {
+			files = append(files, map[string]any{"name": f.Name, "priority": f.Priority, "progress": f.Progress, "size": f.Size})
+		}
+		writeJSON(w, files)
+		return
+	}
+	writeJSON(w, t.Files)
+}
+
+func (s *Server) torrentTrackers(w http.ResponseWriter, r *http.Request) {
+	t, ok := s.findTorrent(w, r)
+	if !ok {
+		return
+	}
+	trackers := t.Trackers
+	if trackers == nil {
+		trackers = []api.TorrentTracker{}
+	}
+	writeJSON(w, trackers)
+}
+
+func (s *Server) torrentAddTrackers(w http.ResponseWriter, r *http.Request) {
+	t, ok := s.findTorrent(w, r)
+	if !ok || !requireParams(w, r, "urls") {
+		return
+	}
+	for _, u := range strings.Split(r.Form.Get("urls"), "\n") {
+		u = strings.TrimSpace(u)
+		if u == "" || slices.ContainsFunc(t.Trackers, func(tr api.TorrentTracker) bool { return tr.URL == u }) {
+			continue
+		}
+		t.Trackers = append(t.Trackers, api.TorrentTracker{URL: u, Status: 1, Tier: len(t.Trackers)})
+	}
+}
+
+func (s *Server) torrentEditTracker(w http.ResponseWriter, r *http.Request) {
+	t, ok := s.findTorrent(w, r)
+	if !ok || !requireParams(w, r, "origUrl", "newUrl") {
+		return
+	}
+	origUrl, newUrl := r.Form.Get("origUrl"), r.Form.Get("newUrl")
+	if u, err := url.Parse(newUrl); err != nil || u.Scheme == "" || u.Host == "" {
+		http.Error(w, "New tracker URL is invalid", http.StatusBadRequest)
+		return
+	}
+	index := -1
+	for i, tr := range t.Trackers {
+		switch tr.URL {
+		case newUrl:
+			http.Error(w, "New tracker URL already exists", http.StatusConflict)
+			return
+		case origUrl:
+			index = i
+		}
+	}
+	if index < 0 {
+		http.Error(w, "Tracker not found", http.StatusConflict)
+		return
+	}
+	t.Trackers[index].URL, t.Trackers[index].Status = newUrl, 1
+	if t.Tracker == origUrl {
+		t.Tracker = ""
+	}
+}
+
+func (s *Server) torrentRemoveTrackers(w http.ResponseWriter, r *http.Request) {
+	t, ok := s.findTorrent(w, r)
+	if !ok || !requireParams(w, r, "urls") {
+		return
+	}
+	urls := strings.Split(r.Form.Get("urls"), "|")
+	n := len(t.Trackers)
+	t.Trackers = slices.DeleteFunc(t.Trackers, func(tr api.TorrentTracker) bool { return slices.Contains(urls, tr.URL) })
+	if len(t.Trackers) == n {
+		http.Error(w, "No trackers were removed", http.StatusConflict)
+		return
+	}
+	if slices.Contains(urls, t.Tracker) {
+		t.Tracker = ""
+	}
+}
+
+// torrentExport returns Metainfo, or a minimal bencoded torrent of name and size if it is not set.
+func (s *Server) torrentExport(w http.ResponseWriter, r *http.Request) {
+	t, ok := s.findTorrent(w, r)
+	if !ok {
+		return
+	}
+	metainfo := t.Metainfo
+	if metainfo == nil {
+		pieces := strings.Repeat("\x00", sha1.Size*int(max((t.Size+pieceSize-1)/pieceSize, 1)))
+		metainfo = []byte(fmt.Sprintf("d4:infod6:lengthi%de4:name%d:%s12:piece lengthi%de6:pieces%d:%see",
+			t.Size, len(t.Name), t.Name, pieceSize, len(pieces), pieces))
+	}
+	w.Header().Set("Content-Type", "application/x-bittorrent")
+	_, _ = w.Write(metainfo)
+}
+
+// pieceSize of all fake torrents
+const pieceSize = 4 << 20
+
+func (s *Server) torrentProperties(w http.ResponseWriter, r *http.Request) {
+	t, ok := s.findTorrent(w, r)
+	if !ok {
+		return
+	}
+	pieces := (t.Size + pieceSize - 1) / pieceSize
+	completion := int64(-1)
+	if t.Progress >= 1 {
+		completion = t.AddedOn
+	}
+	writeJSON(w, api.TorrentGenericProperties{
+		Hash:           t.Hash,
+		InfohashV1:     t.Hash,
+		Name:           t.Name,
+		SavePath:       t.SavePath,
+		Comment:        t.Comment,
+		CreationDate:   -1,
+		IsPrivate:      t.Private,
+		HasMetadata:    true,
+		PieceSize:      pieceSize,
+		PiecesHave:     int64(float64(pieces) * t.Progress),
+		PiecesNum:      pieces,
+		TotalSize:      t.Size,
+		AdditionDate:   t.AddedOn,
+		CompletionDate: completion,
+		LastSeen:       -1,
+		Eta:            8640000,
+		ShareRatio:     t.Ratio,
+		DLSpeed:        t.DLSpeed,
+		UPSpeed:        t.UPSpeed,
+		DLLimit:        t.DlLimit,
+		UPLimit:        t.UpLimit,
+		Peers:          len(t.Peers),
+	})
+}
+
+// torrentAdd adds torrents of urls and torrent files, hash is btih of magnet or sha1 of url or file content.
+func (s *Server) torrentAdd(w http.ResponseWriter, r *http.Request) {
+	type source struct {
+		hash, name string
+		metainfo   []byte
+		// size is known of torrent files, magnets have no metadata yet
+		size int64
+	}
+	var sources []source
+	for _, u := range strings.Split(r.Form.Get("urls"), "\n") {
+		if u = strings.TrimSpace(u); u != "" {
+			hash, name := parseUrl(u)
+			sources = append(sources, source{hash: hash, name: name})
+		}
+	}
+	if r.MultipartForm != nil {
+		for _, fh := range r.MultipartForm.File["torrents"] {
+			f, err := fh.Open()
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			content, err := io.ReadAll(f)
+			_ = f.Close()
+			if err != nil || len(content) == 0 {
+				http.Error(w, "Torrent file is not valid", http.StatusUnsupportedMediaType)
+				return
+			}
+			m, err := metainfo.Parse(content)
+			if err != nil {
+				http.Error(w, "Torrent file is not valid", http.StatusUnsupportedMediaType)
+				return
+			}
+			hash := m.InfoHash
+			if hash == "" {
+				hash = m.InfoHashV2[:40]
+			}
+			sources = append(sources, source{hash, m.Name, content, m.Size})
+		}
+	}
+
+	form := r.Form
+	added := 0
+	for _, src := range sources {
+		if _, exists := s.torrents[src.hash]; exists {
+			continue
+		}
+		t := &Torrent{
+			Hash:     src.hash,
+			Name:     src.name,
+			Category: form.Get("category"),
+			Tags:     form.Get("tags"),
+			SavePath: form.Get("savepath"),
+			AutoTMM:  form.Get("autoTMM") == "true",
+			SeqDl:    form.Get("sequentialDownload") == "true",
+
+			DownloadPath: form.Get("downloadPath"),
+			FLPiecePrio:  form.Get("firstLastPiecePrio") == "true",
+			Metainfo:     src.metainfo,
+			Size:         src.size,
+		}
+		t.RatioLimit, t.SeedingTimeLimit, t.InactiveSeedingTimeLimit = -2, -2, -2
+		if v, err := strconv.ParseFloat(form.Get("ratioLimit"), 64); err == nil {
+			t.RatioLimit = v
+		}
+		if v, err := strconv.ParseInt(form.Get("seedingTimeLimit"), 10, 64); err == nil {
+			t.SeedingTimeLimit = v
+		}
+		if v, err := strconv.ParseInt(form.Get("inactiveSeedingTimeLimit"), 10, 64); err == nil {
+			t.InactiveSeedingTimeLimit = v
+		}
+		if form.Get("rename") != "" {
+			t.Name = form.Get("rename")
+		}
+		t.DlLimit, _ = strconv.ParseInt(form.Get("dlLimit"), 10, 64)
+		t.UpLimit, _ = strconv.ParseInt(form.Get("upLimit"), 10, 64)
+		if form.Get("stopped") == "true" || form.Get("paused") == "true" {
+			t.State = s.stoppedState(t)
+		}
+		s.addTorrent(t)
+		added++
+	}
+	if added == 0 {
+		_, _ = w.Write([]byte("Fails."))
+		return
+	}
+	_, _ = w.Write([]byte("Ok."))
+}
+
+func parseUrl(u string) (hash string, name string) {
+	if magnet, err := url.Parse(u); err == nil && magnet.Scheme == "magnet" {
+		query := magnet.Query()
+		for _, xt := range query["xt"] {
+			if btih, ok := strings.CutPrefix(xt, "urn:btih:"); ok {
+				hash = strings.ToLower(btih)
+			}
+		}
+		name = query.Get("dn")
+	}
+	if hash == "" {
+		sum := sha1.Sum([]byte(u))
+		hash = hex.EncodeToString(sum[:])
+	}
+	if name == "" {
+		name = strings.TrimSuffix(path.Base(u), ".torrent")
+	}
+	return hash, name
+}
+
+func (s *Server) torrentDelete(w http.ResponseWriter, r *http.Request) {
+	if !requireParams(w, r, "hashes", "deleteFiles") {
+		return
+	}
+	for _, t := range s.selectTorrents(r.Form.Get("hashes")) {
+		delete(s.torrents, t.Hash)
+	}
+}
+
+func (s *Server) torrentRename(w http.ResponseWriter, r *http.Request) {
+	t, ok := s.findTorrent(w, r)
+	if !ok || !requireParams(w, r, "name") {
+		return
+	}
+	name := strings.TrimSpace(r.Form.Get("name"))
+	if name == "" {
+		http.Error(w, "Incorrect torrent name", http.StatusConflict)
+		return
+	}
+	t.Name = name
+}
+
+func (s *Server) torrentRenameFile(w http.ResponseWriter, r *http.Request) {
+	t, ok := s.findTorrent(w, r)
+	if !ok || !requireParams(w, r, "oldPath", "newPath") {
+		return
+	}
+	oldPath, newPath := r.Form.Get("oldPath"), r.Form.Get("newPath")
+	index := slices.IndexFunc(t.Files, func(f api.TorrentFile) bool { return f.Name == oldPath })
+	exists := slices.ContainsFunc(t.Files, func(f api.TorrentFile) bool { return f.Name == newPath })
+	if index < 0 || exists || newPath == "" {
+		http.Error(w, "Invalid path", http.StatusConflict)
+		return
+	}
+	t.Files[index].Name = newPath
+}
+
+func (s *Server) torrentRenameFolder(w http.ResponseWriter, r *http.Request) {
+	t, ok := s.findTorrent(w, r)
+	if !ok || !requireParams(w, r, "oldPath", "newPath") {
+		return
+	}
+	oldPrefix, newPrefix := r.Form.Get("oldPath")+"/", r.Form.Get("newPath")+"/"
+	var matched []int
+	for i, f := range t.Files {
+		if strings.HasPrefix(f.Name, newPrefix) {
+			http.Error(w, "Folder already exists", http.StatusConflict)
+			return
+		}
+		if strings.HasPrefix(f.Name, oldPrefix) {
+			matched = append(matched, i)
+		}
+	}
+	if len(matched) == 0 || newPrefix == "/" {
+		http.Error(w, "Invalid path", http.StatusConflict)
+		return
+	}
+	for _, i := range matched {
+		t.Files[i].Name = newPrefix + strings.TrimPrefix(t.Files[i].Name, oldPrefix)
+	}
+}
+
+func (s *Server) torrentFilePrio(w http.ResponseWriter, r *http.Request) {
+	t, ok := s.findTorrent(w, r)
+	if !ok || !requireParams(w, r, "id", "priority") {
+		return
+	}
+	priority, err := strconv.Atoi(r.Form.Get("priority"))
+	if err != nil || !slices.Contains([]int{0, 1, 6, 7}, priority) {
+		http.Error(w, "Priority is not valid", http.StatusBadRequest)
+		return
+	}
+	var ids []int
+	for _, v := range strings.Split(r.Form.Get("id"), "|") {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			http.Error(w, "File IDs are not valid", http.StatusBadRequest)
+			return
+		}
+		if id < 0 || id >= len(t.Files) {
+			http.Error(w, "File ID is not valid", http.StatusConflict)
+			return
+		}
+		ids = append(ids, id)
+	}
+	for _, id := range ids {
+		t.Files[id].Priority = uint8(priority)
+	}
+}
+
+// updateTorrents returns handler of bulk operations on hashes param, nil fn only checks params.
+func (s *Server) updateTorrents(fn func(t *Torrent, form url.Values), required ...string) func(w http.ResponseWriter, r *http.Request) {
+	required = append([]string{"hashes"}, required...)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !requireParams(w, r, required...) || fn == nil {
+			return
+		}
+		for _, t := range s.selectTorrents(r.Form.Get("hashes")) {
+			fn(t, r.Form)
+		}
+	}
+}
+
+func setDownloadLimit(t *Torrent, form url.Values) {
+	t.DlLimit, _ = strconv.ParseInt(form.Get("limit"), 10, 64)
+}
+
+func setUploadLimit(t *Torrent, form url.Values) {
+	t.UpLimit, _ = strconv.ParseInt(form.Get("limit"), 10, 64)
+}
+
+// torrentSpeedLimits returns handler of torrents/downloadLimit and torrents/uploadLimit, limits are keyed by hash.
+func (s *Server) torrentSpeedLimits(limit func(t *Torrent) int64) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !requireParams(w, r, "hashes") {
+			return
+		}
+		limits := make(map[string]int64)
+		for _, t := range s.selectTorrents(r.Form.Get("hashes")) {
+			limits[t.Hash] = max(limit(t), 0)
+		}
+		writeJSON(w, limits)
+	}
+}
+
+// setShareLimits keeps the inactive seeding time and the action if they are missing, as older servers do.
+func setShareLimits(t *Torrent, form url.Values) {
+	t.RatioLimit, _ = strconv.ParseFloat(form.Get("ratioLimit"), 64)
+	t.SeedingTimeLimit, _ = strconv.ParseInt(form.Get("seedingTimeLimit"), 10, 64)
+	if v, err := strconv.ParseInt(form.Get("inactiveSeedingTimeLimit"), 10, 64); err == nil {
+		t.InactiveSeedingTimeLimit = v
+	}
+	if action := form.Get("shareLimitAction"); action != "" {
+		t.ShareLimitAction = action
+	}
+}
+
+// removeTags removes tags param from torrent, empty tags removes all.
+func removeTags(t *Torrent, form url.Values) {
+	remove := splitTags(form.Get("tags"))
+	var tags []string
+	for _, tag := range t.tagList() {
+		if len(remove) > 0 && !slices.Contains(remove, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	t.setTagList(tags)
+}
+
+func splitTags(tags string) []string {
+	var list []string
+	for _, tag := range strings.Split(tags, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			list = append(list, tag)
+		}
+	}
+	return list
+}
+
+func (s *Server) torrentSetCategory(w http.ResponseWriter, r *http.Request) {
+	if !requireParams(w, r, "hashes", "category") {
+		return
+	}
+	category := r.Form.Get("category")
+	if _, ok := s.categories[category]; category != "" && !ok {
+		http.Error(w, "Incorrect category name", http.StatusConflict)
+		return
+	}
+	for _, t := range s.selectTorrents(r.Form.Get("hashes")) {
+		t.Category = category
+	}
+}
+
+func (s *Server) torrentAddTags(w http.ResponseWriter, r *http.Request) {
+	if !requireParams(w, r, "hashes", "tags") {
+		return
+	}
+	add := splitTags(r.Form.Get("tags"))
+	for _, tag := range add {
+		s.tags[tag] = struct{}{}
+	}
+	for _, t := range s.selectTorrents(r.Form.Get("hashes")) {
+		tags := t.tagList()
+		for _, tag := range add {
+			if !slices.Contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+		t.setTagList(tags)
+	}
+}
+
+func (s *Server) torrentSetLocation(w http.ResponseWriter, r *http.Request) {
+	if !requireParams(w, r, "hashes", "location") {
+		return
+	}
+	location := strings.TrimSpace(r.Form.Get("location"))
+	if location == "" {
+		http.Error(w, "Save path cannot be empty", http.StatusBadRequest)
+		return
+	}
+	for _, t := range s.selectTorrents(r.Form.Get("hashes")) {
+		t.SavePath = location
+		t.AutoTMM = false
+	}
+}
+
+func (s *Server) tagList(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, s.tagNames())
+}
+
+func (s *Server) tagNames() []string {
+	tags := make([]string, 0, len(s.tags))
+	for tag := range s.tags {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// Tags returns all tags sorted.
+func (s *Server) Tags() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tagNames()
+}
+
+func (s *Server) createTags(w http.ResponseWriter, r *http.Request) {
+	if !requireParams(w, r, "tags") {
+		return
+	}
+	for _, tag := range splitTags(r.Form.Get("tags")) {
+		s.tags[tag] = struct{}{}
+	}
+}
+
+func (s *Server) deleteTags(w http.ResponseWriter, r *http.Request) {
+	if !requireParams(w, r, "tags") {
+		return
+	}
+	remove := splitTags(r.Form.Get("tags"))
+	for _, tag := range remove {
+		delete(s.tags, tag)
+	}
+	for _, t := range s.torrents {
+		tags := slices.DeleteFunc(t.tagList(), func(tag string) bool { return slices.Contains(remove, tag) })
+		t.setTagList(tags)
+	}
+}
+
+func (s *Server) categoryList(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, s.categories)
+}
+
+// AddCategory adds or replaces a category.
+func (s *Server) AddCategory(name, savePath string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.categories[name] = api.TorrentCategory{Name: name, SavePath: savePath}
+}
+
+// Categories returns all categories sorted by name.
+func (s *Server) Categories() []api.TorrentCategory {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := make([]api.TorrentCategory, 0, len(s.categories))
+	for _, c := range s.categories {
+		list = append(list, c)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+func (s *Server) createCategory(w http.ResponseWriter, r *http.Request) {
+	if !requireParams(w, r, "category") {
+		return
+	}
+	name := strings.TrimSpace(r.Form.Get("category"))
+	if name == "" {
+		http.Error(w, "Category cannot be empty", http.StatusBadRequest)
+		return
+	}
+	if _, exists := s.categories[name]; exists {
+		http.Error(w, "Unable to create category", http.StatusConflict)
+		return
+	}
+	s.categories[name] = api.TorrentCategory{Name: name, SavePath: r.Form.Get("savePath")}
+}
+
+func (s *Server) editCategory(w http.ResponseWriter, r *http.Request) {
+	if !requireParams(w, r, "category", "savePath") {
+		return
+	}
+	name := r.Form.Get("category")
+	if name == "" {
+		http.Error(w, "Category cannot be empty", http.StatusBadRequest)
+		return
+	}
+	if _, exists := s.categories[name]; !exists {
+		http.Error(w, "Unable to edit category", http.StatusConflict)
+		return
+	}
+	s.categories[name] = api.TorrentCategory{Name: name, SavePath: r.Form.Get("savePath")}
+}
+
+func (s *Server) removeCategories(w http.ResponseWriter, r *http.Request) {
+	if !requireParams(w, r, "categories") {
+		return
+	}
+	for _, name := range strings.Split(r.Form.Get("categories"), "\n") {
+		delete(s.categories, strings.TrimSpace(name))
+	}
+	for _, t := range s.torrents {
+		if _, ok := s.categories[t.Category]; !ok {
+			t.Category = ""
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package qbittest
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// transfer limits are kept in preferences, dl_rate_limit and up_rate_limit of server_state are the active ones.
+
+// BannedPeers returns peers banned by transfer/banPeers.
+func (s *Server) BannedPeers() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	banned, _ := s.preferences["banned_IPs"].(string)
+	if banned == "" {
+		return nil
+	}
+	return strings.Split(banned, "\n")
+}
+
+func (s *Server) transferInfo(w http.ResponseWriter, _ *http.Request) {
+	info := make(map[string]any)
+	for _, key := range []string{"dl_info_speed", "dl_info_data", "up_info_speed", "up_info_data",
+		"dl_rate_limit", "up_rate_limit", "dht_nodes", "connection_status"} {
+		if v, ok := s.serverState[key]; ok {
+			info[key] = v
+		} else {
+			info[key] = 0
+		}
+	}
+	writeJSON(w, info)
+}
+
+func (s *Server) altSpeed() bool {
+	alt, _ := s.serverState["use_alt_speed_limits"].(bool)
+	return alt
+}
+
+// limitKey is the preference of the active limit, direction is dl or up.
+func (s *Server) limitKey(direction string) string {
+	if s.altSpeed() {
+		return "alt_" + direction + "_limit"
+	}
+	return direction + "_limit"
+}
+
+func (s *Server) limit(direction string) int64 {
+	switch v := s.preferences[s.limitKey(direction)].(type) {
+	case int:
+		return int64(v)
+	case int64:
+		return v
+	case float64:
+		return int64(v)
+	}
+	return 0
+}
+
+// updateRateLimits copies the active limits to server_state.
+func (s *Server) updateRateLimits() {
+	s.serverState["dl_rate_limit"] = s.limit("dl")
+	s.serverState["up_rate_limit"] = s.limit("up")
+}
+
+func (s *Server) transferLimit(direction string) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprint(w, s.limit(direction))
+	}
+}
+
+func (s *Server) transferSetLimit(direction string) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !requireParams(w, r, "limit") {
+			return
+		}
+		limit, err := strconv.ParseInt(r.Form.Get("limit"), 10, 64)
+		if err != nil {
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+			return
+		}
+		s.preferences[s.limitKey(direction)] = max(limit, 0)
+		s.updateRateLimits()
+	}
+}
+
+func (s *Server) speedLimitsMode(w http.ResponseWriter, _ *http.Request) {
+	if s.altSpeed() {
+		_, _ = w.Write([]byte("1"))
+	} else {
+		_, _ = w.Write([]byte("0"))
+	}
+}
+
+func (s *Server) toggleSpeedLimitsMode(w http.ResponseWriter, _ *http.Request) {
+	s.serverState["use_alt_speed_limits"] = !s.altSpeed()
+	s.updateRateLimits()
+}
+
+// banPeers adds ips of host:port peers to banned_IPs, invalid peers are ignored as qBittorrent does.
+func (s *Server) banPeers(w http.ResponseWriter, r *http.Request) {
+	if !requireParams(w, r, "peers") {
+		return
+	}
+	banned, _ := s.preferences["banned_IPs"].(string)
+	var ips []string
+	if banned != "" {
+		ips = strings.Split(banned, "\n")
+	}
+	for _, peer := range strings.Split(r.Form.Get("peers"), "|") {
+		host, _, err := net.SplitHostPort(peer)
+		if err != nil || net.ParseIP(host) == nil {
+			continue
+		}
+		ips = append(ips, host)
+	}
+	s.preferences["banned_IPs"] = strings.Join(ips, "\n")
+}
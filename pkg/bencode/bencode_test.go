@@ -0,0 +1,59 @@
+package bencode
+
+import "testing"
+
+func TestMarshal(t *testing.T) {
+	type file struct {
+		Length int64    `bencode:"length"`
+		Path   []string `bencode:"path"`
+		Attr   string   `bencode:"attr,omitempty"`
+	}
+	tests := []struct {
+		v    any
+		want string
+	}{
+		{"spam", "4:spam"},
+		{[]byte{0, 1}, "2:\x00\x01"},
+		{-3, "i-3e"},
+		{true, "i1e"},
+		{[]any{"a", 1}, "l1:ai1ee"},
+		{map[string]any{"b": 1, "a": "x", "": 0}, "d0:i0e1:a1:x1:bi1ee"},
+		{file{Length: 2, Path: []string{"a", "b"}}, "d6:lengthi2e4:pathl1:a1:bee"},
+		{map[string]any{"info": RawMessage("d1:xi1ee")}, "d4:infod1:xi1eee"},
+	}
+	for _, tt := range tests {
+		got, err := Marshal(tt.v)
+		if err != nil || string(got) != tt.want {
+			t.Errorf("Marshal(%v) = %q, %v, want %q", tt.v, got, err, tt.want)
+		}
+	}
+	if _, err := Marshal(map[int]int{1: 1}); err == nil {
+		t.Error("int keys should be unsupported")
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	var v struct {
+		Name   string     `bencode:"name"`
+		Length int64      `bencode:"length"`
+		Path   []string   `bencode:"path"`
+		Info   RawMessage `bencode:"info"`
+		Any    any        `bencode:"any"`
+	}
+	data := "d3:anyld1:ai1eee4:infod1:xi1ee6:lengthi-2e4:name4:spam4:pathl1:a1:be7:unknownl1:xee"
+	if err := Unmarshal([]byte(data), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Name != "spam" || v.Length != -2 || len(v.Path) != 2 || string(v.Info) != "d1:xi1ee" {
+		t.Errorf("got %+v", v)
+	}
+	if list, ok := v.Any.([]any); !ok || list[0].(map[string]any)["a"] != int64(1) {
+		t.Errorf("got any %#v", v.Any)
+	}
+
+	for _, invalid := range []string{"", "i1", "5:abc", "d1:a", "l1:ae1:b", "x", "d4:namei1ee"} {
+		if err := Unmarshal([]byte(invalid), &v); err == nil {
+			t.Errorf("%q should be invalid", invalid)
+		}
+	}
+}
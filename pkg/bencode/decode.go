@@ -0,0 +1,282 @@
+package bencode
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// maxDepth limits nested lists and dictionaries, so that malicious input can't exhaust the stack.
+const maxDepth = 256
+
+// Unmarshal decodes data into v, which must be a non-nil pointer.
+// Values decoded into an interface are int64, string, []any and map[string]any,
+// unknown dictionary keys of structs are ignored.
+func Unmarshal(data []byte, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("bencode: Unmarshal requires a non-nil pointer, got %T", v)
+	}
+	d := &decoder{data: data}
+	if err := d.value(rv.Elem(), 0); err != nil {
+		return err
+	}
+	if d.pos != len(data) {
+		return d.errorf("trailing data")
+	}
+	return nil
+}
+
+type decoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *decoder) errorf(format string, args ...any) error {
+	return fmt.Errorf("bencode: %s at offset %d", fmt.Sprintf(format, args...), d.pos)
+}
+
+func (d *decoder) peek() (byte, error) {
+	if d.pos >= len(d.data) {
+		return 0, d.errorf("unexpected end of data")
+	}
+	return d.data[d.pos], nil
+}
+
+func (d *decoder) value(v reflect.Value, depth int) error {
+	if depth > maxDepth {
+		return d.errorf("nested too deep")
+	}
+	c, err := d.peek()
+	if err != nil {
+		return err
+	}
+	if v.Type() == reflect.TypeFor[RawMessage]() {
+		start := d.pos
+		if err := d.skip(depth); err != nil {
+			return err
+		}
+		v.SetBytes(bytes.Clone(d.data[start:d.pos]))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return d.value(v.Elem(), depth)
+	case reflect.Interface:
+		if v.NumMethod() != 0 {
+			return d.errorf("can't decode into %s", v.Type())
+		}
+		x, err := d.any(depth)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(x))
+		return nil
+	}
+
+	switch {
+	case c == 'i':
+		n, err := d.int()
+		if err != nil {
+			return err
+		}
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if v.OverflowInt(n) {
+				return d.errorf("%d overflows %s", n, v.Type())
+			}
+			v.SetInt(n)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if n < 0 || v.OverflowUint(uint64(n)) {
+				return d.errorf("%d overflows %s", n, v.Type())
+			}
+			v.SetUint(uint64(n))
+		case reflect.Bool:
+			v.SetBool(n != 0)
+		default:
+			return d.errorf("can't decode integer into %s", v.Type())
+		}
+	case c >= '0' && c <= '9':
+		s, err := d.string()
+		if err != nil {
+			return err
+		}
+		switch {
+		case v.Kind() == reflect.String:
+			v.SetString(string(s))
+		case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8:
+			v.SetBytes(bytes.Clone(s))
+		default:
+			return d.errorf("can't decode string into %s", v.Type())
+		}
+	case c == 'l':
+		if v.Kind() != reflect.Slice {
+			return d.errorf("can't decode list into %s", v.Type())
+		}
+		d.pos++
+		v.SetLen(0)
+		for {
+			if c, err := d.peek(); err != nil {
+				return err
+			} else if c == 'e' {
+				d.pos++
+				break
+			}
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if err := d.value(elem, depth+1); err != nil {
+				return err
+			}
+			v.Set(reflect.Append(v, elem))
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeSlice(v.Type(), 0, 0))
+		}
+	case c == 'd':
+		return d.dict(v, depth)
+	default:
+		return d.errorf("invalid character %q", c)
+	}
+	return nil
+}
+
+func (d *decoder) dict(v reflect.Value, depth int) error {
+	var fields map[string]field
+	switch {
+	case v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String:
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+	case v.Kind() == reflect.Struct:
+		fields = make(map[string]field)
+		for _, f := range structFields(v.Type()) {
+			fields[f.name] = f
+		}
+	default:
+		return d.errorf("can't decode dictionary into %s", v.Type())
+	}
+	d.pos++
+	for {
+		c, err := d.peek()
+		if err != nil {
+			return err
+		}
+		if c == 'e' {
+			d.pos++
+			return nil
+		}
+		key, err := d.string()
+		if err != nil {
+			return err
+		}
+		if fields == nil {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if err := d.value(elem, depth+1); err != nil {
+				return err
+			}
+			v.SetMapIndex(reflect.ValueOf(string(key)).Convert(v.Type().Key()), elem)
+			continue
+		}
+		f, ok := fields[string(key)]
+		if !ok {
+			if err := d.skip(depth + 1); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := d.value(v.FieldByIndex(f.index), depth+1); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+	}
+}
+
+// any decodes the next value into the generic types.
+func (d *decoder) any(depth int) (any, error) {
+	if depth > maxDepth {
+		return nil, d.errorf("nested too deep")
+	}
+	c, err := d.peek()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case c == 'i':
+		return d.int()
+	case c >= '0' && c <= '9':
+		s, err := d.string()
+		return string(s), err
+	case c == 'l':
+		d.pos++
+		list := []any{}
+		for {
+			if c, err := d.peek(); err != nil {
+				return nil, err
+			} else if c == 'e' {
+				d.pos++
+				return list, nil
+			}
+			x, err := d.any(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, x)
+		}
+	case c == 'd':
+		d.pos++
+		dict := map[string]any{}
+		for {
+			if c, err := d.peek(); err != nil {
+				return nil, err
+			} else if c == 'e' {
+				d.pos++
+				return dict, nil
+			}
+			key, err := d.string()
+			if err != nil {
+				return nil, err
+			}
+			x, err := d.any(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			dict[string(key)] = x
+		}
+	}
+	return nil, d.errorf("invalid character %q", c)
+}
+
+// skip moves over the next value.
+func (d *decoder) skip(depth int) error {
+	_, err := d.any(depth)
+	return err
+}
+
+func (d *decoder) int() (int64, error) {
+	end := bytes.IndexByte(d.data[d.pos:], 'e')
+	if end < 0 {
+		return 0, d.errorf("unterminated integer")
+	}
+	n, err := strconv.ParseInt(string(d.data[d.pos+1:d.pos+end]), 10, 64)
+	if err != nil {
+		return 0, d.errorf("invalid integer %q", d.data[d.pos+1:d.pos+end])
+	}
+	d.pos += end + 1
+	return n, nil
+}
+
+func (d *decoder) string() ([]byte, error) {
+	colon := bytes.IndexByte(d.data[d.pos:], ':')
+	if colon < 0 {
+		return nil, d.errorf("invalid string length")
+	}
+	n, err := strconv.Atoi(string(d.data[d.pos : d.pos+colon]))
+	if err != nil || n < 0 || n > len(d.data)-d.pos-colon-1 {
+		return nil, d.errorf("invalid string length %q", d.data[d.pos:d.pos+colon])
+	}
+	start := d.pos + colon + 1
+	d.pos = start + n
+	return d.data[start:d.pos], nil
+}
@@ -0,0 +1,169 @@
+// Package bencode implements the encoding and decoding of BitTorrent metainfo files(BEP 3).
+//
+// Strings and []byte are byte strings, all integer types and bool(as 0 or 1) are integers,
+// slices and arrays are lists, maps with string keys and structs are dictionaries.
+// Struct fields are named by the bencode tag, e.g. `bencode:"piece length"`,
+// "-" skips the field and omitempty skips zero values.
+package bencode
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Marshal returns the bencoding of v, dictionary keys are sorted as required by the spec.
+func Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+type Encoder struct {
+	w   io.Writer
+	buf []byte
+}
+
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// Encode writes the bencoding of v.
+func (e *Encoder) Encode(v any) error {
+	e.buf = e.buf[:0]
+	if err := e.encode(reflect.ValueOf(v)); err != nil {
+		return err
+	}
+	_, err := e.w.Write(e.buf)
+	return err
+}
+
+var bytesType = reflect.TypeFor[[]byte]()
+
+func (e *Encoder) encode(v reflect.Value) error {
+	if !v.IsValid() {
+		return fmt.Errorf("bencode: can't encode nil")
+	}
+	if v.Type() == reflect.TypeFor[RawMessage]() {
+		e.buf = append(e.buf, v.Bytes()...)
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Interface, reflect.Pointer:
+		if v.IsNil() {
+			return fmt.Errorf("bencode: can't encode nil %s", v.Type())
+		}
+		return e.encode(v.Elem())
+	case reflect.String:
+		e.string(v.String())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		e.int(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		e.buf = append(e.buf, 'i')
+		e.buf = strconv.AppendUint(e.buf, v.Uint(), 10)
+		e.buf = append(e.buf, 'e')
+	case reflect.Bool:
+		if v.Bool() {
+			e.int(1)
+		} else {
+			e.int(0)
+		}
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			if v.Kind() == reflect.Slice {
+				e.string(string(v.Convert(bytesType).Bytes()))
+			} else {
+				b := make([]byte, v.Len())
+				reflect.Copy(reflect.ValueOf(b), v)
+				e.string(string(b))
+			}
+			return nil
+		}
+		e.buf = append(e.buf, 'l')
+		for i := range v.Len() {
+			if err := e.encode(v.Index(i)); err != nil {
+				return err
+			}
+		}
+		e.buf = append(e.buf, 'e')
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("bencode: unsupported map key type %s", v.Type().Key())
+		}
+		keys := v.MapKeys()
+		slices.SortFunc(keys, func(a, b reflect.Value) int { return strings.Compare(a.String(), b.String()) })
+		e.buf = append(e.buf, 'd')
+		for _, k := range keys {
+			e.string(k.String())
+			if err := e.encode(v.MapIndex(k)); err != nil {
+				return fmt.Errorf("%s: %w", k.String(), err)
+			}
+		}
+		e.buf = append(e.buf, 'e')
+	case reflect.Struct:
+		fields := structFields(v.Type())
+		e.buf = append(e.buf, 'd')
+		for _, f := range fields {
+			fv := v.FieldByIndex(f.index)
+			if f.omitEmpty && fv.IsZero() {
+				continue
+			}
+			e.string(f.name)
+			if err := e.encode(fv); err != nil {
+				return fmt.Errorf("%s: %w", f.name, err)
+			}
+		}
+		e.buf = append(e.buf, 'e')
+	default:
+		return fmt.Errorf("bencode: unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+func (e *Encoder) string(s string) {
+	e.buf = strconv.AppendInt(e.buf, int64(len(s)), 10)
+	e.buf = append(e.buf, ':')
+	e.buf = append(e.buf, s...)
+}
+
+func (e *Encoder) int(n int64) {
+	e.buf = append(e.buf, 'i')
+	e.buf = strconv.AppendInt(e.buf, n, 10)
+	e.buf = append(e.buf, 'e')
+}
+
+// RawMessage is an encoded value, it is written and decoded as is, so that the info dictionary keeps the original bytes.
+type RawMessage []byte
+
+type field struct {
+	name      string
+	index     []int
+	omitEmpty bool
+}
+
+// structFields returns the exported fields of t sorted by name.
+func structFields(t reflect.Type) []field {
+	var fields []field
+	for _, sf := range reflect.VisibleFields(t) {
+		if !sf.IsExported() || sf.Anonymous {
+			continue
+		}
+		tag := sf.Tag.Get("bencode")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = sf.Name
+		}
+		fields = append(fields, field{name: name, index: sf.Index, omitEmpty: opts == "omitempty"})
+	}
+	slices.SortFunc(fields, func(a, b field) int { return strings.Compare(a.name, b.name) })
+	return fields
+}
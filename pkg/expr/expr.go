@@ -0,0 +1,339 @@
+// Package expr implements the filter expressions used by --where, e.g.
+//
+//	ratio >= 2 && seeding_time > 14d && category == "movie" && !tags.contains("keep")
+//
+// Values are numbers, strings, booleans and lists of strings. Number literals may have a unit:
+// sizes B, KB, MB, GB, TB(1024 based, case-insensitive, KiB and so on are the same), durations
+// s, m, h, d, w(seconds, can be combined like 1d12h) or %(divided by 100).
+// Strings are quoted by " or '.
+//
+// Operators by precedence from low to high:
+//
+//	||
+//	&&
+//	!
+//	== != < <= > >= =~ !~
+//	+ -
+//
+// =~ and !~ match a regular expression, a list matches if any of its items matches.
+// Methods are contains(s), startsWith(s), endsWith(s) and matches(regexp), contains of a list checks its items.
+// now is the current unix time in seconds, e.g. added_on < now - 7d.
+package expr
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Env resolves field values, numbers may be any int or float type.
+type Env func(name string) (any, bool)
+
+// Expr is a compiled expression, it is safe for concurrent use.
+type Expr struct {
+	src  string
+	root node
+}
+
+// Compile parses src, fields are the names which can be referred to.
+func Compile(src string, fields []string) (*Expr, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, fmt.Errorf("where: %w", err)
+	}
+	p := &parser{tokens: tokens, fields: make(map[string]bool, len(fields))}
+	for _, f := range fields {
+		p.fields[f] = true
+	}
+	root, err := p.parseOr()
+	if err == nil && p.peek().kind != tokEOF {
+		err = p.errorf(p.peek(), "unexpected %s", p.peek())
+	}
+	if err != nil {
+		if p.unknown != "" {
+			names := append([]string(nil), fields...)
+			sort.Strings(names)
+			err = fmt.Errorf("%w, available: %s", err, strings.Join(names, ","))
+		}
+		return nil, fmt.Errorf("where: %w", err)
+	}
+	return &Expr{src: src, root: root}, nil
+}
+
+func (e *Expr) String() string {
+	return e.src
+}
+
+// Match evaluates the expression, it must result in a boolean.
+func (e *Expr) Match(env Env) (bool, error) {
+	v, err := e.root.eval(&evalContext{env: env, now: time.Now()})
+	if err != nil {
+		return false, fmt.Errorf("where: %w", err)
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("where: %q is %s, not a boolean", e.src, typeName(v))
+	}
+	return b, nil
+}
+
+type evalContext struct {
+	env Env
+	now time.Time
+}
+
+type node interface {
+	eval(ctx *evalContext) (any, error)
+}
+
+type literal struct{ value any }
+
+func (n *literal) eval(*evalContext) (any, error) { return n.value, nil }
+
+type field struct{ name string }
+
+func (n *field) eval(ctx *evalContext) (any, error) {
+	v, ok := ctx.env(n.name)
+	if !ok {
+		return nil, fmt.Errorf("field %s is unavailable", n.name)
+	}
+	return normalize(v), nil
+}
+
+type nowNode struct{}
+
+func (n *nowNode) eval(ctx *evalContext) (any, error) { return float64(ctx.now.Unix()), nil }
+
+type unary struct {
+	op string
+	x  node
+}
+
+func (n *unary) eval(ctx *evalContext) (any, error) {
+	v, err := n.x.eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "!":
+		if b, ok := v.(bool); ok {
+			return !b, nil
+		}
+	case "-":
+		if f, ok := v.(float64); ok {
+			return -f, nil
+		}
+	}
+	return nil, fmt.Errorf("operator %s is not defined on %s", n.op, typeName(v))
+}
+
+type logical struct {
+	op   string
+	l, r node
+}
+
+func (n *logical) eval(ctx *evalContext) (any, error) {
+	l, err := evalBool(ctx, n.l, n.op)
+	if err != nil {
+		return nil, err
+	}
+	// short circuit
+	if (n.op == "&&") != l {
+		return l, nil
+	}
+	return evalBool(ctx, n.r, n.op)
+}
+
+func evalBool(ctx *evalContext, n node, op string) (bool, error) {
+	v, err := n.eval(ctx)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("operator %s is not defined on %s", op, typeName(v))
+	}
+	return b, nil
+}
+
+type binary struct {
+	op   string
+	l, r node
+}
+
+func (n *binary) eval(ctx *evalContext) (any, error) {
+	l, err := n.l.eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+	r, err := n.r.eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "+", "-":
+		lf, lok := l.(float64)
+		rf, rok := r.(float64)
+		if !lok || !rok {
+			return nil, fmt.Errorf("operator %s is not defined on %s and %s", n.op, typeName(l), typeName(r))
+		}
+		if n.op == "+" {
+			return lf + rf, nil
+		}
+		return lf - rf, nil
+	}
+	c, err := compare(l, r, n.op)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "==":
+		return c == 0, nil
+	case "!=":
+		return c != 0, nil
+	case "<":
+		return c < 0, nil
+	case "<=":
+		return c <= 0, nil
+	case ">":
+		return c > 0, nil
+	}
+	return c >= 0, nil
+}
+
+// compare returns -1, 0 or 1, booleans are only comparable by == and !=.
+func compare(l, r any, op string) (int, error) {
+	switch l := l.(type) {
+	case float64:
+		if r, ok := r.(float64); ok {
+			switch {
+			case l < r:
+				return -1, nil
+			case l > r:
+				return 1, nil
+			}
+			return 0, nil
+		}
+	case string:
+		if r, ok := r.(string); ok {
+			return strings.Compare(l, r), nil
+		}
+	case bool:
+		if r, ok := r.(bool); ok && (op == "==" || op == "!=") {
+			if l == r {
+				return 0, nil
+			}
+			return 1, nil
+		}
+	}
+	return 0, fmt.Errorf("operator %s is not defined on %s and %s", op, typeName(l), typeName(r))
+}
+
+type match struct {
+	x   node
+	re  *regexp.Regexp
+	not bool
+}
+
+func (n *match) eval(ctx *evalContext) (any, error) {
+	v, err := n.x.eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var matched bool
+	switch v := v.(type) {
+	case string:
+		matched = n.re.MatchString(v)
+	case []string:
+		for _, s := range v {
+			if matched = n.re.MatchString(s); matched {
+				break
+			}
+		}
+	default:
+		return nil, fmt.Errorf("regexp can't match %s", typeName(v))
+	}
+	return matched != n.not, nil
+}
+
+type call struct {
+	method string
+	recv   node
+	arg    string
+}
+
+var methods = []string{"contains", "startsWith", "endsWith", "matches"}
+
+func (n *call) eval(ctx *evalContext) (any, error) {
+	v, err := n.recv.eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var test func(string) bool
+	switch n.method {
+	case "contains":
+		if list, ok := v.([]string); ok {
+			for _, s := range list {
+				if s == n.arg {
+					return true, nil
+				}
+			}
+			return false, nil
+		}
+		test = func(s string) bool { return strings.Contains(s, n.arg) }
+	case "startsWith":
+		test = func(s string) bool { return strings.HasPrefix(s, n.arg) }
+	case "endsWith":
+		test = func(s string) bool { return strings.HasSuffix(s, n.arg) }
+	}
+	s, ok := v.(string)
+	if !ok {
+		return nil, fmt.Errorf("method %s is not defined on %s", n.method, typeName(v))
+	}
+	return test(s), nil
+}
+
+// normalize converts numbers to float64 and string slices to []string.
+func normalize(v any) any {
+	switch v := v.(type) {
+	case int:
+		return float64(v)
+	case int8:
+		return float64(v)
+	case int16:
+		return float64(v)
+	case int32:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case uint:
+		return float64(v)
+	case uint8:
+		return float64(v)
+	case uint16:
+		return float64(v)
+	case uint32:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	}
+	return v
+}
+
+func typeName(v any) string {
+	switch v.(type) {
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case []string:
+		return "list"
+	}
+	return fmt.Sprintf("%T", v)
+}
@@ -0,0 +1,99 @@
+package expr
+
+import (
+	"strings"
+	"testing"
+)
+
+var fields = map[string]any{
+	"name":         "Ubuntu 24.04 LTS",
+	"category":     "movie",
+	"tags":         []string{"linux", "iso"},
+	"ratio":        2.5,
+	"seeding_time": int64(15 * 86400),
+	"size":         int64(3 << 30),
+	"progress":     0.5,
+	"private":      true,
+	"added_on":     int64(1700000000),
+}
+
+func names() []string {
+	list := make([]string, 0, len(fields))
+	for name := range fields {
+		list = append(list, name)
+	}
+	return list
+}
+
+func env(name string) (any, bool) {
+	v, ok := fields[name]
+	return v, ok
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		src  string
+		want bool
+	}{
+		{`ratio >= 2 && seeding_time > 14d && category == "movie" && !tags.contains("keep")`, true},
+		{`tags.contains("iso") && !(ratio < 1 || private == false)`, true},
+		{`size > 2.5GB && size <= 3gib`, true},
+		{`seeding_time > 2w1d`, false},
+		{`progress >= 50% && progress < 0.6`, true},
+		{`name =~ '(?i)^ubuntu' && name !~ "Debian"`, true},
+		{`tags =~ "^lin" && name.matches("\\d+\\.\\d+")`, true},
+		{`name.startsWith("Ubuntu") && name.endsWith("LTS") && name.contains("24")`, true},
+		{`added_on < now - 7d && ratio - 0.5 == 2`, true},
+		{`category != 'movie' || -ratio > -2`, false},
+		{`private`, true},
+	}
+	for _, tt := range tests {
+		e, err := Compile(tt.src, names())
+		if err != nil {
+			t.Errorf("%s: %v", tt.src, err)
+			continue
+		}
+		if got, err := e.Match(env); err != nil || got != tt.want {
+			t.Errorf("%s: got %v %v, want %v", tt.src, got, err, tt.want)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	compileErrors := map[string]string{
+		`ratio >`:              "unexpected end of expression",
+		`ratio > 2x`:           `unknown unit "x"`,
+		`seeding_time > 1d2`:   "invalid duration",
+		`name == "abc`:         "unterminated string",
+		`nme == "abc"`:         "unknown field \"nme\" at 0, available: added_on,category",
+		`name =~ "("`:          "invalid regexp",
+		`name.lower()`:         "unknown method",
+		`(ratio > 1`:           `expected ")"`,
+		`ratio > 1 category`:   `unexpected "category"`,
+		`name.contains(ratio)`: "contains expects a string",
+		`name == é`:            `unknown field "é" at 8`,
+		`名前 == "a"`:            `unknown field "名前" at 0`,
+		`name == "a" § ratio`:  `unexpected '§' at 12`,
+	}
+	for src, want := range compileErrors {
+		if _, err := Compile(src, names()); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: got %v, want %s", src, err, want)
+		}
+	}
+
+	evalErrors := map[string]string{
+		`ratio`:               "not a boolean",
+		`name > 1`:            "not defined on string and number",
+		`private && ratio`:    "not defined on number",
+		`ratio.contains("a")`: "not defined on number",
+	}
+	for src, want := range evalErrors {
+		e, err := Compile(src, names())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := e.Match(env); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: got %v, want %s", src, err, want)
+		}
+	}
+}
@@ -0,0 +1,384 @@
+package expr
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNumber
+	tokString
+	tokIdent
+	tokOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	num  float64
+	pos  int
+}
+
+func (t token) String() string {
+	if t.kind == tokEOF {
+		return "end of expression"
+	}
+	return strconv.Quote(t.text)
+}
+
+// units of number literals, sizes are matched case-insensitively
+var (
+	sizeUnits = map[string]float64{
+		"b":  1,
+		"kb": 1 << 10, "kib": 1 << 10,
+		"mb": 1 << 20, "mib": 1 << 20,
+		"gb": 1 << 30, "gib": 1 << 30,
+		"tb": 1 << 40, "tib": 1 << 40,
+	}
+	durationUnits = map[string]float64{"s": 1, "m": 60, "h": 3600, "d": 86400, "w": 7 * 86400}
+)
+
+var operators = []string{"&&", "||", "==", "!=", "<=", ">=", "=~", "!~", "<", ">", "!", "+", "-", "(", ")", ".", ","}
+
+func lex(src string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(src); {
+		c, size := utf8.DecodeRuneInString(src[i:])
+		switch {
+		case unicode.IsSpace(c):
+			i += size
+		case c >= '0' && c <= '9':
+			t, end, err := lexNumber(src, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, t)
+			i = end
+		case c == '"' || c == '\'':
+			t, end, err := lexString(src, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, t)
+			i = end
+		case isIdentStart(c):
+			end := i + size
+			for end < len(src) {
+				r, n := utf8.DecodeRuneInString(src[end:])
+				if !isIdentStart(r) && !unicode.IsDigit(r) {
+					break
+				}
+				end += n
+			}
+			tokens = append(tokens, token{kind: tokIdent, text: src[i:end], pos: i})
+			i = end
+		default:
+			op := ""
+			for _, o := range operators {
+				if strings.HasPrefix(src[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected %q at %d", c, i)
+			}
+			tokens = append(tokens, token{kind: tokOp, text: op, pos: i})
+			i += len(op)
+		}
+	}
+	return append(tokens, token{kind: tokEOF, pos: len(src)}), nil
+}
+
+// isIdentStart reports whether c can start an identifier, the rest of it may contain digits as well.
+func isIdentStart(c rune) bool {
+	return c == '_' || unicode.IsLetter(c)
+}
+
+func isAlnum(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// lexNumber reads a number with an optional unit, durations can be combined like 1d12h.
+func lexNumber(src string, start int) (token, int, error) {
+	var total float64
+	i := start
+	for {
+		begin := i
+		for i < len(src) && (src[i] >= '0' && src[i] <= '9' || src[i] == '.') {
+			i++
+		}
+		n, err := strconv.ParseFloat(src[begin:i], 64)
+		if err != nil {
+			return token{}, 0, fmt.Errorf("invalid number %q at %d", src[begin:i], begin)
+		}
+		unitStart := i
+		for i < len(src) && (src[i] == '%' || isAlnum(src[i]) && !(src[i] >= '0' && src[i] <= '9')) {
+			i++
+		}
+		unit := src[unitStart:i]
+		if d, ok := durationUnits[unit]; ok {
+			total += n * d
+			// another part of duration
+			if i < len(src) && src[i] >= '0' && src[i] <= '9' {
+				continue
+			}
+			break
+		}
+		if begin != start {
+			return token{}, 0, fmt.Errorf("invalid duration %q at %d", src[start:i], start)
+		}
+		switch {
+		case unit == "":
+			total = n
+		case unit == "%":
+			total = n / 100
+		case sizeUnits[strings.ToLower(unit)] > 0:
+			total = n * sizeUnits[strings.ToLower(unit)]
+		default:
+			return token{}, 0, fmt.Errorf("unknown unit %q at %d", unit, unitStart)
+		}
+		break
+	}
+	return token{kind: tokNumber, text: src[start:i], num: total, pos: start}, i, nil
+}
+
+func lexString(src string, start int) (token, int, error) {
+	quote := src[start]
+	var b strings.Builder
+	for i := start + 1; i < len(src); i++ {
+		switch src[i] {
+		case '\\':
+			if i+1 < len(src) {
+				i++
+				b.WriteByte(src[i])
+			}
+		case quote:
+			return token{kind: tokString, text: b.String(), pos: start}, i + 1, nil
+		default:
+			b.WriteByte(src[i])
+		}
+	}
+	return token{}, 0, fmt.Errorf("unterminated string at %d", start)
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+	fields map[string]bool
+	// unknown is the unknown field, available fields are listed then
+	unknown string
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) accept(ops ...string) (string, bool) {
+	if t := p.peek(); t.kind == tokOp && slices.Contains(ops, t.text) {
+		p.pos++
+		return t.text, true
+	}
+	return "", false
+}
+
+func (p *parser) expect(op string) error {
+	if _, ok := p.accept(op); !ok {
+		return p.errorf(p.peek(), "expected %q, got %s", op, p.peek())
+	}
+	return nil
+}
+
+func (p *parser) errorf(t token, format string, args ...any) error {
+	return fmt.Errorf("%s at %d", fmt.Sprintf(format, args...), t.pos)
+}
+
+func (p *parser) parseOr() (node, error) {
+	l, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.accept("||"); !ok {
+			return l, nil
+		}
+		r, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l = &logical{op: "||", l: l, r: r}
+	}
+}
+
+func (p *parser) parseAnd() (node, error) {
+	l, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.accept("&&"); !ok {
+			return l, nil
+		}
+		r, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		l = &logical{op: "&&", l: l, r: r}
+	}
+}
+
+func (p *parser) parseNot() (node, error) {
+	if _, ok := p.accept("!"); ok {
+		x, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &unary{op: "!", x: x}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (node, error) {
+	l, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	op, ok := p.accept("==", "!=", "<", "<=", ">", ">=", "=~", "!~")
+	if !ok {
+		return l, nil
+	}
+	if op == "=~" || op == "!~" {
+		re, err := p.parseRegexp()
+		if err != nil {
+			return nil, err
+		}
+		return &match{x: l, re: re, not: op == "!~"}, nil
+	}
+	r, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	return &binary{op: op, l: l, r: r}, nil
+}
+
+// parseRegexp reads a string literal and compiles it, so that invalid patterns are reported before evaluation.
+func (p *parser) parseRegexp() (*regexp.Regexp, error) {
+	t := p.next()
+	if t.kind != tokString {
+		return nil, p.errorf(t, "expected a regexp string, got %s", t)
+	}
+	re, err := regexp.Compile(t.text)
+	if err != nil {
+		return nil, p.errorf(t, "invalid regexp: %v", err)
+	}
+	return re, nil
+}
+
+func (p *parser) parseSum() (node, error) {
+	l, err := p.parsePostfix()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.accept("+", "-")
+		if !ok {
+			return l, nil
+		}
+		r, err := p.parsePostfix()
+		if err != nil {
+			return nil, err
+		}
+		l = &binary{op: op, l: l, r: r}
+	}
+}
+
+func (p *parser) parsePostfix() (node, error) {
+	x, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.accept("."); !ok {
+			return x, nil
+		}
+		t := p.next()
+		if t.kind != tokIdent || !slices.Contains(methods, t.text) {
+			return nil, p.errorf(t, "unknown method %s, available: %s", t, strings.Join(methods, ","))
+		}
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		c := &call{method: t.text, recv: x}
+		if t.text == "matches" {
+			re, err := p.parseRegexp()
+			if err != nil {
+				return nil, err
+			}
+			x = &match{x: x, re: re}
+		} else {
+			arg := p.next()
+			if arg.kind != tokString {
+				return nil, p.errorf(arg, "%s expects a string, got %s", t.text, arg)
+			}
+			c.arg = arg.text
+			x = c
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokNumber:
+		return &literal{value: t.num}, nil
+	case tokString:
+		return &literal{value: t.text}, nil
+	case tokIdent:
+		switch t.text {
+		case "true", "false":
+			return &literal{value: t.text == "true"}, nil
+		case "now":
+			return &nowNode{}, nil
+		}
+		if !p.fields[t.text] {
+			p.unknown = t.text
+			return nil, p.errorf(t, "unknown field %s", t)
+		}
+		return &field{name: t.text}, nil
+	case tokOp:
+		switch t.text {
+		case "(":
+			x, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			return x, p.expect(")")
+		case "-":
+			x, err := p.parsePrimary()
+			if err != nil {
+				return nil, err
+			}
+			return &unary{op: "-", x: x}, nil
+		}
+	}
+	return nil, p.errorf(t, "unexpected %s", t)
+}
@@ -0,0 +1,406 @@
+// Package metainfo parses and creates BitTorrent metainfo(.torrent) files and parses magnet links.
+// v1(BEP 3), v2 and hybrid v1/v2(BEP 52) torrents are supported.
+package metainfo
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"qbit-cli/pkg/bencode"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// BlockSize is the leaf size of v2 merkle trees, piece length must be a multiple of it.
+	BlockSize      = 16 << 10
+	MaxPieceLength = 64 << 20
+	// maxAutoPieceLength caps the automatic piece length, larger pieces are unfriendly to most clients
+	maxAutoPieceLength = 16 << 20
+	// autoPieceCount is the expected number of pieces of the automatic piece length
+	autoPieceCount = 1500
+)
+
+type CreateOptions struct {
+	// Path is a file or a directory, directories are created as multi-file torrents.
+	Path string
+	// Name defaults to the base name of Path.
+	Name string
+	// PieceLength is a power of two between BlockSize and MaxPieceLength, 0 chooses one by the total size.
+	PieceLength int64
+	// Hybrid creates a v1/v2 hybrid torrent, v1 files are aligned to pieces by padding files.
+	Hybrid bool
+	// Trackers are placed in tiers of their own, the first one is the announce url.
+	Trackers []string
+	WebSeeds []string
+	Private  bool
+	Comment  string
+	// Source is the info source field, private trackers use it to make the info hash unique.
+	Source       string
+	CreatedBy    string
+	CreationDate time.Time
+	// Progress is called after every block with hashed and total bytes.
+	Progress func(done, total int64)
+}
+
+// Torrent is a created torrent, Data is the content of the .torrent file.
+type Torrent struct {
+	Data        []byte
+	Name        string
+	InfoHash    string
+	InfoHashV2  string
+	PieceLength int64
+	Pieces      int
+	Files       int
+	Size        int64
+}
+
+type sourceFile struct {
+	path   string
+	parts  []string
+	length int64
+}
+
+// Create hashes the files of opts.Path and returns the metainfo.
+func Create(opts CreateOptions) (*Torrent, error) {
+	files, err := walkFiles(opts.Path)
+	if err != nil {
+		return nil, err
+	}
+	var total int64
+	for _, f := range files {
+		total += f.length
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("%s: all files are empty", opts.Path)
+	}
+	name := opts.Name
+	if name == "" {
+		abs, err := filepath.Abs(opts.Path)
+		if err != nil {
+			return nil, err
+		}
+		name = filepath.Base(abs)
+	}
+	pieceLength := opts.PieceLength
+	if pieceLength == 0 {
+		pieceLength = AutoPieceLength(total)
+	}
+	if err := CheckPieceLength(pieceLength); err != nil {
+		return nil, err
+	}
+
+	h := newHasher(pieceLength, opts.Hybrid, total, opts.Progress)
+	// single file torrents have no path
+	single := len(files) == 1 && len(files[0].parts) == 0
+	var v1Files []map[string]any
+	for i, f := range files {
+		if err := h.hashFile(f); err != nil {
+			return nil, err
+		}
+		if single {
+			break
+		}
+		v1Files = append(v1Files, map[string]any{"length": f.length, "path": f.parts})
+		if i == len(files)-1 {
+			break
+		}
+		if pad := h.pad(); pad > 0 {
+			v1Files = append(v1Files, map[string]any{"attr": "p", "length": pad, "path": []string{".pad", strconv.FormatInt(pad, 10)}})
+		}
+	}
+	h.finish()
+
+	info := map[string]any{
+		"name":         name,
+		"piece length": pieceLength,
+		"pieces":       h.pieces,
+	}
+	if single {
+		info["length"] = files[0].length
+	} else {
+		info["files"] = v1Files
+	}
+	if opts.Private {
+		info["private"] = 1
+	}
+	if opts.Source != "" {
+		info["source"] = opts.Source
+	}
+	if opts.Hybrid {
+		info["meta version"] = 2
+		info["file tree"] = fileTree(files, h.roots, single, name)
+	}
+	infoData, err := bencode.Marshal(info)
+	if err != nil {
+		return nil, err
+	}
+
+	torrent := map[string]any{"info": bencode.RawMessage(infoData)}
+	if len(opts.Trackers) > 0 {
+		torrent["announce"] = opts.Trackers[0]
+		tiers := make([][]string, len(opts.Trackers))
+		for i, tracker := range opts.Trackers {
+			tiers[i] = []string{tracker}
+		}
+		torrent["announce-list"] = tiers
+	}
+	if len(opts.WebSeeds) > 0 {
+		torrent["url-list"] = opts.WebSeeds
+	}
+	if opts.Comment != "" {
+		torrent["comment"] = opts.Comment
+	}
+	if opts.CreatedBy != "" {
+		torrent["created by"] = opts.CreatedBy
+	}
+	if !opts.CreationDate.IsZero() {
+		torrent["creation date"] = opts.CreationDate.Unix()
+	}
+	if opts.Hybrid {
+		torrent["piece layers"] = h.layers
+	}
+	data, err := bencode.Marshal(torrent)
+	if err != nil {
+		return nil, err
+	}
+
+	v1 := sha1.Sum(infoData)
+	t := &Torrent{
+		Data:        data,
+		Name:        name,
+		InfoHash:    hex.EncodeToString(v1[:]),
+		PieceLength: pieceLength,
+		Pieces:      len(h.pieces) / sha1.Size,
+		Files:       len(files),
+		Size:        total,
+	}
+	if opts.Hybrid {
+		v2 := sha256.Sum256(infoData)
+		t.InfoHashV2 = hex.EncodeToString(v2[:])
+	}
+	return t, nil
+}
+
+// AutoPieceLength returns the smallest power of two which splits total into about 1500 pieces.
+func AutoPieceLength(total int64) int64 {
+	length := int64(BlockSize)
+	for length < maxAutoPieceLength && total/length > autoPieceCount {
+		length *= 2
+	}
+	return length
+}
+
+func CheckPieceLength(length int64) error {
+	if length < BlockSize || length > MaxPieceLength || length&(length-1) != 0 {
+		return fmt.Errorf("piece length %d must be a power of two between 16KB and 64MB", length)
+	}
+	return nil
+}
+
+// walkFiles returns the regular files of path sorted by path, which is the order of v2 file tree.
+func walkFiles(root string) ([]sourceFile, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []sourceFile{{path: root, length: info.Size()}}, nil
+	}
+	var files []sourceFile
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, sourceFile{path: path, parts: strings.Split(filepath.ToSlash(rel), "/"), length: info.Size()})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("%s: no files", root)
+	}
+	slices.SortFunc(files, func(a, b sourceFile) int { return slices.Compare(a.parts, b.parts) })
+	return files, nil
+}
+
+// fileTree builds the v2 file tree, a file is a dictionary with an empty key.
+func fileTree(files []sourceFile, roots [][]byte, single bool, name string) map[string]any {
+	tree := map[string]any{}
+	for i, f := range files {
+		leaf := map[string]any{"length": f.length}
+		if f.length > 0 {
+			leaf["pieces root"] = roots[i]
+		}
+		parts := f.parts
+		if single {
+			parts = []string{name}
+		}
+		dir := tree
+		for _, part := range parts[:len(parts)-1] {
+			sub, ok := dir[part].(map[string]any)
+			if !ok {
+				sub = map[string]any{}
+				dir[part] = sub
+			}
+			dir = sub
+		}
+		dir[parts[len(parts)-1]] = map[string]any{"": leaf}
+	}
+	return tree
+}
+
+// hasher reads files once and computes v1 pieces and v2 merkle trees at the same time.
+type hasher struct {
+	pieceLength int64
+	v2          bool
+	done, total int64
+	progress    func(done, total int64)
+
+	piece     hash.Hash
+	pieceFill int64
+	pieces    []byte
+
+	// roots are the v2 pieces root of each file, layers are keyed by the root
+	roots  [][]byte
+	layers map[string][]byte
+
+	block []byte
+}
+
+func newHasher(pieceLength int64, v2 bool, total int64, progress func(done, total int64)) *hasher {
+	return &hasher{
+		pieceLength: pieceLength,
+		v2:          v2,
+		total:       total,
+		progress:    progress,
+		piece:       sha1.New(),
+		layers:      map[string][]byte{},
+		block:       make([]byte, BlockSize),
+	}
+}
+
+func (h *hasher) hashFile(file sourceFile) error {
+	f, err := os.Open(file.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var leaves [][]byte
+	r := bufio.NewReaderSize(f, 1<<20)
+	var read int64
+	for {
+		n, err := io.ReadFull(r, h.block)
+		if n > 0 {
+			read += int64(n)
+			h.writeV1(h.block[:n])
+			if h.v2 {
+				sum := sha256.Sum256(h.block[:n])
+				leaves = append(leaves, sum[:])
+			}
+			h.done += int64(n)
+			if h.progress != nil {
+				h.progress(h.done, h.total)
+			}
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if read != file.length {
+		return fmt.Errorf("%s: size changed while hashing", file.path)
+	}
+	if h.v2 {
+		root, layer := merkle(leaves, int(h.pieceLength/BlockSize))
+		h.roots = append(h.roots, root)
+		if file.length > h.pieceLength {
+			h.layers[string(root)] = layer
+		}
+	}
+	return nil
+}
+
+func (h *hasher) writeV1(p []byte) {
+	for len(p) > 0 {
+		n := min(int64(len(p)), h.pieceLength-h.pieceFill)
+		h.piece.Write(p[:n])
+		h.pieceFill += n
+		p = p[n:]
+		if h.pieceFill == h.pieceLength {
+			h.pieces = h.piece.Sum(h.pieces)
+			h.piece.Reset()
+			h.pieceFill = 0
+		}
+	}
+}
+
+// pad aligns the next file of hybrid torrents to a piece with zeros and returns the size of padding.
+func (h *hasher) pad() int64 {
+	if !h.v2 || h.pieceFill == 0 {
+		return 0
+	}
+	pad := h.pieceLength - h.pieceFill
+	for i := range h.block {
+		h.block[i] = 0
+	}
+	for rest := pad; rest > 0; rest -= BlockSize {
+		h.writeV1(h.block[:min(rest, BlockSize)])
+	}
+	return pad
+}
+
+func (h *hasher) finish() {
+	if h.pieceFill > 0 {
+		h.pieces = h.piece.Sum(h.pieces)
+		h.pieceFill = 0
+	}
+}
+
+// merkle returns the root of block hashes and the piece layer, leaves are padded with zero hashes to a power of two.
+func merkle(leaves [][]byte, blocksPerPiece int) (root []byte, layer []byte) {
+	width := 1
+	for width < len(leaves) {
+		width *= 2
+	}
+	nodes := make([][]byte, width)
+	copy(nodes, leaves)
+	for i := len(leaves); i < width; i++ {
+		nodes[i] = make([]byte, sha256.Size)
+	}
+	pieces := (len(leaves) + blocksPerPiece - 1) / blocksPerPiece
+	for covered := 1; len(nodes) > 1; covered *= 2 {
+		if covered == blocksPerPiece {
+			layer = slices.Concat(nodes[:pieces]...)
+		}
+		next := make([][]byte, len(nodes)/2)
+		for i := range next {
+			sum := sha256.Sum256(slices.Concat(nodes[2*i], nodes[2*i+1]))
+			next[i] = sum[:]
+		}
+		nodes = next
+	}
+	return nodes[0], layer
+}
@@ -0,0 +1,114 @@
+package metainfo
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func content(n int, seed byte) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = seed + byte(i%251)
+	}
+	return b
+}
+
+func sha1s(chunks ...[]byte) string {
+	var b []byte
+	for _, c := range chunks {
+		sum := sha1.Sum(c)
+		b = append(b, sum[:]...)
+	}
+	return string(b)
+}
+
+func sha256Of(chunks ...[]byte) []byte {
+	sum := sha256.Sum256(bytes.Join(chunks, nil))
+	return sum[:]
+}
+
+func TestCreateV1(t *testing.T) {
+	data := content(40000, 1)
+	path := filepath.Join(t.TempDir(), "file.bin")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	torrent, err := Create(CreateOptions{Path: path, PieceLength: BlockSize, Trackers: []string{"http://a/announce", "http://b/announce"}, Private: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := "d6:lengthi40000e4:name8:file.bin12:piece lengthi16384e6:pieces60:" +
+		sha1s(data[:16384], data[16384:32768], data[32768:]) + "7:privatei1ee"
+	want := "d8:announce17:http://a/announce13:announce-listll17:http://a/announceel17:http://b/announceee4:info" + info + "e"
+	if string(torrent.Data) != want {
+		t.Errorf("got\n%q\nwant\n%q", torrent.Data, want)
+	}
+	if sum := sha1.Sum([]byte(info)); torrent.InfoHash != hex.EncodeToString(sum[:]) || torrent.Pieces != 3 {
+		t.Errorf("got info hash %s, %d pieces", torrent.InfoHash, torrent.Pieces)
+	}
+}
+
+func TestCreateHybrid(t *testing.T) {
+	dir := t.TempDir()
+	a, b := content(40000, 1), content(100, 2)
+	if err := os.MkdirAll(filepath.Join(dir, "a"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "a", "1"), a, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "b"), b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	torrent, err := Create(CreateOptions{Path: dir, Name: "release", PieceLength: 2 * BlockSize, Hybrid: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := string(torrent.Data)
+
+	// v1 files are aligned to pieces
+	padded := append(append([]byte(nil), a[32768:]...), make([]byte, 65536-40000)...)
+	for _, want := range []string{
+		"5:filesld6:lengthi40000e4:pathl1:a1:1eed4:attr1:p6:lengthi25536e4:pathl4:.pad5:25536eed6:lengthi100e4:pathl1:beee",
+		"6:pieces60:" + sha1s(a[:32768], padded, b),
+		"12:meta versioni2e",
+	} {
+		if !strings.Contains(data, want) {
+			t.Errorf("missing %q", want)
+		}
+	}
+
+	// 3 blocks of a are padded with a zero hash to 4 leaves, a piece has 2 blocks
+	zero := make([]byte, sha256.Size)
+	l0, l1, l2 := sha256Of(a[:16384]), sha256Of(a[16384:32768]), sha256Of(a[32768:])
+	layer := append(sha256Of(l0, l1), sha256Of(l2, zero)...)
+	root := sha256Of(layer)
+	if !strings.Contains(data, "d1:ad1:1d0:d6:lengthi40000e11:pieces root32:"+string(root)+"eee") {
+		t.Error("missing file tree of a/1")
+	}
+	if !strings.Contains(data, "1:bd0:d6:lengthi100e11:pieces root32:"+string(sha256Of(b))+"eee") {
+		t.Error("missing file tree of b")
+	}
+	// b is smaller than a piece and has no piece layer
+	if !strings.HasSuffix(data, "12:piece layersd32:"+string(root)+"64:"+string(layer)+"ee") {
+		t.Error("unexpected piece layers")
+	}
+	if torrent.Pieces != 3 || torrent.Files != 2 || len(torrent.InfoHashV2) != 64 {
+		t.Errorf("got %d pieces, %d files, v2 hash %q", torrent.Pieces, torrent.Files, torrent.InfoHashV2)
+	}
+}
+
+func TestAutoPieceLength(t *testing.T) {
+	tests := map[int64]int64{1 << 20: BlockSize, 1 << 30: 1 << 20, 1 << 40: maxAutoPieceLength}
+	for total, want := range tests {
+		if got := AutoPieceLength(total); got != want {
+			t.Errorf("AutoPieceLength(%d) = %d, want %d", total, got, want)
+		}
+	}
+}
@@ -0,0 +1,241 @@
+package metainfo
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"qbit-cli/pkg/bencode"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Metainfo is the content of a .torrent file or a magnet link, magnet links have no pieces and files.
+type Metainfo struct {
+	InfoHash    string `json:"infohash_v1"`
+	InfoHashV2  string `json:"infohash_v2"`
+	Name        string `json:"name"`
+	Size        int64  `json:"size"`
+	PieceLength int64  `json:"piece_length"`
+	Pieces      int    `json:"pieces"`
+	Private     bool   `json:"private"`
+	Source      string `json:"source"`
+	// Trackers are grouped by tiers
+	Trackers     [][]string `json:"trackers"`
+	WebSeeds     []string   `json:"web_seeds"`
+	Comment      string     `json:"comment"`
+	CreatedBy    string     `json:"created_by"`
+	CreationDate int64      `json:"creation_date"`
+	// Files are sorted as they are in the torrent, padding files are excluded
+	Files  []File `json:"files"`
+	Magnet bool   `json:"magnet"`
+}
+
+// File is a file of torrent, Path is separated by / and relative to the torrent name of multi-file torrents.
+type File struct {
+	Path   string `json:"path"`
+	Length int64  `json:"length"`
+}
+
+type rawTorrent struct {
+	Announce     string             `bencode:"announce"`
+	AnnounceList [][]string         `bencode:"announce-list"`
+	URLList      bencode.RawMessage `bencode:"url-list"`
+	Comment      string             `bencode:"comment"`
+	CreatedBy    string             `bencode:"created by"`
+	CreationDate int64              `bencode:"creation date"`
+	Info         bencode.RawMessage `bencode:"info"`
+}
+
+type rawInfo struct {
+	Name        string         `bencode:"name"`
+	PieceLength int64          `bencode:"piece length"`
+	Pieces      []byte         `bencode:"pieces"`
+	Length      int64          `bencode:"length"`
+	Files       []rawFile      `bencode:"files"`
+	Private     int64          `bencode:"private"`
+	Source      string         `bencode:"source"`
+	MetaVersion int64          `bencode:"meta version"`
+	FileTree    map[string]any `bencode:"file tree"`
+}
+
+type rawFile struct {
+	Length int64    `bencode:"length"`
+	Path   []string `bencode:"path"`
+	Attr   string   `bencode:"attr"`
+}
+
+// Load parses a magnet link or a .torrent file.
+func Load(source string) (*Metainfo, error) {
+	if strings.HasPrefix(source, "magnet:") {
+		return ParseMagnet(source)
+	}
+	data, err := os.ReadFile(source)
+	if err != nil {
+		return nil, err
+	}
+	m, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", source, err)
+	}
+	return m, nil
+}
+
+// Parse parses the content of a .torrent file.
+func Parse(data []byte) (*Metainfo, error) {
+	var t rawTorrent
+	if err := bencode.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("invalid torrent: %w", err)
+	}
+	if len(t.Info) == 0 {
+		return nil, errors.New("invalid torrent: info is missing")
+	}
+	var info rawInfo
+	if err := bencode.Unmarshal(t.Info, &info); err != nil {
+		return nil, fmt.Errorf("invalid torrent info: %w", err)
+	}
+	v1 := len(info.Pieces) > 0
+	v2 := info.MetaVersion == 2
+	if info.Name == "" || info.PieceLength <= 0 || !v1 && !v2 || len(info.Pieces)%sha1.Size != 0 {
+		return nil, errors.New("invalid torrent info: name, piece length or pieces is invalid")
+	}
+
+	m := &Metainfo{
+		Name:         info.Name,
+		PieceLength:  info.PieceLength,
+		Private:      info.Private == 1,
+		Source:       info.Source,
+		Trackers:     t.AnnounceList,
+		Comment:      t.Comment,
+		CreatedBy:    t.CreatedBy,
+		CreationDate: t.CreationDate,
+	}
+	if len(m.Trackers) == 0 && t.Announce != "" {
+		m.Trackers = [][]string{{t.Announce}}
+	}
+	if len(t.URLList) > 0 {
+		var single string
+		if bencode.Unmarshal(t.URLList, &single) == nil {
+			m.WebSeeds = []string{single}
+		} else if err := bencode.Unmarshal(t.URLList, &m.WebSeeds); err != nil {
+			return nil, fmt.Errorf("invalid url-list: %w", err)
+		}
+	}
+
+	if v1 {
+		sum := sha1.Sum(t.Info)
+		m.InfoHash = hex.EncodeToString(sum[:])
+		m.Pieces = len(info.Pieces) / sha1.Size
+		if len(info.Files) == 0 {
+			m.Files = []File{{Path: info.Name, Length: info.Length}}
+		}
+		for _, f := range info.Files {
+			if !strings.Contains(f.Attr, "p") {
+				m.Files = append(m.Files, File{Path: path.Join(f.Path...), Length: f.Length})
+			}
+		}
+	}
+	if v2 {
+		var err error
+		sum := sha256.Sum256(t.Info)
+		m.InfoHashV2 = hex.EncodeToString(sum[:])
+		if !v1 {
+			// paths are relative to the torrent name, single file torrents have a file named by the torrent
+			if m.Files, err = walkFileTree(info.FileTree, ""); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if len(m.Files) == 0 {
+		return nil, errors.New("invalid torrent info: no files")
+	}
+	for _, f := range m.Files {
+		m.Size += f.Length
+	}
+	if !v1 {
+		m.Pieces = int((m.Size + m.PieceLength - 1) / m.PieceLength)
+	}
+	return m, nil
+}
+
+// walkFileTree returns files of a v2 file tree, a file is a dictionary with an empty key.
+func walkFileTree(tree map[string]any, dir string) ([]File, error) {
+	var files []File
+	names := make([]string, 0, len(tree))
+	for name := range tree {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		node, ok := tree[name].(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("invalid file tree: %s", path.Join(dir, name))
+		}
+		if leaf, ok := node[""].(map[string]any); ok {
+			length, _ := leaf["length"].(int64)
+			files = append(files, File{Path: path.Join(dir, name), Length: length})
+			continue
+		}
+		sub, err := walkFileTree(node, path.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, sub...)
+	}
+	return files, nil
+}
+
+// ParseMagnet parses a magnet link, btih of v1 and btmh of v2 are supported.
+func ParseMagnet(uri string) (*Metainfo, error) {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "magnet" {
+		return nil, fmt.Errorf("invalid magnet link %q", uri)
+	}
+	query := u.Query()
+	m := &Metainfo{Name: query.Get("dn"), WebSeeds: query["ws"], Magnet: true}
+	for _, xt := range query["xt"] {
+		if btih, ok := strings.CutPrefix(xt, "urn:btih:"); ok {
+			if m.InfoHash, err = parseBtih(btih); err != nil {
+				return nil, err
+			}
+		}
+		// multihash of sha256 starts with 0x12 and length 0x20
+		if btmh, ok := strings.CutPrefix(xt, "urn:btmh:1220"); ok {
+			if _, err := hex.DecodeString(btmh); err != nil || len(btmh) != 64 {
+				return nil, fmt.Errorf("invalid btmh %q", xt)
+			}
+			m.InfoHashV2 = strings.ToLower(btmh)
+		}
+	}
+	if m.InfoHash == "" && m.InfoHashV2 == "" {
+		return nil, fmt.Errorf("magnet link has no info hash: %s", uri)
+	}
+	for _, tr := range query["tr"] {
+		m.Trackers = append(m.Trackers, []string{tr})
+	}
+	if xl := query.Get("xl"); xl != "" {
+		m.Size, _ = strconv.ParseInt(xl, 10, 64)
+	}
+	return m, nil
+}
+
+// parseBtih returns the hex info hash, base32 is used by some old links.
+func parseBtih(btih string) (string, error) {
+	switch len(btih) {
+	case 40:
+		if _, err := hex.DecodeString(btih); err == nil {
+			return strings.ToLower(btih), nil
+		}
+	case 32:
+		if b, err := base32.StdEncoding.DecodeString(strings.ToUpper(btih)); err == nil {
+			return hex.EncodeToString(b), nil
+		}
+	}
+	return "", fmt.Errorf("invalid btih %q", btih)
+}
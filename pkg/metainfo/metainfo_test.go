@@ -0,0 +1,95 @@
+package metainfo
+
+import (
+	"os"
+	"path/filepath"
+	"qbit-cli/pkg/bencode"
+	"reflect"
+	"testing"
+)
+
+func TestParseCreated(t *testing.T) {
+	dir := t.TempDir()
+	for name, size := range map[string]int{"a/1.mkv": 40000, "a/2.nfo": 10, "b.txt": 100} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, content(size, 3), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wantFiles := []File{{"a/1.mkv", 40000}, {"a/2.nfo", 10}, {"b.txt", 100}}
+	for _, hybrid := range []bool{false, true} {
+		created, err := Create(CreateOptions{Path: dir, Name: "release", PieceLength: BlockSize, Hybrid: hybrid,
+			Trackers: []string{"http://a/announce", "http://b/announce"}, WebSeeds: []string{"http://seed/"},
+			Private: true, Source: "SRC", Comment: "hello", CreatedBy: "test"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		m, err := Parse(created.Data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.InfoHash != created.InfoHash || m.InfoHashV2 != created.InfoHashV2 || m.Pieces != created.Pieces {
+			t.Errorf("hybrid %v: got hashes %s %s, %d pieces", hybrid, m.InfoHash, m.InfoHashV2, m.Pieces)
+		}
+		if !reflect.DeepEqual(m.Files, wantFiles) || m.Size != 40110 || m.Name != "release" {
+			t.Errorf("hybrid %v: got %s, %d bytes, files %v", hybrid, m.Name, m.Size, m.Files)
+		}
+		if !m.Private || m.Source != "SRC" || m.Comment != "hello" || m.CreatedBy != "test" ||
+			!reflect.DeepEqual(m.Trackers, [][]string{{"http://a/announce"}, {"http://b/announce"}}) ||
+			!reflect.DeepEqual(m.WebSeeds, []string{"http://seed/"}) {
+			t.Errorf("hybrid %v: got %+v", hybrid, m)
+		}
+	}
+}
+
+func TestParseV2(t *testing.T) {
+	leaf := func(n int64) map[string]any { return map[string]any{"": map[string]any{"length": n}} }
+	info := map[string]any{
+		"name": "v2", "piece length": 16384, "meta version": 2,
+		"file tree": map[string]any{"dir": map[string]any{"b": leaf(20000)}, "a": leaf(1)},
+	}
+	data, err := bencode.Marshal(map[string]any{"info": info, "announce": "udp://t:80", "url-list": "http://seed/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.InfoHash != "" || len(m.InfoHashV2) != 64 || m.Pieces != 2 || m.Size != 20001 {
+		t.Errorf("got %+v", m)
+	}
+	if !reflect.DeepEqual(m.Files, []File{{"a", 1}, {"dir/b", 20000}}) || m.Trackers[0][0] != "udp://t:80" || m.WebSeeds[0] != "http://seed/" {
+		t.Errorf("got files %v, trackers %v, web seeds %v", m.Files, m.Trackers, m.WebSeeds)
+	}
+
+	for _, invalid := range []string{"", "d4:infod4:name1:aee", "d4:infoi1ee", "d4:infod4:name1:a12:piece lengthi1e6:pieces3:abce6:lengthi1eee"} {
+		if _, err := Parse([]byte(invalid)); err == nil {
+			t.Errorf("%q should be invalid", invalid)
+		}
+	}
+}
+
+func TestParseMagnet(t *testing.T) {
+	m, err := ParseMagnet("magnet:?xt=urn:btih:C12FE1C06BBA254A9DC9F519B335AA7C1367A88A&dn=Ubuntu&tr=udp%3A%2F%2Ft1&tr=udp%3A%2F%2Ft2&xl=100" +
+		"&xt=urn:btmh:1220caf1e1c30e81cb361b9ee167c4aa64228a7fa4fa9f6105232b28ad099f3a302e")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.InfoHash != "c12fe1c06bba254a9dc9f519b335aa7c1367a88a" || m.InfoHashV2 != "caf1e1c30e81cb361b9ee167c4aa64228a7fa4fa9f6105232b28ad099f3a302e" ||
+		m.Name != "Ubuntu" || len(m.Trackers) != 2 || m.Size != 100 || !m.Magnet {
+		t.Errorf("got %+v", m)
+	}
+	m, err = ParseMagnet("magnet:?xt=urn:btih:YEX6DQDLXISUVHOJ6UM3GNNKPQJWPKEK")
+	if err != nil || m.InfoHash != "c12fe1c06bba254a9dc9f519b335aa7c1367a88a" {
+		t.Errorf("base32 btih: got %v, %v", m, err)
+	}
+	for _, invalid := range []string{"magnet:?dn=x", "magnet:?xt=urn:btih:xyz", "http://example.com/a.torrent"} {
+		if _, err := ParseMagnet(invalid); err == nil {
+			t.Errorf("%s should be invalid", invalid)
+		}
+	}
+}
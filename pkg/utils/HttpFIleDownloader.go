@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -15,22 +16,28 @@ import (
 )
 
 type HttpFileDownloader struct {
-	Client              *http.Client
-	Headers             map[string]string
-	SavePath            string
-	OverwriteExistFile  bool
-	ChunkSize           int64
-	MaxWorker, MaxRetry int
-	InfoLogger          *log.Logger
-	DebugLogger         *log.Logger
+	// Client retries 429, 5xx and connection reset by its transport, it should be built by api.NewHttpClient so that
+	// retry, rate limit and record follow the http block of config file.
+	Client *http.Client
+	// Context cancels requests and retries, defaults to context.Background
+	Context            context.Context
+	Headers            map[string]string
+	SavePath           string
+	OverwriteExistFile bool
+	ChunkSize          int64
+	MaxWorker          int
+	// MaxRetry of a chunk, a chunk may fail while reading the body, which the transport can't retry
+	MaxRetry    int
+	InfoLogger  *log.Logger
+	DebugLogger *log.Logger
 }
 
 func defaultClient() *http.Client {
-	return &http.Client{Timeout: time.Minute * 1}
+	return &http.Client{Timeout: time.Minute * 1, Transport: NewTransport(nil, TransportOptions{MaxRetry: 3})}
 }
 
 func (d *HttpFileDownloader) buildRequest(url string, method string) (*http.Request, error) {
-	req, err := http.NewRequest(method, url, nil)
+	req, err := http.NewRequestWithContext(d.Context, method, url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -46,6 +53,9 @@ func (d *HttpFileDownloader) init() {
 	if d.Client == nil {
 		d.Client = defaultClient()
 	}
+	if d.Context == nil {
+		d.Context = context.Background()
+	}
 	if d.InfoLogger == nil {
 		d.InfoLogger = log.New(os.Stdout, "", 0)
 	}
@@ -265,59 +275,14 @@ func (d *HttpFileDownloader) Download(url, newName string) error {
 					continue
 				}
 				d.InfoLogger.Printf("worker-%d starting...\n", i)
-
-				var success = false
-				for attempt := 0; attempt < d.maxRetry(); attempt++ {
-
-					req, err := d.buildRequest(url, http.MethodGet)
-					if err != nil {
-						d.DebugLogger.Printf("worker-%d: failed to create request for chunk %d, aborting chunk: %v\n", i, t.chunkIndex, err)
-						break
-					}
-					req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", t.start, t.end))
-					resp, err := d.Client.Do(req)
-					if err != nil {
-						d.DebugLogger.Printf("worker-%d: failed to do request for chunk %d: %v\n", i, t.chunkIndex, err)
-						continue
-					}
-
-					if resp.StatusCode == http.StatusTooManyRequests {
-						SafeClose(resp.Body)
-						time.Sleep(time.Second << 2)
-						d.DebugLogger.Printf("unexpected status: %v\n", resp.Status)
-						continue
-					}
-					if resp.StatusCode >= 400 && resp.StatusCode < 500 {
-						d.DebugLogger.Printf("stop download, unexpected client status: %v\n", resp.Status)
-						SafeClose(resp.Body)
-						break
-					}
-					if resp.StatusCode != http.StatusPartialContent {
-						d.DebugLogger.Printf("unexpected status: %v\n", resp.Status)
-						SafeClose(resp.Body)
-						continue
-					}
-
-					err = writeParts(resp, file, t.start)
-					SafeClose(resp.Body)
-					if err != nil {
-						d.DebugLogger.Printf("worker-%d: attempt %d, failed to write chunk %d to file: %v\n", i, attempt+1, t.chunkIndex, err)
-						continue
-					}
-
-					err = saveMetadata(t.chunkIndex)
-					if err != nil {
-						d.DebugLogger.Printf("worker-%d: attempt %d, failed to save metadata for chunk %d: %v\n", i, attempt+1, t.chunkIndex, err)
-						continue
-					}
-
-					success = true
-					break
+				// failed chunks are downloaded again on next run
+				if err := d.retryChunk(url, file, t); err != nil {
+					d.DebugLogger.Printf("worker-%d: failed to download chunk %d: %v\n", i, t.chunkIndex, err)
+					continue
 				}
-				if !success {
-					d.DebugLogger.Printf("worker-%d: failed to download chunk %d after %d attempts\n", i, t.chunkIndex, d.maxRetry())
+				if err := saveMetadata(t.chunkIndex); err != nil {
+					d.DebugLogger.Printf("worker-%d: failed to save metadata for chunk %d: %v\n", i, t.chunkIndex, err)
 				}
-
 			}
 
 		}(i)
@@ -361,6 +326,43 @@ func (d *HttpFileDownloader) Download(url, newName string) error {
 	return nil
 }
 
+// retryChunk downloads the chunk with exponential backoff until it succeeds, fails with a client error
+// or MaxRetry attempts are used up.
+func (d *HttpFileDownloader) retryChunk(url string, file *os.File, t task) error {
+	for attempt := 1; ; attempt++ {
+		retry, err := d.downloadChunk(url, file, t)
+		if err == nil || !retry || attempt >= d.maxRetry() {
+			return err
+		}
+		wait := min(time.Second<<(attempt-1), defaultMaxRetryWait)
+		d.DebugLogger.Printf("chunk %d: attempt %d failed, retry in %s: %v\n", t.chunkIndex, attempt, wait, err)
+		select {
+		case <-d.Context.Done():
+			return d.Context.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// downloadChunk writes the range of the task into file, retry is false when retrying can't help.
+func (d *HttpFileDownloader) downloadChunk(url string, file *os.File, t task) (retry bool, err error) {
+	req, err := d.buildRequest(url, http.MethodGet)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", t.start, t.end))
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return d.Context.Err() == nil, err
+	}
+	defer SafeClose(resp.Body)
+	if resp.StatusCode != http.StatusPartialContent {
+		clientErr := resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests
+		return !clientErr, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	return d.Context.Err() == nil, writeParts(resp, file, t.start)
+}
+
 func writeParts(resp *http.Response, file *os.File, begin int64) error {
 	start := begin
 	_, err := io.Copy(&WriterAt{writer: file, off: start}, resp.Body)
@@ -0,0 +1,292 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Cassette is one recorded request and response, saved as a json file.
+type Cassette struct {
+	Request  CassetteRequest  `json:"request"`
+	Response CassetteResponse `json:"response"`
+}
+
+type CassetteRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+	// Base64 is true if Body is base64 encoded binary
+	Base64 bool `json:"base64,omitempty"`
+}
+
+type CassetteResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+	Base64     bool        `json:"base64,omitempty"`
+}
+
+const redacted = "REDACTED"
+
+var secretHeaders = []string{"Cookie", "Set-Cookie", "X-Emby-Token", "Authorization", "X-Api-Key"}
+
+// key identifies requests in replay, body is not included because multipart boundary is random.
+func (r *CassetteRequest) key() string {
+	return r.Method + " " + r.URL
+}
+
+func encodeBody(body []byte) (string, bool) {
+	if utf8.Valid(body) {
+		return string(body), false
+	}
+	return base64.StdEncoding.EncodeToString(body), true
+}
+
+func decodeBody(body string, isBase64 bool) []byte {
+	if isBase64 {
+		b, _ := base64.StdEncoding.DecodeString(body)
+		return b
+	}
+	return []byte(body)
+}
+
+// redactHeader masks credentials, cookie names are kept so that a replayed login still looks valid.
+func redactHeader(h http.Header) http.Header {
+	h = h.Clone()
+	for _, name := range secretHeaders {
+		values := h.Values(name)
+		for i, v := range values {
+			switch name {
+			case "Cookie", "Set-Cookie":
+				values[i] = redactCookie(v)
+			default:
+				values[i] = redacted
+			}
+		}
+	}
+	return h
+}
+
+func redactCookie(v string) string {
+	parts := strings.Split(v, ";")
+	for i, p := range parts {
+		parts[i] = strings.TrimSpace(p)
+		name, _, found := strings.Cut(parts[i], "=")
+		// attributes of Set-Cookie are kept
+		if i == 0 || (found && !isCookieAttr(name)) {
+			parts[i] = name + "=" + redacted
+		}
+	}
+	return strings.Join(parts, "; ")
+}
+
+func isCookieAttr(name string) bool {
+	switch strings.ToLower(name) {
+	case "path", "domain", "expires", "max-age", "samesite":
+		return true
+	}
+	return false
+}
+
+// redactForm masks secret fields of url encoded body, other bodies are returned as they are.
+func redactForm(contentType string, body []byte) []byte {
+	if !strings.HasPrefix(contentType, "application/x-www-form-urlencoded") {
+		return body
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		return body
+	}
+	changed := false
+	for _, p := range secretParams {
+		if form.Has(p) {
+			form.Set(p, redacted)
+			changed = true
+		}
+	}
+	if !changed {
+		return body
+	}
+	return []byte(form.Encode())
+}
+
+// RecordTransport saves every request and response to Dir, credentials are redacted.
+type RecordTransport struct {
+	Base http.RoundTripper
+	Dir  string
+}
+
+// recordSeq numbers cassettes of a dir, it is shared by all clients so that files are in request order.
+var (
+	recordLock sync.Mutex
+	recordSeq  = make(map[string]int)
+)
+
+func (t *RecordTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		reqBody, err = io.ReadAll(body)
+		SafeClose(body)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := t.Base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	SafeClose(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	c := Cassette{
+		Request: CassetteRequest{
+			Method: req.Method,
+			URL:    redactURL(req.URL),
+			Header: redactHeader(req.Header),
+		},
+		Response: CassetteResponse{
+			StatusCode: resp.StatusCode,
+			Header:     redactHeader(resp.Header),
+		},
+	}
+	c.Request.Body, c.Request.Base64 = encodeBody(redactForm(req.Header.Get("Content-Type"), reqBody))
+	c.Response.Body, c.Response.Base64 = encodeBody(respBody)
+	if err := t.save(&c); err != nil {
+		return nil, fmt.Errorf("record %s: %w", c.Request.key(), err)
+	}
+	return resp, nil
+}
+
+func (t *RecordTransport) save(c *Cassette) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	recordLock.Lock()
+	defer recordLock.Unlock()
+	seq, ok := recordSeq[t.Dir]
+	if !ok {
+		if err := os.MkdirAll(t.Dir, 0700); err != nil {
+			return err
+		}
+		// continue numbering of an existing recording
+		files, _ := filepath.Glob(filepath.Join(t.Dir, "*.json"))
+		seq = len(files)
+	}
+	seq++
+	recordSeq[t.Dir] = seq
+	return os.WriteFile(filepath.Join(t.Dir, fmt.Sprintf("%05d.json", seq)), data, 0600)
+}
+
+// ReplayTransport serves responses recorded by RecordTransport without network.
+// Requests are matched by method and redacted url, responses of the same request are served in recorded order
+// and the last one is repeated when exhausted.
+type ReplayTransport struct {
+	Dir string
+
+	once      sync.Once
+	loadErr   error
+	mu        sync.Mutex
+	responses map[string][]CassetteResponse
+}
+
+var (
+	replayLock       sync.Mutex
+	replayTransports = make(map[string]*ReplayTransport)
+)
+
+// NewReplayTransport returns the replay transport of dir, all clients share it to consume responses in order.
+func NewReplayTransport(dir string) *ReplayTransport {
+	replayLock.Lock()
+	defer replayLock.Unlock()
+	if t, ok := replayTransports[dir]; ok {
+		return t
+	}
+	t := &ReplayTransport{Dir: dir}
+	replayTransports[dir] = t
+	return t
+}
+
+func (t *ReplayTransport) load() {
+	files, err := filepath.Glob(filepath.Join(t.Dir, "*.json"))
+	if err != nil {
+		t.loadErr = err
+		return
+	}
+	if len(files) == 0 {
+		t.loadErr = fmt.Errorf("no cassette found in %s", t.Dir)
+		return
+	}
+	sort.Strings(files)
+	t.responses = make(map[string][]CassetteResponse)
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.loadErr = err
+			return
+		}
+		var c Cassette
+		if err := json.Unmarshal(data, &c); err != nil {
+			t.loadErr = fmt.Errorf("%s: %w", file, err)
+			return
+		}
+		t.responses[c.Request.key()] = append(t.responses[c.Request.key()], c.Response)
+	}
+}
+
+func (t *ReplayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.once.Do(t.load)
+	if t.loadErr != nil {
+		return nil, t.loadErr
+	}
+	if req.Body != nil {
+		SafeClose(req.Body)
+	}
+
+	key := req.Method + " " + redactURL(req.URL)
+	t.mu.Lock()
+	queue := t.responses[key]
+	if len(queue) == 0 {
+		t.mu.Unlock()
+		return nil, fmt.Errorf("replay: no recorded response for %s", key)
+	}
+	c := queue[0]
+	if len(queue) > 1 {
+		t.responses[key] = queue[1:]
+	}
+	t.mu.Unlock()
+
+	body := decodeBody(c.Body, c.Base64)
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", c.StatusCode, http.StatusText(c.StatusCode)),
+		StatusCode:    c.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        c.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
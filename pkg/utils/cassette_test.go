@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "SID", Value: "secret-sid", Path: "/"})
+		_, _ = w.Write([]byte("hello " + r.URL.Path))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	record := &http.Client{Transport: NewTransport(nil, TransportOptions{RecordDir: dir})}
+	form := url.Values{"username": {"admin"}, "password": {"secret-pwd"}}
+	resp, err := record.Post(server.URL+"/login?apikey=secret-key", "application/x-www-form-urlencoded", strings.NewReader(form.Encode()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	SafeClose(resp.Body)
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(files) != 1 {
+		t.Fatalf("got %d cassettes, want 1", len(files))
+	}
+	data, _ := os.ReadFile(files[0])
+	for _, secret := range []string{"secret-sid", "secret-pwd", "secret-key"} {
+		if strings.Contains(string(data), secret) {
+			t.Fatalf("%s is not redacted: %s", secret, data)
+		}
+	}
+
+	replay := &http.Client{Transport: NewTransport(nil, TransportOptions{ReplayDir: dir})}
+	resp, err = replay.Post(server.URL+"/login?apikey=another-key", "application/x-www-form-urlencoded", strings.NewReader(form.Encode()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	SafeClose(resp.Body)
+	if string(body) != "hello /login" {
+		t.Fatalf("replayed body %q", body)
+	}
+	if _, err := replay.Get(server.URL + "/unknown"); err == nil {
+		t.Fatal("request not recorded should fail")
+	}
+}
@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+)
+
+func TestDownloadRetryChunk(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 100)
+	var gets atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Accept-Ranges", "bytes")
+		if r.Method == http.MethodHead {
+			w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+			return
+		}
+		var start, end int
+		if _, err := fmt.Sscanf(r.Header.Get("Range"), "bytes=%d-%d", &start, &end); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		part := content[start : end+1]
+		w.Header().Set("Content-Length", strconv.Itoa(len(part)))
+		w.WriteHeader(http.StatusPartialContent)
+		// the body of the first request is cut, which the transport can't retry
+		if gets.Add(1) == 1 {
+			part = part[:len(part)/2]
+		}
+		_, _ = w.Write(part)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	d := HttpFileDownloader{
+		Client:     server.Client(),
+		SavePath:   dir,
+		ChunkSize:  300,
+		MaxWorker:  1,
+		InfoLogger: log.New(io.Discard, "", 0),
+	}
+	if err := d.Download(server.URL+"/file", "file"); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(filepath.Join(dir, "file"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, content) || gets.Load() != 5 {
+		t.Fatalf("got %d bytes after %d requests, want %d bytes after 5", len(got), gets.Load(), len(content))
+	}
+}
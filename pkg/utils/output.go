@@ -0,0 +1,413 @@
+package utils
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	OutputTable    = "table"
+	OutputJSON     = "json"
+	OutputYAML     = "yaml"
+	OutputCSV      = "csv"
+	OutputTSV      = "tsv"
+	OutputTemplate = "template"
+)
+
+var OutputFormats = []string{OutputTable, OutputJSON, OutputYAML, OutputCSV, OutputTSV, OutputTemplate + "="}
+
+// Output is how list commands render their rows.
+type Output struct {
+	Format string
+	// Template is executed for every row when Format is template, fields are column names
+	Template string
+	// Columns selected by name, empty means the default columns
+	Columns []string
+}
+
+// ParseOutput parses table, json, yaml, csv, tsv or template=<go template>.
+func ParseOutput(value string) (Output, error) {
+	if value == "" {
+		return Output{Format: OutputTable}, nil
+	}
+	if tmpl, ok := strings.CutPrefix(value, OutputTemplate+"="); ok {
+		if tmpl == "" {
+			return Output{}, fmt.Errorf("output: template is empty")
+		}
+		return Output{Format: OutputTemplate, Template: tmpl}, nil
+	}
+	switch value {
+	case OutputTable, OutputJSON, OutputYAML, OutputCSV, OutputTSV:
+		return Output{Format: value}, nil
+	}
+	return Output{}, fmt.Errorf("output: unsupported format %q, use one of %s", value, strings.Join(OutputFormats, ", "))
+}
+
+// IsTable reports whether output is for human, extra messages like totals should only be printed then.
+func (o Output) IsTable() bool {
+	return o.Format == "" || o.Format == OutputTable
+}
+
+// Column is a field of list output.
+type Column[T any] struct {
+	// Name is the key of json, yaml and template, header of csv and the name used by --columns
+	Name string
+	// Header of table, Name is used if empty
+	Header string
+	// Value is the raw value for json, yaml, csv, tsv and template
+	Value func(T) any
+	// Text is the table cell, formatted Value is used if nil
+	Text func(T) string
+	// Width is the max width of table cell, 0 means auto
+	Width int
+	// Wrap wraps table cells instead of truncating them
+	Wrap bool
+	// Hidden columns are not shown by table, csv and tsv unless selected, json and yaml always have them
+	Hidden bool
+}
+
+func (c Column[T]) header() string {
+	if c.Header != "" {
+		return c.Header
+	}
+	return c.Name
+}
+
+func (c Column[T]) text(item T) string {
+	if c.Text != nil {
+		return c.Text(item)
+	}
+	return FormatValue(c.Value(item))
+}
+
+// ColumnNames returns names of columns, used by flag completion.
+func ColumnNames[T any](columns []Column[T]) []string {
+	names := make([]string, len(columns))
+	for i, c := range columns {
+		names[i] = c.Name
+	}
+	return names
+}
+
+// selectColumns returns columns selected by name, or the default ones.
+func selectColumns[T any](o Output, columns []Column[T]) ([]Column[T], error) {
+	if len(o.Columns) == 0 {
+		structured := o.Format == OutputJSON || o.Format == OutputYAML
+		selected := make([]Column[T], 0, len(columns))
+		for _, c := range columns {
+			if structured || !c.Hidden {
+				selected = append(selected, c)
+			}
+		}
+		return selected, nil
+	}
+	selected := make([]Column[T], 0, len(o.Columns))
+	for _, name := range o.Columns {
+		found := false
+		for _, c := range columns {
+			if strings.EqualFold(c.Name, strings.TrimSpace(name)) {
+				selected = append(selected, c)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("columns: unknown column %q, available: %s", name, strings.Join(ColumnNames(columns), ","))
+		}
+	}
+	return selected, nil
+}
+
+// Render writes items in the output format.
+func Render[T any](w io.Writer, o Output, columns []Column[T], items []T) error {
+	selected, err := selectColumns(o, columns)
+	if err != nil {
+		return err
+	}
+	switch o.Format {
+	case "", OutputTable:
+		renderTable(w, selected, items)
+		return nil
+	case OutputJSON:
+		return renderJSON(w, selected, items)
+	case OutputYAML:
+		return renderYAML(w, selected, items)
+	case OutputCSV:
+		return renderCSV(w, ',', selected, items)
+	case OutputTSV:
+		return renderCSV(w, '\t', selected, items)
+	case OutputTemplate:
+		// all columns are available to template
+		return renderTemplate(w, o.Template, columns, items)
+	}
+	return fmt.Errorf("output: unsupported format %q", o.Format)
+}
+
+func renderTable[T any](w io.Writer, columns []Column[T], items []T) {
+	headers := make([]string, len(columns))
+	wrap := false
+	for i, c := range columns {
+		headers[i] = c.header()
+		wrap = wrap || c.Wrap
+	}
+	t := table.New().
+		Border(lipgloss.ASCIIBorder()).
+		Headers(headers...).
+		StyleFunc(func(row, col int) lipgloss.Style {
+			if row == table.HeaderRow {
+				return DefaultHeaderStyle()
+			}
+			if width := columns[col].Width; width > 0 {
+				return DefaultCellStyle().Width(width)
+			}
+			return DefaultCellStyle()
+		}).
+		Wrap(wrap)
+	for _, item := range items {
+		row := make([]string, len(columns))
+		for i, c := range columns {
+			row[i] = c.text(item)
+		}
+		t.Row(row...)
+	}
+	_, _ = fmt.Fprintln(w, t)
+}
+
+// record is a json object which keeps the order of columns.
+type record struct {
+	keys   []string
+	values []any
+}
+
+func newRecord[T any](columns []Column[T], item T) record {
+	r := record{keys: make([]string, len(columns)), values: make([]any, len(columns))}
+	for i, c := range columns {
+		r.keys[i] = c.Name
+		r.values[i] = c.Value(item)
+	}
+	return r
+}
+
+func (r record) MarshalJSON() ([]byte, error) {
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, key := range r.keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		k, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		v, err := json.Marshal(r.values[i])
+		if err != nil {
+			return nil, err
+		}
+		b.Write(k)
+		b.WriteByte(':')
+		b.Write(v)
+	}
+	b.WriteByte('}')
+	return []byte(b.String()), nil
+}
+
+func (r record) yamlNode() (*yaml.Node, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	for i, key := range r.keys {
+		k := &yaml.Node{}
+		k.SetString(key)
+		v := &yaml.Node{}
+		if err := v.Encode(r.values[i]); err != nil {
+			return nil, err
+		}
+		node.Content = append(node.Content, k, v)
+	}
+	return node, nil
+}
+
+func renderJSON[T any](w io.Writer, columns []Column[T], items []T) error {
+	records := make([]record, len(items))
+	for i, item := range items {
+		records[i] = newRecord(columns, item)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(records)
+}
+
+func renderYAML[T any](w io.Writer, columns []Column[T], items []T) error {
+	doc := &yaml.Node{Kind: yaml.SequenceNode}
+	for _, item := range items {
+		node, err := newRecord(columns, item).yamlNode()
+		if err != nil {
+			return err
+		}
+		doc.Content = append(doc.Content, node)
+	}
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func renderCSV[T any](w io.Writer, comma rune, columns []Column[T], items []T) error {
+	cw := csv.NewWriter(w)
+	cw.Comma = comma
+	if err := cw.Write(ColumnNames(columns)); err != nil {
+		return err
+	}
+	for _, item := range items {
+		row := make([]string, len(columns))
+		for i, c := range columns {
+			row[i] = FormatValue(c.Value(item))
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+var templateFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"join": func(sep string, v []string) string {
+		return strings.Join(v, sep)
+	},
+	"size": func(v any) (string, error) {
+		var bytes int64
+		switch v := v.(type) {
+		case int64:
+			bytes = v
+		case int:
+			bytes = int64(v)
+		case float64:
+			bytes = int64(v)
+		case json.Number:
+			n, err := v.Float64()
+			if err != nil {
+				return "", err
+			}
+			bytes = int64(n)
+		default:
+			return "", fmt.Errorf("size: unsupported value %v", v)
+		}
+		return FormatFileSizeAuto(uint64(bytes), 1), nil
+	},
+}
+
+func renderTemplate[T any](w io.Writer, text string, columns []Column[T], items []T) error {
+	tmpl, err := template.New("output").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return fmt.Errorf("output: %w", err)
+	}
+	for _, item := range items {
+		data := make(map[string]any, len(columns))
+		for _, c := range columns {
+			data[c.Name] = c.Value(item)
+		}
+		if err := tmpl.Execute(w, data); err != nil {
+			return fmt.Errorf("output: %w", err)
+		}
+		if !strings.HasSuffix(text, "\n") {
+			_, _ = io.WriteString(w, "\n")
+		}
+	}
+	return nil
+}
+
+// RenderObject writes a single object like details of a torrent in the output format,
+// keys are the json names of fields. Table is rendered by callers and csv and tsv are not supported.
+func RenderObject(w io.Writer, o Output, v any) error {
+	switch o.Format {
+	case OutputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case OutputYAML:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		// json is yaml, decoding it to node keeps the order of fields
+		var doc yaml.Node
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return err
+		}
+		clearStyle(&doc)
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(&doc); err != nil {
+			return err
+		}
+		return enc.Close()
+	case OutputTemplate:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		var fields any
+		dec := json.NewDecoder(strings.NewReader(string(data)))
+		dec.UseNumber()
+		if err := dec.Decode(&fields); err != nil {
+			return err
+		}
+		tmpl, err := template.New("output").Funcs(templateFuncs).Parse(o.Template)
+		if err != nil {
+			return fmt.Errorf("output: %w", err)
+		}
+		if err := tmpl.Execute(w, fields); err != nil {
+			return fmt.Errorf("output: %w", err)
+		}
+		if !strings.HasSuffix(o.Template, "\n") {
+			_, _ = io.WriteString(w, "\n")
+		}
+		return nil
+	}
+	return fmt.Errorf("output: format %q is not supported by details, use json, yaml or template", o.Format)
+}
+
+// clearStyle resets flow and quoted styles of json so that it is encoded as block yaml.
+func clearStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, n := range node.Content {
+		clearStyle(n)
+	}
+}
+
+// FormatValue formats raw value for csv and table, numbers are not humanized.
+func FormatValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []string:
+		return strings.Join(v, ",")
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return v.Format(time.RFC3339)
+	case fmt.Stringer:
+		return v.String()
+	}
+	return fmt.Sprint(v)
+}
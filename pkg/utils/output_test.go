@@ -0,0 +1,93 @@
+package utils
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+type outputRow struct {
+	name string
+	size int64
+	tags []string
+}
+
+var outputColumns = []Column[outputRow]{
+	{Name: "name", Value: func(r outputRow) any { return r.name }},
+	{Name: "size", Value: func(r outputRow) any { return r.size },
+		Text: func(r outputRow) string { return FormatFileSizeAuto(uint64(r.size), 1) }},
+	{Name: "tags", Value: func(r outputRow) any { return r.tags }, Hidden: true},
+}
+
+var outputRows = []outputRow{
+	{name: "ubuntu, 24.04", size: 2048, tags: []string{"linux", "iso"}},
+	{name: "debian", size: 10},
+}
+
+func render(t *testing.T, value string, columns ...string) string {
+	t.Helper()
+	o, err := ParseOutput(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o.Columns = columns
+	var buf bytes.Buffer
+	if err := Render(&buf, o, outputColumns, outputRows); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		output  string
+		columns []string
+		want    string
+	}{
+		{"csv", nil, "name,size\n\"ubuntu, 24.04\",2048\ndebian,10\n"},
+		{"tsv", []string{"size", "tags"}, "size\ttags\n2048\tlinux,iso\n10\t\n"},
+		{"json", []string{"size", "name"}, `[
+  {
+    "size": 2048,
+    "name": "ubuntu, 24.04"
+  },
+  {
+    "size": 10,
+    "name": "debian"
+  }
+]
+`},
+		{"yaml", []string{"name", "tags"}, `- name: ubuntu, 24.04
+  tags:
+    - linux
+    - iso
+- name: debian
+  tags: []
+`},
+		{"template={{.name}}: {{size .size}} {{join \"|\" .tags}}", nil, "ubuntu, 24.04: 2KB linux|iso\ndebian: 10B \n"},
+	}
+	for _, tt := range tests {
+		if got := render(t, tt.output, tt.columns...); got != tt.want {
+			t.Errorf("%s: got\n%s\nwant\n%s", tt.output, got, tt.want)
+		}
+	}
+
+	if got := render(t, "json"); !strings.Contains(got, `"tags"`) {
+		t.Errorf("json should contain hidden columns: %s", got)
+	}
+	if got := render(t, "table"); strings.Contains(got, "linux") || !strings.Contains(got, "2KB") {
+		t.Errorf("unexpected table:\n%s", got)
+	}
+}
+
+func TestRenderErrors(t *testing.T) {
+	if _, err := ParseOutput("xml"); err == nil {
+		t.Error("xml should be unsupported")
+	}
+	o, _ := ParseOutput("csv")
+	o.Columns = []string{"name", "unknown"}
+	err := Render(&bytes.Buffer{}, o, outputColumns, outputRows)
+	if err == nil || !strings.Contains(err.Error(), "name,size,tags") {
+		t.Errorf("got %v, want unknown column error with available columns", err)
+	}
+}
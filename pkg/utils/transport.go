@@ -0,0 +1,259 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// TransportOptions configures the RoundTripper stack built by NewTransport.
+type TransportOptions struct {
+	// MaxRetry is the max retry count of a request, 0 means no retry
+	MaxRetry int
+	// RetryWait is the initial backoff, doubled on every retry
+	RetryWait time.Duration
+	// MaxRetryWait caps the backoff and Retry-After
+	MaxRetryWait time.Duration
+	// RateLimit is the max requests per second per host, 0 means no limit
+	RateLimit float64
+	// RecordDir saves requests and responses as cassettes
+	RecordDir string
+	// ReplayDir serves responses from cassettes without network, retry and rate limit are disabled
+	ReplayDir string
+}
+
+// NewTransport wraps base with debug log, retry, rate limit and record:
+//
+//	DebugTransport -> RetryTransport -> RateLimitTransport -> RecordTransport -> base
+//
+// so that every retry is rate limited, recorded and logged with the same request id.
+func NewTransport(base http.RoundTripper, opts TransportOptions) http.RoundTripper {
+	if opts.ReplayDir != "" {
+		return &DebugTransport{Base: NewReplayTransport(opts.ReplayDir)}
+	}
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	var rt = base
+	if opts.RecordDir != "" {
+		rt = &RecordTransport{Base: rt, Dir: opts.RecordDir}
+	}
+	if opts.RateLimit > 0 {
+		rt = &RateLimitTransport{Base: rt, Interval: time.Duration(float64(time.Second) / opts.RateLimit)}
+	}
+	if opts.MaxRetry > 0 {
+		rt = &RetryTransport{Base: rt, MaxRetry: opts.MaxRetry, Wait: opts.RetryWait, MaxWait: opts.MaxRetryWait}
+	}
+	return &DebugTransport{Base: rt}
+}
+
+type requestIDKey struct{}
+
+var requestID atomic.Uint64
+
+// RequestID returns the id assigned by DebugTransport, 0 if not exists.
+func RequestID(ctx context.Context) uint64 {
+	id, _ := ctx.Value(requestIDKey{}).(uint64)
+	return id
+}
+
+// DebugTransport assigns an id to every request and logs method, url, status and duration.
+// Logs go to the standard logger which is discarded unless --debug is set.
+type DebugTransport struct {
+	Base http.RoundTripper
+}
+
+func (t *DebugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	id := requestID.Add(1)
+	req = req.WithContext(context.WithValue(req.Context(), requestIDKey{}, id))
+	start := time.Now()
+	log.Printf("[req-%d] %s %s\n", id, req.Method, redactURL(req.URL))
+	resp, err := t.Base.RoundTrip(req)
+	if err != nil {
+		log.Printf("[req-%d] failed after %s: %v\n", id, time.Since(start), err)
+		return nil, err
+	}
+	log.Printf("[req-%d] %s in %s\n", id, resp.Status, time.Since(start))
+	return resp, nil
+}
+
+var secretParams = []string{"apikey", "api_key", "token", "X-Emby-Token", "password"}
+
+// redactURL masks password and secret query params, e.g. Jackett apikey.
+func redactURL(u *url.URL) string {
+	if u.RawQuery == "" {
+		return u.Redacted()
+	}
+	r := *u
+	query := r.Query()
+	for _, p := range secretParams {
+		if query.Has(p) {
+			query.Set(p, "xxxxx")
+		}
+	}
+	r.RawQuery = query.Encode()
+	return r.Redacted()
+}
+
+// RetryTransport retries on 429, 5xx and connection errors with exponential backoff.
+// Retry-After header is honored. Requests whose body can't be rewound are not retried.
+// Requests which are not idempotent may have reached the server already, so that they are only retried
+// when the connection was refused or the server answered 429 or 503 without handling them.
+type RetryTransport struct {
+	Base     http.RoundTripper
+	MaxRetry int
+	Wait     time.Duration
+	MaxWait  time.Duration
+}
+
+const (
+	defaultRetryWait    = time.Second
+	defaultMaxRetryWait = 30 * time.Second
+)
+
+func (t *RetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	for attempt := 0; ; attempt++ {
+		resp, err := t.Base.RoundTrip(req)
+		if attempt >= t.MaxRetry || !retryable(req, resp, err) || (req.Body != nil && req.GetBody == nil) {
+			return resp, err
+		}
+
+		wait := t.backoff(attempt, resp)
+		if err != nil {
+			log.Printf("[req-%d] retry %d/%d in %s: %v\n", RequestID(ctx), attempt+1, t.MaxRetry, wait, err)
+		} else {
+			log.Printf("[req-%d] retry %d/%d in %s: %s\n", RequestID(ctx), attempt+1, t.MaxRetry, wait, resp.Status)
+			// drain body to reuse the connection
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+			SafeClose(resp.Body)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(ctx)
+			req.Body = body
+		}
+	}
+}
+
+func (t *RetryTransport) backoff(attempt int, resp *http.Response) time.Duration {
+	wait, maxWait := t.Wait, t.MaxWait
+	if wait <= 0 {
+		wait = defaultRetryWait
+	}
+	if maxWait <= 0 {
+		maxWait = defaultMaxRetryWait
+	}
+	if resp != nil {
+		if d, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+			return min(d, maxWait)
+		}
+	}
+	d := wait << attempt
+	if d <= 0 || d > maxWait {
+		d = maxWait
+	}
+	// jitter up to 1/4 of the backoff, so that parallel workers don't retry at the same time
+	return d + rand.N(d/4+1)
+}
+
+// retryAfter parses Retry-After header, both seconds and http date are supported.
+func retryAfter(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if s, err := strconv.Atoi(v); err == nil && s >= 0 {
+		return time.Duration(s) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(time.Until(t), 0), true
+	}
+	return 0, false
+}
+
+func retryable(req *http.Request, resp *http.Response, err error) bool {
+	if err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return false
+		}
+		if errors.Is(err, syscall.ECONNREFUSED) {
+			return true
+		}
+		return idempotent(req) && (errors.Is(err, syscall.ECONNRESET) ||
+			errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF))
+	}
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+		return true
+	}
+	return idempotent(req) && resp.StatusCode >= 500 && resp.StatusCode != http.StatusNotImplemented
+}
+
+// idempotent reports whether the request can be sent twice, other methods can be marked by Idempotency-Key header
+// like net/http does.
+func idempotent(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	_, ok := req.Header["Idempotency-Key"]
+	if !ok {
+		_, ok = req.Header["X-Idempotency-Key"]
+	}
+	return ok
+}
+
+// RateLimitTransport spaces requests to the same host by Interval.
+// Slots are shared by all RateLimitTransport, so that clients of different jobs hitting one host are limited together.
+type RateLimitTransport struct {
+	Base     http.RoundTripper
+	Interval time.Duration
+}
+
+var (
+	hostSlotsLock sync.Mutex
+	hostSlots     = make(map[string]time.Time)
+)
+
+func (t *RateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if wait := t.reserve(req.URL.Host); wait > 0 {
+		log.Printf("[req-%d] rate limited, wait %s\n", RequestID(req.Context()), wait)
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(wait):
+		}
+	}
+	return t.Base.RoundTrip(req)
+}
+
+// reserve books the next slot of host and returns how long to wait for it.
+func (t *RateLimitTransport) reserve(host string) time.Duration {
+	hostSlotsLock.Lock()
+	defer hostSlotsLock.Unlock()
+	now := time.Now()
+	slot := hostSlots[host]
+	if slot.Before(now) {
+		slot = now
+	}
+	hostSlots[host] = slot.Add(t.Interval)
+	return slot.Sub(now)
+}
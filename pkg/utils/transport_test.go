@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryTransport(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch hits.Add(1) {
+		case 1:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 2:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: NewTransport(nil, TransportOptions{MaxRetry: 3, RetryWait: time.Millisecond})}
+	resp, err := client.Post(server.URL, "text/plain", strings.NewReader("body"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	SafeClose(resp.Body)
+	if resp.StatusCode != http.StatusOK || hits.Load() != 3 {
+		t.Fatalf("got %d after %d requests, want 200 after 3", resp.StatusCode, hits.Load())
+	}
+}
+
+func TestRetryTransportGiveUp(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: NewTransport(nil, TransportOptions{MaxRetry: 2, RetryWait: time.Millisecond})}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	SafeClose(resp.Body)
+	if resp.StatusCode != http.StatusBadGateway || hits.Load() != 3 {
+		t.Fatalf("got %d after %d requests, want 502 after 3", resp.StatusCode, hits.Load())
+	}
+}
+
+func TestRetryTransportNotIdempotent(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	// the post may have been handled by the server, it must not be sent twice
+	client := &http.Client{Transport: NewTransport(nil, TransportOptions{MaxRetry: 2, RetryWait: time.Millisecond})}
+	resp, err := client.Post(server.URL, "text/plain", strings.NewReader("body"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	SafeClose(resp.Body)
+	if resp.StatusCode != http.StatusBadGateway || hits.Load() != 1 {
+		t.Fatalf("got %d after %d requests, want 502 after 1", resp.StatusCode, hits.Load())
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	if d, ok := retryAfter("2"); !ok || d != 2*time.Second {
+		t.Fatalf("retryAfter(2) = %s %v", d, ok)
+	}
+	date := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	if d, ok := retryAfter(date); !ok || d <= 0 || d > time.Minute {
+		t.Fatalf("retryAfter(%s) = %s %v", date, d, ok)
+	}
+	if _, ok := retryAfter("soon"); ok {
+		t.Fatal("invalid Retry-After should be ignored")
+	}
+}
+
+func TestRateLimitTransport(t *testing.T) {
+	rt := &RateLimitTransport{Interval: 50 * time.Millisecond}
+	if wait := rt.reserve("rate.test"); wait != 0 {
+		t.Fatalf("first request waits %s", wait)
+	}
+	if wait := rt.reserve("rate.test"); wait <= 0 {
+		t.Fatal("second request should wait")
+	}
+	if wait := rt.reserve("other.test"); wait != 0 {
+		t.Fatalf("other host waits %s", wait)
+	}
+}
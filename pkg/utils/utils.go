@@ -49,13 +49,18 @@ func CmdRun(cmd string, args []string, defaultCmd string, defaultArgs []string)
 	return nil
 }
 
-func DownloadUrlToFile(file, url string) error {
-	resp, err := http.Get(url)
+// DownloadUrlToFile saves url to file by client, which should be built by api.NewHttpClient so that retry,
+// rate limit and record follow the http block of config file.
+func DownloadUrlToFile(client *http.Client, file, url string) error {
+	resp, err := client.Get(url)
 	if err != nil {
 		log.Println(err)
 		return err
 	}
 	defer SafeClose(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("download %s: %s", url, resp.Status)
+	}
 	out, err := os.Create(file)
 	if err != nil {
 		log.Println(err)
@@ -418,3 +423,26 @@ func FormatFileSizeAuto(bytes uint64, decimal int) string {
 
 	return str + unit
 }
+
+var fileSizeUnits = map[string]int64{
+	"": 1, "b": 1,
+	"k": 1 << 10, "kb": 1 << 10, "kib": 1 << 10,
+	"m": 1 << 20, "mb": 1 << 20, "mib": 1 << 20,
+	"g": 1 << 30, "gb": 1 << 30, "gib": 1 << 30,
+	"t": 1 << 40, "tb": 1 << 40, "tib": 1 << 40,
+}
+
+// ParseFileSize parses sizes like 512KB, 4MiB or 1.5GB, units are 1024 based and case-insensitive, bare numbers are bytes.
+func ParseFileSize(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	if i < 0 {
+		i = len(s)
+	}
+	n, err := strconv.ParseFloat(s[:i], 64)
+	unit, ok := fileSizeUnits[strings.ToLower(strings.TrimSpace(s[i:]))]
+	if err != nil || !ok || n < 0 {
+		return 0, fmt.Errorf("invalid size %q, e.g. 512KB, 4MB or 1GB", s)
+	}
+	return int64(n * float64(unit)), nil
+}
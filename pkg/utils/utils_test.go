@@ -37,3 +37,17 @@ func TestFormatFileSize(t *testing.T) {
 	println(FormatFileSizeAuto(1024, 0))
 	println(FormatFileSizeAuto(124, 0))
 }
+
+func TestParseFileSize(t *testing.T) {
+	tests := map[string]int64{"100": 100, "16KB": 16 << 10, "4MiB": 4 << 20, "1.5gb": 3 << 29, "2 TB": 2 << 40}
+	for s, want := range tests {
+		if got, err := ParseFileSize(s); err != nil || got != want {
+			t.Errorf("ParseFileSize(%q) = %d, %v, want %d", s, got, err, want)
+		}
+	}
+	for _, s := range []string{"", "MB", "4XB", "-1KB"} {
+		if _, err := ParseFileSize(s); err == nil {
+			t.Errorf("ParseFileSize(%q) should fail", s)
+		}
+	}
+}